@@ -0,0 +1,215 @@
+package api
+
+// Adapters let the endpoint packages, which only know OpenAI routes such as /v1/chat/completions, talk to APIs which
+// route or authenticate requests differently. An adapter rewrites routes, and an auth scheme puts the api key on requests.
+// Both are selected by the settings (per context) and can be overridden by profiles.
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+)
+
+const (
+	AdapterOpenAI = "openai"
+	AdapterAzure  = "azure"
+)
+
+const (
+	AuthBearer = "bearer"
+	AuthApiKey = "api-key"
+	AuthHeader = "header"
+	AuthNone   = "none"
+)
+
+type Adapter interface {
+	// Returns the url of an OpenAI route, given the base url, the adapter settings and the model of the request when known.
+	Url(baseUrl string, route string, s config.AdapterSettings, model string) (string, error)
+	// Auth scheme used when none is set.
+	DefaultAuthScheme() string
+}
+
+// Sets the api key on a request. The key is empty with the none scheme.
+type AuthScheme func(req *http.Request, apiKey string, s config.AdapterSettings) error
+
+var adapters = map[string]Adapter{
+	AdapterOpenAI: openAIAdapter{},
+	AdapterAzure:  azureAdapter{},
+}
+
+var authSchemes = map[string]AuthScheme{
+	AuthBearer: func(req *http.Request, apiKey string, s config.AdapterSettings) error {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		return nil
+	},
+	AuthApiKey: func(req *http.Request, apiKey string, s config.AdapterSettings) error {
+		req.Header.Set("api-key", apiKey)
+		return nil
+	},
+	AuthHeader: func(req *http.Request, apiKey string, s config.AdapterSettings) error {
+		if s.AuthHeader == "" {
+			return errors.New("the " + AuthHeader + " auth scheme requires the AuthHeader setting, naming the header holding the api key")
+		}
+
+		req.Header.Set(s.AuthHeader, apiKey)
+		return nil
+	},
+	AuthNone: func(req *http.Request, apiKey string, s config.AdapterSettings) error {
+		return nil
+	},
+}
+
+// Makes an adapter selectable by name, replacing any adapter with the same name.
+func RegisterAdapter(name string, a Adapter) {
+	adapters[name] = a
+}
+
+// Makes an auth scheme selectable by name, replacing any scheme with the same name.
+func RegisterAuthScheme(name string, scheme AuthScheme) {
+	authSchemes[name] = scheme
+}
+
+func Adapters() []string {
+	return sortedKeys(adapters)
+}
+
+func AuthSchemes() []string {
+	return sortedKeys(authSchemes)
+}
+
+func sortedKeys[T any](m map[string]T) (names []string) {
+	for name := range m {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return
+}
+
+// Returns the adapter settings of a request: the configured ones, overridden by the ones set in the profile.
+func adapterSettings(p profile.Profile) config.AdapterSettings {
+	s := config.Adapter()
+	if p == nil {
+		return s
+	}
+
+	o := p.ProfileOptions()
+	for _, override := range []struct {
+		setting *string
+		value   string
+	}{
+		{&s.Adapter, o.Adapter},
+		{&s.AuthScheme, o.AuthScheme},
+		{&s.AuthHeader, o.AuthHeader},
+		{&s.ApiVersion, o.ApiVersion},
+		{&s.Deployment, o.Deployment},
+	} {
+		if override.value != "" {
+			*override.setting = override.value
+		}
+	}
+
+	return s
+}
+
+func getAdapter(s config.AdapterSettings) (Adapter, error) {
+	name := s.Adapter
+	if name == "" {
+		name = AdapterOpenAI
+	}
+
+	a, ok := adapters[name]
+	if !ok {
+		return nil, errors.New("unknown adapter " + name + ", adapters are: " + strings.Join(Adapters(), ", "))
+	}
+
+	return a, nil
+}
+
+func getAuthScheme(s config.AdapterSettings) (scheme AuthScheme, name string, err error) {
+	a, err := getAdapter(s)
+	if err != nil {
+		return
+	}
+
+	name = s.AuthScheme
+	if name == "" {
+		name = a.DefaultAuthScheme()
+	}
+
+	scheme, ok := authSchemes[name]
+	if !ok {
+		err = errors.New("unknown auth scheme " + name + ", auth schemes are: " + strings.Join(AuthSchemes(), ", "))
+	}
+
+	return
+}
+
+type openAIAdapter struct{}
+
+func (openAIAdapter) Url(baseUrl string, route string, s config.AdapterSettings, model string) (string, error) {
+	return baseUrl + route, nil
+}
+
+func (openAIAdapter) DefaultAuthScheme() string {
+	return AuthBearer
+}
+
+// Azure OpenAI serves models from deployments, ex: /openai/deployments/{name}/chat/completions?api-version=...,
+// while other routes, such as files or fine-tuning jobs, are served under /openai.
+type azureAdapter struct{}
+
+// Routes served from a deployment.
+var azureDeploymentRoutes = []string{
+	"/v1/chat/completions",
+	"/v1/completions",
+	"/v1/embeddings",
+	"/v1/audio/",
+	"/v1/images/",
+}
+
+func (azureAdapter) Url(baseUrl string, route string, s config.AdapterSettings, model string) (string, error) {
+	if s.ApiVersion == "" {
+		return "", errors.New("the " + AdapterAzure + " adapter requires the ApiVersion setting, ex: 2024-06-01")
+	}
+
+	path := "/openai" + strings.TrimPrefix(route, "/v1")
+	for _, prefix := range azureDeploymentRoutes {
+		if !strings.HasPrefix(route, prefix) {
+			continue
+		}
+
+		deployment := s.Deployment
+		if deployment == "" {
+			deployment = model
+		}
+
+		if deployment == "" {
+			return "", errors.New("the " + AdapterAzure + " adapter requires a deployment for " + route + ", set the Deployment setting or the model of the request")
+		}
+
+		path = "/openai/deployments/" + url.PathEscape(deployment) + strings.TrimPrefix(route, "/v1")
+		break
+	}
+
+	return baseUrl + path + "?api-version=" + url.QueryEscape(s.ApiVersion), nil
+}
+
+func (azureAdapter) DefaultAuthScheme() string {
+	return AuthApiKey
+}
+
+// Returns an error when the adapter or auth scheme of the settings is unknown, or when a setting they require is missing.
+func ValidateAdapter(s config.AdapterSettings) (err error) {
+	_, name, err := getAuthScheme(s)
+	if err == nil && name == AuthHeader && s.AuthHeader == "" {
+		err = errors.New("the " + AuthHeader + " auth scheme requires a header name")
+	}
+
+	return
+}
@@ -0,0 +1,353 @@
+package api
+
+// Responses to deterministic requests can be cached on disk, which avoids paying again for prompts and embeddings inputs
+// that scripts send repeatedly. Entries are keyed on the method, the url (base url and route) and the normalized request body.
+// The cache is enabled per profile through its Cache option, or for every request with the --cache flag.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/client"
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/lockedfile"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/log"
+)
+
+const cacheEntryExtension = ".json"
+
+// Routes whose responses can be cached, along with the temperature the API uses when a request does not set one.
+// Other routes either have side effects (files, batches, fine-tuning) or return different results on each call (images).
+var cacheableRoutes = map[string]float64{
+	"/v1/chat/completions":     1,
+	"/v1/embeddings":           0,
+	"/v1/audio/speech":         0,
+	"/v1/audio/transcriptions": 0,
+	"/v1/audio/translations":   0,
+}
+
+var cacheEnabled bool
+
+type cacheEntry struct {
+	Url     string
+	Route   string
+	Created time.Time
+	Body    []byte
+}
+
+type CacheStats struct {
+	Directory string
+	Entries   int
+	Expired   int
+	Size      int64
+	MaxSize   int64
+	TTL       string
+	Oldest    *time.Time `json:",omitempty"`
+	Newest    *time.Time `json:",omitempty"`
+}
+
+// Enables the cache for every request, regardless of the profile's Cache option.
+func SetCacheEnabled(enabled bool) {
+	cacheEnabled = enabled
+}
+
+// Directory in which cached responses are stored.
+func CacheDir() (dir string, err error) {
+	if config.CacheDir == "" {
+		err = errors.New("the cache directory is not set")
+		return
+	}
+
+	dir = filepath.Join(config.CacheDir, "responses")
+	return
+}
+
+// Returns the cache key of a request, and whether its response can be cached.
+// Params holds the request's parameters, used to bypass the cache for requests which are not deterministic.
+func cacheKey(p profile.Profile, method string, rawUrl string, route string, contentType string, body []byte, params map[string]any) (key string, ok bool) {
+	if !cacheEnabled && (p == nil || !p.ProfileOptions().Cache) {
+		return
+	}
+
+	// Cassettes must see every request, cached responses would be missing from recordings.
+	if cassetteMode != "" {
+		return
+	}
+
+	// Dry runs print every request rather than the cached response
+	if DryRun() {
+		return
+	}
+
+	if method != "POST" || !deterministic(route, params) {
+		log.Debug("Request to %s is not deterministic, bypassing the cache\n", route)
+		return
+	}
+
+	sum := sha256.Sum256([]byte(method + "\n" + rawUrl + "\n" + normalizeBody(contentType, body)))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// Requests setting a seed are considered deterministic, others only when their temperature is 0.
+func deterministic(route string, params map[string]any) bool {
+	defaultTemperature, ok := cacheableRoutes[route]
+	if !ok {
+		return false
+	}
+
+	if seed, ok := params["seed"]; ok && seed != nil {
+		return true
+	}
+
+	temperature := defaultTemperature
+	switch t := params["temperature"].(type) {
+	case float64:
+		temperature = t
+	case string:
+		var err error
+		temperature, err = strconv.ParseFloat(t, 64)
+		if err != nil {
+			return false
+		}
+	}
+
+	return temperature == 0
+}
+
+// Serves the responses of deterministic requests from the cache, and caches the responses of the ones it misses.
+func cacheMiddleware(p profile.Profile) client.Middleware {
+	return func(ctx context.Context, r *client.Request, next client.Sender) (buf []byte, err error) {
+		key, cacheable := cacheKey(p, r.Method, r.Url, r.Route, r.ContentType, r.Body, r.Params)
+		if cacheable {
+			if cached, ok := readCache(key); ok {
+				return cached, nil
+			}
+		}
+
+		buf, err = next(ctx, r)
+		if err == nil && cacheable {
+			writeCache(key, r.Url, r.Route, buf)
+		}
+
+		return
+	}
+}
+
+func cacheEntryPath(key string) (path string, err error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return
+	}
+
+	path = filepath.Join(dir, key+cacheEntryExtension)
+	return
+}
+
+// Returns the cached response body for a key, if there is one which has not expired.
+func readCache(key string) (body []byte, ok bool) {
+	path, err := cacheEntryPath(key)
+	if err != nil {
+		return
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var entry cacheEntry
+	err = json.Unmarshal(buf, &entry)
+	if err != nil || time.Since(entry.Created) > config.CacheTTL() {
+		os.Remove(path)
+		return
+	}
+
+	// The modification time tracks when entries were last used, so that the least recently used are evicted first
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	log.Debug("Serving response to %s from the cache\n", entry.Route)
+	return entry.Body, true
+}
+
+// Caches a response body. Failures are only logged, the response is still returned to the caller.
+func writeCache(key string, rawUrl string, route string, body []byte) {
+	path, err := cacheEntryPath(key)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0700)
+	}
+
+	var buf []byte
+	if err == nil {
+		buf, err = json.Marshal(cacheEntry{Url: rawUrl, Route: route, Created: time.Now(), Body: body})
+	}
+
+	if err == nil {
+		err = lockedfile.WriteFile(path, buf, 0600)
+	}
+
+	if err == nil {
+		err = evictCache()
+	}
+
+	if err != nil {
+		log.Warning("Unable to cache the response to %s: %s\n", route, err.Error())
+	}
+}
+
+type cacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func cacheFiles() (files []cacheFile, err error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), cacheEntryExtension) {
+			continue
+		}
+
+		info, infoErr := e.Info()
+		if infoErr != nil {
+			continue
+		}
+
+		files = append(files, cacheFile{path: filepath.Join(dir, e.Name()), size: info.Size(), modTime: info.ModTime()})
+	}
+
+	return
+}
+
+// Removes the least recently used entries until the cache is under its max size.
+// Expired entries are removed when they are read, or with ClearCache.
+func evictCache() (err error) {
+	files, err := cacheFiles()
+	if err != nil {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	var size int64
+	for _, f := range files {
+		size += f.size
+	}
+
+	for _, f := range files {
+		if size <= config.CacheMaxSize() {
+			break
+		}
+
+		err = os.Remove(f.path)
+		if err != nil {
+			return
+		}
+
+		size -= f.size
+	}
+
+	return
+}
+
+func expired(path string) bool {
+	created, err := entryCreated(path)
+	return err != nil || time.Since(created) > config.CacheTTL()
+}
+
+func entryCreated(path string) (created time.Time, err error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var entry struct{ Created time.Time }
+	err = json.Unmarshal(buf, &entry)
+	created = entry.Created
+	return
+}
+
+func GetCacheStats() (stats CacheStats, err error) {
+	stats.Directory, err = CacheDir()
+	if err != nil {
+		return
+	}
+
+	stats.MaxSize = config.CacheMaxSize()
+	stats.TTL = config.CacheTTL().String()
+
+	files, err := cacheFiles()
+	if err != nil {
+		return
+	}
+
+	for _, f := range files {
+		created, createdErr := entryCreated(f.path)
+		if createdErr != nil || time.Since(created) > config.CacheTTL() {
+			stats.Expired++
+		}
+
+		if createdErr == nil {
+			c := created
+			if stats.Oldest == nil || c.Before(*stats.Oldest) {
+				stats.Oldest = &c
+			}
+
+			if stats.Newest == nil || c.After(*stats.Newest) {
+				stats.Newest = &c
+			}
+		}
+
+		stats.Entries++
+		stats.Size += f.size
+	}
+
+	return
+}
+
+// Removes cached responses, only the expired ones when expiredOnly is set. Returns the number of entries removed.
+func ClearCache(expiredOnly bool) (removed int, err error) {
+	files, err := cacheFiles()
+	if err != nil {
+		return
+	}
+
+	for _, f := range files {
+		if expiredOnly && !expired(f.path) {
+			continue
+		}
+
+		err = os.Remove(f.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return
+		}
+
+		err = nil
+		removed++
+	}
+
+	return
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/config"
+)
+
+// Stores cached responses in a temporary directory, with the cache enabled for every request.
+func useCache(t *testing.T) {
+	t.Helper()
+
+	dir := config.CacheDir
+	config.CacheDir = t.TempDir()
+	SetCacheEnabled(true)
+	t.Cleanup(func() {
+		config.CacheDir = dir
+		SetCacheEnabled(false)
+		config.ResetFlagSettings()
+	})
+}
+
+func TestCacheKey(t *testing.T) {
+	useCache(t)
+
+	body := []byte(`{"model": "text-embedding-3-small", "input": "Hello"}`)
+	key, ok := cacheKey(nil, "POST", "https://api.openai.com/v1/embeddings", "/v1/embeddings", "application/json", body, map[string]any{})
+	if !ok {
+		t.Fatal("expected an embeddings request to be cached")
+	}
+
+	reordered := []byte(`{"input": "Hello", "model": "text-embedding-3-small"}`)
+	if other, _ := cacheKey(nil, "POST", "https://api.openai.com/v1/embeddings", "/v1/embeddings", "application/json", reordered, map[string]any{}); other != key {
+		t.Error("expected the key not to depend on the order of the body's fields")
+	}
+
+	if other, _ := cacheKey(nil, "POST", "https://proxy.example/v1/embeddings", "/v1/embeddings", "application/json", body, map[string]any{}); other == key {
+		t.Error("expected the key to depend on the url")
+	}
+
+	tests := []struct {
+		name   string
+		route  string
+		params map[string]any
+		ok     bool
+	}{
+		{"default temperature", "/v1/chat/completions", map[string]any{}, false},
+		{"temperature 0", "/v1/chat/completions", map[string]any{"temperature": 0.0}, true},
+		{"temperature flag", "/v1/chat/completions", map[string]any{"temperature": "0"}, true},
+		{"seed", "/v1/chat/completions", map[string]any{"seed": 42.0}, true},
+		{"images", "/v1/images/generations", map[string]any{"temperature": 0.0}, false},
+	}
+
+	for _, test := range tests {
+		_, ok := cacheKey(nil, "POST", "https://api.openai.com"+test.route, test.route, "application/json", body, test.params)
+		if ok != test.ok {
+			t.Errorf("%s: expected the request to be cacheable: %v", test.name, test.ok)
+		}
+	}
+
+	SetCacheEnabled(false)
+	if _, ok := cacheKey(nil, "POST", "https://api.openai.com/v1/embeddings", "/v1/embeddings", "application/json", body, map[string]any{}); ok {
+		t.Error("expected the cache to be bypassed when it is not enabled")
+	}
+}
+
+func TestCacheReadWrite(t *testing.T) {
+	useCache(t)
+
+	writeCache("fresh", "https://api.openai.com/v1/embeddings", "/v1/embeddings", []byte(`{"data": []}`))
+	body, ok := readCache("fresh")
+	if !ok || string(body) != `{"data": []}` {
+		t.Errorf("expected the cached body, got %s", body)
+	}
+
+	if _, ok = readCache("missing"); ok {
+		t.Error("expected a miss for a missing entry")
+	}
+
+	config.SetFlagSetting("CacheTTL", "1ms", "cache-ttl")
+	time.Sleep(5 * time.Millisecond)
+	if _, ok = readCache("fresh"); ok {
+		t.Error("expected an expired entry to be missed")
+	}
+
+	path, _ := cacheEntryPath("fresh")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected an expired entry to be removed when read")
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	useCache(t)
+
+	body := make([]byte, 1000)
+	for i := 0; i < 3; i++ {
+		writeCache("entry"+strconv.Itoa(i), "https://api.openai.com/v1/embeddings", "/v1/embeddings", body)
+		path, _ := cacheEntryPath("entry" + strconv.Itoa(i))
+		used := time.Now().Add(time.Duration(i-10) * time.Minute)
+		os.Chtimes(path, used, used)
+	}
+
+	// Reading the oldest entry makes it the most recently used
+	readCache("entry0")
+
+	stats, err := GetCacheStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.SetFlagSetting("CacheMaxSize", strconv.FormatInt(stats.Size-1, 10), "cache-max-size")
+	err = evictCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, kept := range []bool{true, false, true} {
+		if _, ok := readCache("entry" + strconv.Itoa(i)); ok != kept {
+			t.Errorf("entry%d: expected it to be kept: %v", i, kept)
+		}
+	}
+
+	removed, err := ClearCache(false)
+	if err != nil || removed != 2 {
+		t.Errorf("expected the 2 remaining entries to be removed, got %d (%v)", removed, err)
+	}
+}
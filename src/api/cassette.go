@@ -0,0 +1,377 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ephex2/go-gpt-cli/log"
+)
+
+// Cassettes record every request and response going through the api package to a file, so that they can be served back later on.
+// This allows the whole CLI to be run deterministically, without reaching the API, for tests and demos.
+const (
+	CassetteModeRecord = "record"
+	CassetteModeReplay = "replay"
+)
+
+const redactedHeaderValue = "REDACTED"
+
+// The cassette mode currently in use, empty when cassettes are disabled.
+var cassetteMode string
+
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method         string      `json:"method"`
+	Route          string      `json:"route"`
+	Url            string      `json:"url"`
+	Headers        http.Header `json:"headers"`
+	Body           string      `json:"body"`
+	BodyEncoding   string      `json:"body_encoding,omitempty"` // "base64" when the body is not valid utf-8, empty otherwise
+	NormalizedBody string      `json:"normalized_body"`
+}
+
+type RecordedResponse struct {
+	StatusCode   int         `json:"status_code"`
+	Status       string      `json:"status"`
+	Headers      http.Header `json:"headers"`
+	Body         string      `json:"body"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+}
+
+// cassetteTransport is an http.RoundTripper which either records interactions performed by its next RoundTripper,
+// or replays interactions previously recorded without performing any request.
+type cassetteTransport struct {
+	mode     string
+	path     string
+	next     http.RoundTripper
+	client   *http.Client // Client of the api package before the cassette was set, restored when it is unset
+	mu       sync.Mutex
+	cassette Cassette
+	used     map[int]bool
+}
+
+// Enables recording to, or replaying from, the cassette file at path for every request made through the api package.
+// When recording, interactions are appended to the cassette if it already exists. An empty mode disables the cassette
+// set by a previous execution.
+func SetCassette(mode string, path string) (err error) {
+	if t, ok := httpClient.Transport.(*cassetteTransport); ok {
+		httpClient = t.client
+	}
+
+	cassetteMode = ""
+	if mode == "" {
+		return
+	}
+
+	if path == "" {
+		err = errors.New("a cassette file path must be provided when using cassette mode " + mode)
+		return
+	}
+
+	t := &cassetteTransport{
+		mode:   strings.ToLower(mode),
+		path:   path,
+		next:   httpClient.Transport,
+		client: httpClient,
+		used:   make(map[int]bool),
+	}
+
+	if t.next == nil {
+		t.next = http.DefaultTransport
+	}
+
+	switch t.mode {
+	case CassetteModeRecord:
+		_, statErr := os.Stat(path)
+		if statErr == nil {
+			err = t.load()
+		}
+	case CassetteModeReplay:
+		err = t.load()
+	default:
+		err = errors.New("cassette mode not supported: " + mode + ". Supported modes are: " + CassetteModeRecord + ", " + CassetteModeReplay)
+	}
+
+	if err != nil {
+		return
+	}
+
+	cassetteMode = t.mode
+	httpClient = &http.Client{Transport: t}
+	return
+}
+
+// Returns true when requests are served from a cassette rather than from the API.
+func Replaying() bool {
+	return cassetteMode == CassetteModeReplay
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return
+	}
+
+	normalizedBody := normalizeBody(req.Header.Get("Content-Type"), body)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.mode == CassetteModeReplay {
+		return t.replay(req, normalizedBody)
+	}
+
+	res, err = t.next.RoundTrip(req)
+	if err != nil {
+		return
+	}
+
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	reqBodyString, reqEncoding := encodeBody(body)
+	resBodyString, resEncoding := encodeBody(resBody)
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method:         req.Method,
+			Route:          route(req),
+			Url:            req.URL.String(),
+			Headers:        redactHeaders(req.Header),
+			Body:           reqBodyString,
+			BodyEncoding:   reqEncoding,
+			NormalizedBody: normalizedBody,
+		},
+		Response: RecordedResponse{
+			StatusCode:   res.StatusCode,
+			Status:       res.Status,
+			Headers:      redactHeaders(res.Header),
+			Body:         resBodyString,
+			BodyEncoding: resEncoding,
+		},
+	}
+
+	t.cassette.Interactions = append(t.cassette.Interactions, interaction)
+	err = t.save()
+	return
+}
+
+// Serves the first unused interaction matching the request's method, route and normalized body.
+// Once all matching interactions have been used, the last one keeps being served.
+func (t *cassetteTransport) replay(req *http.Request, normalizedBody string) (res *http.Response, err error) {
+	r := route(req)
+	match := -1
+
+	for i, interaction := range t.cassette.Interactions {
+		if interaction.Request.Method != req.Method || interaction.Request.Route != r || interaction.Request.NormalizedBody != normalizedBody {
+			continue
+		}
+
+		match = i
+		if !t.used[i] {
+			break
+		}
+	}
+
+	if match == -1 {
+		err = errors.New("no interaction recorded in cassette " + t.path + " matches request: " + req.Method + " " + r)
+		return
+	}
+
+	t.used[match] = true
+	recorded := t.cassette.Interactions[match].Response
+	log.Debug("Replaying interaction %d from cassette for request: %s %s\n", match, req.Method, r)
+
+	body, err := decodeBody(recorded.Body, recorded.BodyEncoding)
+	if err != nil {
+		return
+	}
+
+	res = &http.Response{
+		Status:        recorded.Status,
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Headers.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+
+	if res.Header == nil {
+		res.Header = make(http.Header)
+	}
+
+	return
+}
+
+func (t *cassetteTransport) load() (err error) {
+	buf, err := os.ReadFile(t.path)
+	if err != nil {
+		return
+	}
+
+	if len(buf) == 0 {
+		return
+	}
+
+	err = json.Unmarshal(buf, &t.cassette)
+	if err != nil {
+		err = errors.New("unable to parse cassette file " + t.path + ": " + err.Error())
+	}
+
+	return
+}
+
+func (t *cassetteTransport) save() (err error) {
+	buf, err := json.MarshalIndent(t.cassette, "", "    ")
+	if err != nil {
+		return
+	}
+
+	log.Debug("Writing cassette file to path: %s\n", t.path)
+	err = os.WriteFile(t.path, buf, 0600)
+	return
+}
+
+// Reads the body of a request and replaces it so that it can be read again by the next RoundTripper.
+func readRequestBody(req *http.Request) (body []byte, err error) {
+	if req.Body == nil {
+		return
+	}
+
+	body, err = io.ReadAll(req.Body)
+	if err != nil {
+		return
+	}
+	req.Body.Close()
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return
+}
+
+func route(req *http.Request) string {
+	if req.URL.RawQuery == "" {
+		return req.URL.Path
+	}
+
+	return req.URL.Path + "?" + req.URL.RawQuery
+}
+
+// Secrets are never written to a cassette file: request and response headers are redacted with the rules of the logs,
+// which cover the Authorization and api-key headers, the header of the header auth scheme, cookies, and extra headers
+// named like a secret.
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for name := range out {
+		if log.IsSecretHeader(name) {
+			out.Set(name, redactedHeaderValue)
+		}
+	}
+
+	return out
+}
+
+// Returns a representation of the body which does not depend on json key ordering, multipart boundaries or multipart field ordering.
+func normalizeBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err == nil && mediaType == "multipart/form-data" {
+		normalized, err := normalizeMultipart(body, params["boundary"])
+		if err == nil {
+			return normalized
+		}
+	}
+
+	var v any
+	err = json.Unmarshal(body, &v)
+	if err == nil {
+		// encoding/json sorts map keys, which gives us a stable representation
+		buf, err := json.Marshal(v)
+		if err == nil {
+			return string(buf)
+		}
+	}
+
+	return string(body)
+}
+
+func normalizeMultipart(body []byte, boundary string) (normalized string, err error) {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+
+	var fields []string
+	for {
+		var part *multipart.Part
+		part, err = reader.NextPart()
+		if err == io.EOF {
+			err = nil
+			break
+		}
+
+		if err != nil {
+			return
+		}
+
+		var content []byte
+		content, err = io.ReadAll(part)
+		if err != nil {
+			return
+		}
+
+		if part.FileName() != "" {
+			sum := sha256.Sum256(content)
+			fields = append(fields, part.FormName()+"=@"+part.FileName()+";sha256="+hex.EncodeToString(sum[:]))
+		} else {
+			fields = append(fields, part.FormName()+"="+string(content))
+		}
+	}
+
+	sort.Strings(fields)
+	normalized = strings.Join(fields, "\n")
+	return
+}
+
+func encodeBody(body []byte) (s string, encoding string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func decodeBody(s string, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(s)
+	}
+
+	return []byte(s), nil
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassetteRedactsHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "__cf_bm", Value: "session-secret"})
+		w.Header().Set("Openai-Organization", "org-test")
+		w.Write([]byte(`{"data": []}`))
+	}))
+	defer server.Close()
+
+	client := httpClient
+	t.Cleanup(func() { SetCassette("", ""); httpClient = client })
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	err := SetCassette(CassetteModeRecord, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer sk-test-0123456789")
+	res, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"sk-test-0123456789", "session-secret"} {
+		if strings.Contains(string(buf), secret) {
+			t.Errorf("expected %s to be redacted from the cassette, got: %s", secret, buf)
+		}
+	}
+
+	var c Cassette
+	err = json.Unmarshal(buf, &c)
+	if err != nil || len(c.Interactions) != 1 || c.Interactions[0].Response.Headers.Get("Openai-Organization") != "org-test" {
+		t.Errorf("expected the other response headers to be kept, got: %s", buf)
+	}
+}
+
+func TestUnsetCassette(t *testing.T) {
+	client := httpClient
+	t.Cleanup(func() { httpClient = client })
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	err := os.WriteFile(path, []byte(`{"interactions": []}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = SetCassette(CassetteModeReplay, path)
+	if err != nil || !Replaying() {
+		t.Fatalf("expected the cassette to be replayed, got: %v", err)
+	}
+
+	err = SetCassette("", "")
+	if err != nil || Replaying() || httpClient != client {
+		t.Errorf("expected the cassette to be unset and the client restored, got: %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
+)
+
+// Dry runs build requests as usual, from the profiles and settings, but print them instead of sending them: either as
+// a curl command, or as the raw http request. Secrets are redacted unless shown explicitly.
+//
+// The command stops once its first request is printed, as the request fails with ErrDryRun, before anything depending
+// on a response happens, such as writing the chat history or the files of a response. Requests depending on the
+// response of a previous one, such as the batch created from an uploaded file, are therefore never printed.
+const (
+	DryRunCurl = "curl"
+	DryRunHttp = "http"
+)
+
+// Returned by requests during dry runs, once they are printed. Commands stop on it like on any error, and exit with a
+// success status.
+var ErrDryRun = errors.New("dry run, the request was not sent")
+
+// The dry run format currently in use, empty when requests are sent.
+var dryRunFormat string
+var showSecrets bool
+
+// Prints requests in the given format instead of sending them, see DryRunCurl and DryRunHttp. Secrets are printed as is
+// when secrets is true. An empty format sends requests again.
+func SetDryRun(format string, secrets bool) (err error) {
+	if format != "" && format != DryRunCurl && format != DryRunHttp {
+		return errors.New("dry run format not supported: " + format + ". Supported formats are: " + DryRunCurl + ", " + DryRunHttp)
+	}
+
+	dryRunFormat = format
+	showSecrets = secrets
+	return
+}
+
+// Returns true when requests are printed rather than sent. Code with side effects taking place before a request, such
+// as persisting the prompt to a history, should skip them.
+func DryRun() bool {
+	return dryRunFormat != ""
+}
+
+type dryRunTransport struct {
+	format string
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return
+	}
+
+	var out string
+	if t.format == DryRunHttp {
+		out, err = httpCommand(req, body)
+	} else {
+		out, err = curlCommand(req, body)
+	}
+
+	if err != nil {
+		return
+	}
+
+	_, err = fmt.Fprint(output.Writer, out)
+	if err != nil {
+		return
+	}
+
+	// Nothing depending on a response may run, see the comment of DryRunCurl
+	return nil, ErrDryRun
+}
+
+// Returns the headers of a request sorted by name, with secrets redacted unless they are shown.
+func dryRunHeaders(h http.Header) (names []string, values map[string]string) {
+	if showSecrets {
+		values = make(map[string]string, len(h))
+		for k, v := range h {
+			values[k] = strings.Join(v, ", ")
+		}
+	} else {
+		values = log.Headers(h)
+	}
+
+	for k := range values {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+	return
+}
+
+func dryRunUrl(req *http.Request) string {
+	if showSecrets {
+		return req.URL.String()
+	}
+
+	return log.Redact(req.URL.String())
+}
+
+// Quotes a string for a posix shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Multipart bodies are printed as form fields, with -F, so that files are read from disk by curl. Files are referred
+// to by their name, relative to the directory the command is run from.
+func curlCommand(req *http.Request, body []byte) (cmd string, err error) {
+	var parts []formPart
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	isForm := mediaType == "multipart/form-data"
+	if isForm {
+		parts, err = readFormParts(body, params["boundary"])
+		if err != nil {
+			return
+		}
+	}
+
+	lines := []string{"curl -X " + req.Method + " " + shellQuote(dryRunUrl(req))}
+
+	names, values := dryRunHeaders(req.Header)
+	for _, name := range names {
+		// curl sets the content type of forms itself, along with their boundary
+		if isForm && http.CanonicalHeaderKey(name) == "Content-Type" {
+			continue
+		}
+
+		lines = append(lines, "-H "+shellQuote(name+": "+values[name]))
+	}
+
+	switch {
+	case isForm:
+		for _, part := range parts {
+			if part.fileName != "" {
+				lines = append(lines, "-F "+shellQuote(part.name+"=@"+part.fileName))
+			} else {
+				lines = append(lines, "-F "+shellQuote(part.name+"="+string(part.content)))
+			}
+		}
+	case len(body) > 0:
+		lines = append(lines, "--data-raw "+shellQuote(string(body)))
+	}
+
+	cmd = strings.Join(lines, " \\\n  ") + "\n"
+	return
+}
+
+// Binary contents, such as the files of a multipart body, are replaced by their size.
+func httpCommand(req *http.Request, body []byte) (out string, err error) {
+	var b strings.Builder
+	b.WriteString(req.Method + " " + req.URL.RequestURI() + " HTTP/1.1\n")
+	b.WriteString("Host: " + req.URL.Host + "\n")
+
+	names, values := dryRunHeaders(req.Header)
+	for _, name := range names {
+		b.WriteString(name + ": " + values[name] + "\n")
+	}
+
+	if len(body) > 0 {
+		b.WriteString("Content-Length: " + strconv.Itoa(len(body)) + "\n")
+	}
+
+	b.WriteString("\n")
+
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "multipart/form-data":
+		var parts []formPart
+		parts, err = readFormParts(body, params["boundary"])
+		if err != nil {
+			return
+		}
+
+		for _, part := range parts {
+			b.WriteString("--" + params["boundary"] + "\n")
+			disposition := `Content-Disposition: form-data; name="` + part.name + `"`
+			if part.fileName != "" {
+				disposition += `; filename="` + part.fileName + `"`
+			}
+
+			b.WriteString(disposition + "\n")
+			if part.contentType != "" {
+				b.WriteString("Content-Type: " + part.contentType + "\n")
+			}
+
+			b.WriteString("\n" + printableBody(part.content) + "\n")
+		}
+
+		b.WriteString("--" + params["boundary"] + "--\n")
+	case len(body) > 0:
+		b.WriteString(printableBody(body) + "\n")
+	}
+
+	out = b.String()
+	return
+}
+
+func printableBody(body []byte) string {
+	if utf8.Valid(body) && !bytes.ContainsRune(body, 0) {
+		return string(body)
+	}
+
+	return "[" + strconv.Itoa(len(body)) + " bytes of binary data]"
+}
+
+type formPart struct {
+	name        string
+	fileName    string
+	contentType string
+	content     []byte
+}
+
+func readFormParts(body []byte, boundary string) (parts []formPart, err error) {
+	r := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		var p *multipart.Part
+		p, err = r.NextPart()
+		if err == io.EOF {
+			err = nil
+			return
+		} else if err != nil {
+			return
+		}
+
+		var content []byte
+		content, err = io.ReadAll(p)
+		if err != nil {
+			return
+		}
+
+		parts = append(parts, formPart{name: p.FormName(), fileName: p.FileName(), contentType: p.Header.Get("Content-Type"), content: content})
+	}
+}
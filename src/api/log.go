@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/log"
+)
+
+// Header carrying the id generated for each request, which the API echoes back so that the request can be traced on
+// both ends. Responses carry their own id in the x-request-id header.
+const clientRequestIdHeader string = "X-Client-Request-Id"
+
+// loggingTransport is an http.RoundTripper tagging requests with an id, and logging them along with their response at
+// the debug level. Headers are redacted, see log.Headers.
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+// Returns a copy of c whose requests are tagged and logged, or c when its requests already are.
+func withLogging(c *http.Client) *http.Client {
+	if _, ok := c.Transport.(*loggingTransport); ok {
+		return c
+	}
+
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	logged := *c
+	logged.Transport = &loggingTransport{next: next}
+	return &logged
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	id := newRequestId()
+	req = req.Clone(req.Context())
+	req.Header.Set(clientRequestIdHeader, id)
+
+	log.Log(log.LevelDebug, "request", "request_id", id, "method", req.Method, "url", req.URL.String(), "headers", log.Headers(req.Header))
+
+	start := time.Now()
+	res, err = t.next.RoundTrip(req)
+	duration := time.Since(start).Milliseconds()
+	if err != nil {
+		log.Log(log.LevelDebug, "request failed", "request_id", id, "duration_ms", duration, "error", err)
+		return
+	}
+
+	log.Log(log.LevelDebug, "response", "request_id", id, "status", res.StatusCode, "x_request_id", res.Header.Get("x-request-id"), "duration_ms", duration)
+	return
+}
+
+func newRequestId() string {
+	id, _ := randomHex(8)
+	return id
+}
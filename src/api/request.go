@@ -1,15 +1,16 @@
 package api
 
 import (
-	"bytes"
+	"context"
 	"errors"
-	"io"
-	"mime/multipart"
 	"net/http"
 	"path/filepath"
 	"strings"
 
+	"github.com/ephex2/go-gpt-cli/client"
 	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/config/secret"
 	"github.com/ephex2/go-gpt-cli/log"
 )
 
@@ -23,106 +24,88 @@ var allowedMethods = map[string]bool{
 	"HEAD":    true,
 }
 
-func isValidHTTPMethod(method string) bool {
-	_, ok := allowedMethods[strings.ToUpper(method)]
-	return ok
-}
-
-func GenericRequest(queryParameters map[string]string, body []byte, route string, method string, overrideUrl string) (buf []byte, err error) {
-	log.Debug("Body is : %s\n", string(body))
-
-    var rawUrl string
-    if overrideUrl != "" {
-        rawUrl = overrideUrl + route
-    }else {
-    	rawUrl = config.BaseUrl() + route
-    }
-
-	method = strings.ToUpper(method)
-
-	req, err := http.NewRequest(method, rawUrl, bytes.NewBuffer(body))
-	if err != nil {
-		err = errors.New("Error while initializing http request, error is: %s" + err.Error())
-		return
-	}
+// The client used for every request made through the api package. It is replaced when cassettes are in use.
+var httpClient = http.DefaultClient
 
-	err = defaultHeaders(req)
-	if err != nil {
-		return
-	}
-
-	for k, v := range queryParameters {
-		req.Header.Add(k, v)
-	}
-
-	log.Debug("Request is : %s\n", req)
+// Replaces the client used by the api package, for example with the client of a test server.
+func SetClient(c *http.Client) {
+	httpClient = c
+}
 
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return
+// Returns the client of the api package, whose requests are logged and traced, or printed during dry runs.
+func Client() *http.Client {
+	if DryRun() {
+		return &http.Client{Transport: &dryRunTransport{format: dryRunFormat}}
 	}
 
-	if res.StatusCode != 200 {
-		errBuf, _ := io.ReadAll(res.Body)
+	return withLogging(withTracing(httpClient))
+}
 
-		if len(errBuf) == 0 {
-			errBuf = []byte("EMPTY")
-		}
+// Sends an http request using the client of the api package.
+// Code performing requests outside of the functions below (paginators, downloads) should use Do so that cassettes apply to them as well.
+func Do(req *http.Request) (*http.Response, error) {
+	return Client().Do(req)
+}
 
-		err = errors.New("Response from API does not indicate success: " + res.Status + "\nBody of response: " + string(errBuf))
-		return
-	}
+func isValidHTTPMethod(method string) bool {
+	_, ok := allowedMethods[strings.ToUpper(method)]
+	return ok
+}
 
-	buf, err = io.ReadAll(res.Body)
-	if err != nil {
-		return
+// Returns a client of the API making requests on behalf of a profile: the url override, credentials and adapter of the
+// profile apply, responses of deterministic requests are cached and billable calls are recorded in the usage ledger.
+// The profile is nil for routes which are not tied to an endpoint, such as models.
+func NewClient(p profile.Profile) *client.Client {
+	return &client.Client{
+		HTTPClient: Client(),
+		Url: func(route string, model string) (string, error) {
+			return RequestUrl(route, p, map[string]any{"model": model})
+		},
+		Authorize: func(req *http.Request) error {
+			return Authorize(req, p)
+		},
+		Middlewares: []client.Middleware{debugMiddleware, cacheMiddleware(p), usageMiddleware(p)},
 	}
+}
 
-	return
+func debugMiddleware(ctx context.Context, r *client.Request, next client.Sender) ([]byte, error) {
+	log.Debug("Request is : %s %s\n", r.Method, r.Url)
+	return next(ctx, r)
 }
 
-func GenericPaginatedRequest(paginator Paginator, queryParameters map[string]string, body []byte, route string, method string, overrideUrl string) (err error) {
+// Requests are made on behalf of a profile, which provides the url override and options such as caching.
+// The profile is nil for routes which are not tied to an endpoint, such as models.
+func GenericRequest(queryParameters map[string]string, body []byte, route string, method string, p profile.Profile) (buf []byte, err error) {
 	log.Debug("Body is : %s\n", string(body))
 
-    var rawUrl string
-    if overrideUrl != "" {
-        rawUrl = overrideUrl + route
-    } else {
-    	rawUrl = config.BaseUrl() + route
-    }
-
-	method = strings.ToUpper(method)
+	return NewClient(p).Send(context.Background(), &client.Request{
+		Method:      method,
+		Route:       route,
+		Query:       queryParameters,
+		ContentType: "application/json",
+		Body:        body,
+		Params:      client.JsonParams(body),
+	})
+}
 
-	req, err := http.NewRequest(method, rawUrl, bytes.NewBuffer(body))
-	if err != nil {
-		err = errors.New("Error while initializing http request, error is: %s" + err.Error())
-		return
-	}
+func GenericPaginatedRequest(paginator Paginator, queryParameters map[string]string, body []byte, route string, method string, p profile.Profile) (err error) {
+	log.Debug("Body is : %s\n", string(body))
 
-	err = defaultHeaders(req)
+	c := NewClient(p)
+	req, err := c.NewRequest(context.Background(), method, route, queryParameters, body)
 	if err != nil {
 		return
 	}
 
-	for k, v := range queryParameters {
-		req.Header.Add(k, v)
-	}
+	log.Debug("Request is : %s %s\n", req.Method, req.URL)
 
-	log.Debug("Request is : %s\n", req)
-
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.Do(req)
 	if err != nil {
 		return
 	}
 
-	if res.StatusCode != 200 {
-		errBuf, _ := io.ReadAll(res.Body)
-
-		if len(errBuf) == 0 {
-			errBuf = []byte("EMPTY")
-		}
-
-		err = errors.New("Response from API does not indicate success: " + res.Status + "\nBody of response: " + string(errBuf))
+	err = client.CheckResponse(res)
+	if err != nil {
 		return
 	}
 
@@ -133,7 +116,7 @@ func GenericPaginatedRequest(paginator Paginator, queryParameters map[string]str
 	// Pagination loop. response behavior should be covered in paginator's Continue() function.
 	for more == true {
 		if nextReq != nil {
-			nextRes, err = http.DefaultClient.Do(nextReq)
+			nextRes, err = Do(nextReq)
 			if err != nil {
 				return
 			}
@@ -150,30 +133,13 @@ func GenericPaginatedRequest(paginator Paginator, queryParameters map[string]str
 	return
 }
 
-func MultiPartFormRequest(fileDetails []FileUploadDetails, fields map[string]string, route string, method string, overrideUrl string) (outputBuf []byte, err error) {
+func MultiPartFormRequest(fileDetails []FileUploadDetails, fields map[string]string, route string, method string, p profile.Profile) (outputBuf []byte, err error) {
 	if !isValidHTTPMethod(method) {
 		err = errors.New("Method provided to api.MultiPartFormRequest() is not a valid http method: " + method)
 		return
 	}
 
-	buf := new(bytes.Buffer)
-	bodyWriter := multipart.NewWriter(buf)
-
-	// Add fields to MultiPartFormRequest
-	for key, val := range fields {
-		var part io.Writer
-		part, err = bodyWriter.CreateFormField(key)
-		if err != nil {
-			return
-		}
-
-		_, err = part.Write([]byte(val))
-		if err != nil {
-			return
-		}
-	}
-
-	// Add file(s) to MultiPartFormRequest
+	var files []client.FormFile
 	for _, details := range fileDetails {
 		f := details.File
 
@@ -183,74 +149,107 @@ func MultiPartFormRequest(fileDetails []FileUploadDetails, fields map[string]str
 			return
 		}
 
-		var fileWriter io.Writer
-		fileWriter, err = bodyWriter.CreateFormFile(details.UploadFormFieldName, name)
-		if err != nil {
-			return
-		}
-
-		_, err = io.Copy(fileWriter, f)
-		if err != nil {
-			return
-		}
+		files = append(files, client.FormFile{FieldName: details.UploadFormFieldName, FileName: name, Content: f})
 	}
 
-	err = bodyWriter.Close()
+	contentType, body, err := client.EncodeForm(fields, files)
 	if err != nil {
 		return
 	}
 
-    var rawUrl string
-    if overrideUrl != "" {
-        rawUrl = overrideUrl + route
-    } else {
-    	rawUrl = config.BaseUrl() + route
-    }
+	return NewClient(p).Send(context.Background(), &client.Request{
+		Method:      method,
+		Route:       route,
+		ContentType: contentType,
+		Body:        body,
+		Params:      client.FormParams(fields),
+	})
+}
 
-	// Setup request, using buf generated for multi part form fields
-	req, err := http.NewRequest(method, rawUrl, buf)
-	if err != nil {
-		return
+// Returns the url a route should be requested at: on the profile's override url when it is set, on the configured base url
+// otherwise. The adapter of the request rewrites the route, using the model found in the parameters of the request when
+// it needs one.
+func RequestUrl(route string, p profile.Profile, params map[string]any) (string, error) {
+	baseUrl := config.BaseUrl()
+	if p != nil && p.OverrideUrl() != "" {
+		baseUrl = p.OverrideUrl()
 	}
 
-	err = defaultHeaders(req)
+	s := adapterSettings(p)
+	a, err := getAdapter(s)
 	if err != nil {
-		return
+		return "", err
 	}
-	req.Header.Set("Content-Type", bodyWriter.FormDataContentType())
 
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return
+	model, _ := params["model"].(string)
+	return a.Url(baseUrl, route, s, model)
+}
+
+// Sets the authentication headers of a request, using the auth scheme of the request, along with the organization,
+// project and extra headers.
+// Settings of the active context apply first, then the ones of the profile, which can target a different vendor entirely.
+// The profile is nil for requests which are not made on behalf of one.
+func Authorize(req *http.Request, p profile.Profile) (err error) {
+	var options profile.Options
+	if p != nil {
+		options = p.ProfileOptions()
 	}
 
-	if res.StatusCode != 200 {
-		errBuf, _ := io.ReadAll(res.Body)
+	organization := config.Organization()
+	if options.Organization != "" {
+		organization = options.Organization
+	}
 
-		if len(errBuf) == 0 {
-			errBuf = []byte("EMPTY")
-		}
+	if organization != "" {
+		req.Header.Set("OpenAI-Organization", organization)
+	}
 
-		err = errors.New("Response from API does not indicate success: " + res.Status + "\nBody of response: " + string(errBuf))
-		return
+	project := config.Project()
+	if options.Project != "" {
+		project = options.Project
 	}
 
-	outputBuf, err = io.ReadAll(res.Body)
-	if err != nil {
+	if project != "" {
+		req.Header.Set("OpenAI-Project", project)
+	}
+
+	for k, v := range config.Headers() {
+		req.Header.Set(k, v)
+	}
+
+	for k, v := range options.Headers {
+		req.Header.Set(k, v)
+	}
+
+	s := adapterSettings(p)
+	if s.AuthHeader != "" {
+		log.AddSecretHeader(s.AuthHeader)
+	}
+
+	scheme, schemeName, err := getAuthScheme(s)
+	if err != nil || schemeName == AuthNone {
 		return
 	}
 
-	return
-}
+	var apiKey string
+	if options.ApiKey != "" {
+		apiKey, err = secret.Resolve(options.ApiKey)
+		if err != nil {
+			err = errors.New("unable to resolve the api key of profile " + p.Name() + ": " + err.Error())
+		}
+	} else {
+		apiKey, err = config.GetApiKey()
+	}
 
-func defaultHeaders(req *http.Request) (err error) {
-	apiKey, err := config.GetApiKey()
 	if err != nil {
+		// Replayed interactions never reach the API, so no key is required to run offline.
+		if Replaying() {
+			err = nil
+		}
+
 		return
 	}
 
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
+	err = scheme(req, apiKey, s)
 	return
 }
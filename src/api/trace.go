@@ -0,0 +1,430 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/log"
+)
+
+// Tracing times every phase of the requests going through the api package: dns lookup, connection, tls handshake,
+// time to first byte and transfer of the response. Timings are logged with the rate limit headers of the response, and
+// can be exported as spans to a file in the OpenTelemetry (OTLP) json format, to be loaded in a tracing backend.
+
+// Response headers reported with the timings of a request.
+var tracedHeaders = []struct{ header, name string }{
+	{"x-ratelimit-remaining-requests", "ratelimit_remaining_requests"},
+	{"x-ratelimit-remaining-tokens", "ratelimit_remaining_tokens"},
+	{"openai-processing-ms", "processing_ms"},
+}
+
+// The tracer currently in use, nil when tracing is disabled.
+var tracer *traceExporter
+
+// Enables tracing of every request made through the api package. Timings are logged when report is true, and spans
+// are written to the file at spansPath when it is not empty.
+func SetTrace(report bool, spansPath string) (err error) {
+	if !report && spansPath == "" {
+		tracer = nil
+		return
+	}
+
+	traceId, err := randomHex(16)
+	if err != nil {
+		return
+	}
+
+	tracer = &traceExporter{report: report, path: spansPath, traceId: traceId}
+	return
+}
+
+// Returns a copy of c whose requests are traced, or c when tracing is disabled.
+func withTracing(c *http.Client) *http.Client {
+	if tracer == nil {
+		return c
+	}
+
+	if _, ok := c.Transport.(*traceTransport); ok {
+		return c
+	}
+
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	traced := *c
+	traced.Transport = &traceTransport{next: next, exporter: tracer}
+	return &traced
+}
+
+type traceTransport struct {
+	next     http.RoundTripper
+	exporter *traceExporter
+}
+
+// The time at which each phase of a request started and ended. Phases which did not happen, such as the dns lookup
+// of a reused connection, are left zero.
+type requestTimings struct {
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	end          time.Time
+	reused       bool
+	err          error
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	timings := &requestTimings{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		DNSStart:     func(httptrace.DNSStartInfo) { timings.dnsStart = time.Now() },
+		DNSDone:      func(httptrace.DNSDoneInfo) { timings.dnsDone = time.Now() },
+		ConnectStart: func(string, string) { timings.connectStart = time.Now() },
+		ConnectDone:  func(string, string, error) { timings.connectDone = time.Now() },
+		TLSHandshakeStart: func() {
+			timings.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			timings.tlsDone = time.Now()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			timings.gotConn = time.Now()
+			timings.reused = info.Reused
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { timings.wroteRequest = time.Now() },
+		GotFirstResponseByte: func() { timings.firstByte = time.Now() },
+	}
+
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	res, err = t.next.RoundTrip(req)
+	if err != nil {
+		timings.end = time.Now()
+		timings.err = err
+		t.exporter.export(req, nil, timings)
+		return
+	}
+
+	// The transfer ends once the body of the response has been read, or closed
+	res.Body = &tracedBody{ReadCloser: res.Body, done: func(err error) {
+		timings.end = time.Now()
+		timings.err = err
+		t.exporter.export(req, res, timings)
+	}}
+
+	return
+}
+
+type tracedBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func(err error)
+}
+
+func (b *tracedBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	if err != nil {
+		readErr := err
+		if errors.Is(err, io.EOF) {
+			readErr = nil
+		}
+
+		b.once.Do(func() { b.done(readErr) })
+	}
+
+	return
+}
+
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(nil) })
+	return err
+}
+
+// Returns the duration between two times in milliseconds, or -1 when either of them is unknown.
+func milliseconds(from time.Time, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() {
+		return -1
+	}
+
+	return float64(to.Sub(from).Microseconds()) / 1000
+}
+
+type traceExporter struct {
+	mu      sync.Mutex
+	report  bool
+	path    string
+	traceId string
+	spans   []otlpSpan
+}
+
+func (e *traceExporter) export(req *http.Request, res *http.Response, timings *requestTimings) {
+	if e.report {
+		e.log(req, res, timings)
+	}
+
+	if e.path == "" {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	spans, err := e.requestSpans(req, res, timings)
+	if err == nil {
+		e.spans = append(e.spans, spans...)
+		err = e.save()
+	}
+
+	if err != nil {
+		log.Warning("Unable to write trace spans to %s: %s\n", e.path, err.Error())
+	}
+}
+
+func (e *traceExporter) log(req *http.Request, res *http.Response, timings *requestTimings) {
+	args := []any{"request_id", req.Header.Get(clientRequestIdHeader), "method", req.Method, "url", req.URL.String()}
+	if res != nil {
+		args = append(args, "status", res.StatusCode)
+	}
+
+	phases := []struct {
+		name     string
+		from, to time.Time
+	}{
+		{"dns_ms", timings.dnsStart, timings.dnsDone},
+		{"connect_ms", timings.connectStart, timings.connectDone},
+		{"tls_ms", timings.tlsStart, timings.tlsDone},
+		{"ttfb_ms", timings.wroteRequest, timings.firstByte},
+		{"transfer_ms", timings.firstByte, timings.end},
+		{"total_ms", timings.start, timings.end},
+	}
+
+	for _, phase := range phases {
+		if ms := milliseconds(phase.from, phase.to); ms >= 0 {
+			args = append(args, phase.name, ms)
+		}
+	}
+
+	args = append(args, "reused_connection", timings.reused)
+
+	if res != nil {
+		for _, h := range tracedHeaders {
+			if v := res.Header.Get(h.header); v != "" {
+				args = append(args, h.name, v)
+			}
+		}
+	}
+
+	if timings.err != nil {
+		args = append(args, "error", timings.err)
+	}
+
+	log.Log(log.LevelInfo, "trace", args...)
+}
+
+// The OpenTelemetry protocol (OTLP) json encoding of spans, see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceId           string          `json:"traceId"`
+	SpanId            string          `json:"spanId"`
+	ParentSpanId      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"` // 64 bit integers are encoded as strings in OTLP json
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"` // 0 unset, 1 ok, 2 error
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+)
+
+func stringAttribute(key string, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttribute(key string, value int) otlpAttribute {
+	s := strconv.Itoa(value)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &s}}
+}
+
+func boolAttribute(key string, value bool) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{BoolValue: &value}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// Returns a client span covering the whole request, with a child span for each phase which happened.
+func (e *traceExporter) requestSpans(req *http.Request, res *http.Response, timings *requestTimings) (spans []otlpSpan, err error) {
+	spanId, err := randomHex(8)
+	if err != nil {
+		return
+	}
+
+	root := otlpSpan{
+		TraceId:           e.traceId,
+		SpanId:            spanId,
+		Name:              req.Method + " " + route(req),
+		Kind:              spanKindClient,
+		StartTimeUnixNano: unixNano(timings.start),
+		EndTimeUnixNano:   unixNano(timings.end),
+		Attributes: []otlpAttribute{
+			stringAttribute("http.request.method", req.Method),
+			stringAttribute("url.full", req.URL.String()),
+			stringAttribute("server.address", req.URL.Hostname()),
+			boolAttribute("go_gpt_cli.reused_connection", timings.reused),
+		},
+	}
+
+	if id := req.Header.Get(clientRequestIdHeader); id != "" {
+		root.Attributes = append(root.Attributes, stringAttribute("go_gpt_cli.request_id", id))
+	}
+
+	if res != nil {
+		root.Attributes = append(root.Attributes, intAttribute("http.response.status_code", res.StatusCode))
+		for _, h := range tracedHeaders {
+			if v := res.Header.Get(h.header); v != "" {
+				root.Attributes = append(root.Attributes, stringAttribute("http.response.header."+h.header, v))
+			}
+		}
+
+		if id := res.Header.Get("x-request-id"); id != "" {
+			root.Attributes = append(root.Attributes, stringAttribute("http.response.header.x-request-id", id))
+		}
+
+		if res.StatusCode >= 400 {
+			root.Status = otlpStatus{Code: 2, Message: res.Status}
+		}
+	}
+
+	if timings.err != nil {
+		root.Status = otlpStatus{Code: 2, Message: timings.err.Error()}
+	}
+
+	spans = append(spans, root)
+
+	phases := []struct {
+		name     string
+		from, to time.Time
+	}{
+		{"dns", timings.dnsStart, timings.dnsDone},
+		{"connect", timings.connectStart, timings.connectDone},
+		{"tls", timings.tlsStart, timings.tlsDone},
+		{"request", timings.gotConn, timings.wroteRequest},
+		{"time to first byte", timings.wroteRequest, timings.firstByte},
+		{"transfer", timings.firstByte, timings.end},
+	}
+
+	for _, phase := range phases {
+		if phase.from.IsZero() || phase.to.IsZero() {
+			continue
+		}
+
+		var id string
+		id, err = randomHex(8)
+		if err != nil {
+			return
+		}
+
+		spans = append(spans, otlpSpan{
+			TraceId:           e.traceId,
+			SpanId:            id,
+			ParentSpanId:      spanId,
+			Name:              phase.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: unixNano(phase.from),
+			EndTimeUnixNano:   unixNano(phase.to),
+		})
+	}
+
+	return
+}
+
+// Spans are written as a single batch, rewritten after each request so that the file is complete whenever the CLI exits.
+func (e *traceExporter) save() (err error) {
+	traces := otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{stringAttribute("service.name", "go-gpt-cli")}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/ephex2/go-gpt-cli/api"},
+			Spans: e.spans,
+		}},
+	}}}
+
+	buf, err := json.MarshalIndent(traces, "", "    ")
+	if err != nil {
+		return
+	}
+
+	log.Debug("Writing trace spans to path: %s\n", e.path)
+	err = os.WriteFile(e.path, buf, 0600)
+	return
+}
+
+func randomHex(n int) (s string, err error) {
+	buf := make([]byte, n)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return
+	}
+
+	s = hex.EncodeToString(buf)
+	return
+}
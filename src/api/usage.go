@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+
+	"github.com/ephex2/go-gpt-cli/client"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/usage"
+)
+
+// Appends the usage of a successful call to the usage ledger. Failures are only logged, the response is still returned to the caller.
+// Replayed and cached responses are not recorded since they are not billed.
+func recordUsage(p profile.Profile, route string, params map[string]any, resBody []byte) {
+	if Replaying() || !usage.Billable(route) {
+		return
+	}
+
+	var endpointName, profileName string
+	if p != nil {
+		endpointName = p.Endpoint().Name()
+		profileName = p.Name()
+	}
+
+	warning, err := usage.Append(usage.FromResponse(endpointName, profileName, route, params, resBody))
+	if err != nil {
+		log.Warning("Unable to record usage of %s in the usage ledger: %s\n", route, err.Error())
+	}
+
+	if warning != "" {
+		log.Warning(warning + "\n")
+	}
+}
+
+// Records the usage of the calls which reach the API, responses served from the cache are not billed.
+func usageMiddleware(p profile.Profile) client.Middleware {
+	return func(ctx context.Context, r *client.Request, next client.Sender) (buf []byte, err error) {
+		buf, err = next(ctx, r)
+		if err == nil {
+			recordUsage(p, r.Route, r.Params, buf)
+		}
+
+		return
+	}
+}
@@ -0,0 +1,124 @@
+// Package app builds the runtime commands run against.
+//
+// A Runtime carries the settings, the profile repository, the endpoint registry, the usage ledger and the http client
+// used for API requests. The CLI loads its runtime from disk, tests build one with NewMemory, which keeps settings,
+// profiles and usage records in memory, along with the client of a test server.
+//
+// Commands read the runtime they run against from their context, see FromContext. The packages implementing the
+// endpoints do not take a runtime yet: they read config.RuntimeConfig, profile.RuntimeRepository,
+// profile.EndpointRegistry, usage.RuntimeLedger and the client of the api package, which Install sets. A runtime must be
+// installed before those packages are used, and only one runtime can be used at a time.
+package app
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ephex2/go-gpt-cli/api"
+	"github.com/ephex2/go-gpt-cli/audio"
+	"github.com/ephex2/go-gpt-cli/batches"
+	"github.com/ephex2/go-gpt-cli/chat"
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/config/repository"
+	"github.com/ephex2/go-gpt-cli/embeddings"
+	"github.com/ephex2/go-gpt-cli/file"
+	"github.com/ephex2/go-gpt-cli/finetuning"
+	"github.com/ephex2/go-gpt-cli/image"
+	"github.com/ephex2/go-gpt-cli/usage"
+)
+
+type Runtime struct {
+	Config     config.Config
+	Repository profile.Repository
+	Registry   *profile.Registry
+	Ledger     usage.Ledger
+	Client     *http.Client
+}
+
+// Builds a runtime from its repositories, with every endpoint of the CLI registered. A nil client is replaced by
+// http.DefaultClient.
+func New(cr config.ConfigRepository, repo profile.Repository, ledger usage.Ledger, client *http.Client) (rt *Runtime, err error) {
+	cfg, err := cr.Get()
+	if err != nil {
+		return
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	rt = &Runtime{
+		Config:     cfg,
+		Repository: repo,
+		Registry:   &profile.Registry{},
+		Ledger:     ledger,
+		Client:     client,
+	}
+
+	RegisterEndpoints(rt.Registry)
+	return
+}
+
+// Builds a runtime whose settings, profiles and usage records are kept in memory, starting with the settings provided.
+func NewMemory(settings map[string]string, client *http.Client) (rt *Runtime, err error) {
+	m := repository.NewMemory(settings)
+	return New(m, m, &usage.MemoryLedger{}, client)
+}
+
+// Builds the runtime of the CLI from the settings and profiles on disk, see package repository.
+func Load() (rt *Runtime, err error) {
+	err = repository.Init()
+	if err != nil {
+		return
+	}
+
+	rt = &Runtime{
+		Config:     config.RuntimeConfig,
+		Repository: profile.RuntimeRepository,
+		Registry:   &profile.Registry{},
+		Ledger:     usage.RuntimeLedger,
+		Client:     http.DefaultClient,
+	}
+
+	RegisterEndpoints(rt.Registry)
+	return
+}
+
+// Adds every endpoint of the CLI to a registry.
+func RegisterEndpoints(r *profile.Registry) {
+	audio.Register(r)
+	batches.Register(r)
+	chat.Register(r)
+	embeddings.Register(r)
+	file.Register(r)
+	finetuning.Register(r)
+	image.Register(r)
+}
+
+// Makes the runtime the one used by the packages of the CLI. Runtimes are not meant to be used concurrently: installing
+// one replaces the runtime installed before.
+func (rt *Runtime) Install() {
+	config.RuntimeConfig = rt.Config
+	profile.RuntimeRepository = rt.Repository
+	profile.EndpointRegistry = *rt.Registry
+	usage.RuntimeLedger = rt.Ledger
+	api.SetClient(rt.Client)
+}
+
+type contextKey struct{}
+
+// Returns a copy of ctx carrying the runtime, which commands read back with FromContext.
+func NewContext(ctx context.Context, rt *Runtime) context.Context {
+	return context.WithValue(ctx, contextKey{}, rt)
+}
+
+// Returns the runtime carried by ctx, nil when it carries none.
+func FromContext(ctx context.Context) *Runtime {
+	if ctx == nil {
+		return nil
+	}
+
+	rt, _ := ctx.Value(contextKey{}).(*Runtime)
+	return rt
+}
@@ -1,9 +1,9 @@
 package audio
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"os"
 	"time"
 
@@ -35,12 +35,7 @@ func CreateSpeech(prompt []string) (speechPath string, err error) {
 
 	audioP.CreateSpeechBody.Input = msg
 
-	bodyBuf, err := json.Marshal(audioP.CreateSpeechBody)
-	if err != nil {
-		return
-	}
-
-	buf, err := api.GenericRequest(nil, bodyBuf, createSpeechRoute, "POST", audioP.OverrideUrl())
+	buf, err := api.NewClient(audioP).CreateSpeech(context.Background(), audioP.CreateSpeechBody)
 	if err != nil {
 		return
 	}
@@ -87,7 +82,7 @@ func CreateTranscription(filePath string, prompt []string) (s string, err error)
 		},
 	}
 
-	buf, err := api.MultiPartFormRequest(details, fieldMap, createTranscriptionRoute, "POST", audioP.OverrideUrl())
+	buf, err := api.MultiPartFormRequest(details, fieldMap, createTranscriptionRoute, "POST", audioP)
 	if err != nil {
 		return
 	}
@@ -136,7 +131,7 @@ func CreateTranslation(filePath string, prompt []string) (s string, err error) {
 		},
 	}
 
-	buf, err := api.MultiPartFormRequest(details, fieldMap, createTranslationRoute, "POST", audioP.OverrideUrl())
+	buf, err := api.MultiPartFormRequest(details, fieldMap, createTranslationRoute, "POST", audioP)
 
 	format := fieldMap["response_format"]
 
@@ -190,7 +185,7 @@ func CreateVerboseTranscription(filePath string, prompt []string) (resp CreateVe
 		},
 	}
 
-	buf, err := api.MultiPartFormRequest(details, fieldMap, createTranscriptionRoute, "POST", audioP.OverrideUrl())
+	buf, err := api.MultiPartFormRequest(details, fieldMap, createTranscriptionRoute, "POST", audioP)
 	if err != nil {
 		return
 	}
@@ -241,7 +236,7 @@ func CreateVerboseTranslation(filePath string, prompt []string) (resp CreateVerb
 		},
 	}
 
-	buf, err := api.MultiPartFormRequest(details, fieldMap, createTranscriptionRoute, "POST", audioP.OverrideUrl())
+	buf, err := api.MultiPartFormRequest(details, fieldMap, createTranscriptionRoute, "POST", audioP)
 	if err != nil {
 		return
 	}
@@ -326,18 +321,23 @@ $mediaPlayer.Play()`
 	return
 }
 
-func ReadAudioPrompt(prompt []string) (err error) {
+// Speaks the completion of a prompt. show is called with the completion and the path of its speech before it is played.
+func ReadAudioPrompt(prompt []string, show func(msg string, speechPath string) error) (err error) {
 	msg, err := chat.CreateChatCompletion(prompt)
 	if err != nil {
 		return
 	}
 
-	fmt.Println(msg)
 	path, err := CreateSpeech([]string{msg})
 	if err != nil {
 		return
 	}
 
+	err = show(msg, path)
+	if err != nil {
+		return
+	}
+
 	err = PlayAudioFile(path)
 	if err != nil {
 		return
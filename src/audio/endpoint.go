@@ -3,6 +3,7 @@ package audio
 import (
 	"encoding/json"
 
+	"github.com/ephex2/go-gpt-cli/config"
 	"github.com/ephex2/go-gpt-cli/config/profile"
 )
 
@@ -47,5 +48,35 @@ func (ae audioEndpoint) ProfileFromJsonBuf(buf []byte) (ap profile.Profile, err
 // This function is meant to be called by the init function in the audio cobra package
 // This may be due to 'skill-issues' -- consider renaming to init() and removing calls to audio.Init() if this is the case.
 func Init() {
-	profile.EndpointRegistry.Add(aEndpoint)
+	Register(&profile.EndpointRegistry)
+}
+
+// Adds the endpoint to a registry other than profile.EndpointRegistry, such as the registry of an app.Runtime.
+func Register(r *profile.Registry) {
+	r.Add(aEndpoint)
+}
+
+func (ae audioEndpoint) ValidateProfile(p profile.Profile) (errs profile.ValidationErrors) {
+	ap, ok := p.(AudioProfile)
+	if !ok {
+		return
+	}
+
+	speech := ap.CreateSpeechBody
+	// Other vendors have their own models
+	if config.TargetsOpenAI(ap) {
+		errs.CheckAllowed("CreateSpeechBody.model", speech.Model, profile.AllowedValues(AllowedSpeechModels))
+	}
+	errs.CheckAllowed("CreateSpeechBody.voice", speech.Voice, profile.AllowedValues(AllowedVoices))
+	if speech.ResponseFormat != nil {
+		errs.CheckAllowed("CreateSpeechBody.response_format", *speech.ResponseFormat, profile.AllowedValues(AllowedSpeechResponseFormats))
+	}
+	errs.CheckRange("CreateSpeechBody.speed", speech.Speed, 0.25, 4)
+
+	errs.CheckAllowed("CreateTranscriptionBody.response_format", ap.CreateTranscriptionBody["response_format"], profile.AllowedValues(AllowedTranscriptionResponseFormats))
+	errs.CheckAllowed("CreateTranslationBody.response_format", ap.CreateTranslationBody["response_format"], profile.AllowedValues(AllowedTranslationResponseFormats))
+	errs.CheckAllowed("CreateVerboseTranscriptionBody.response_format", ap.CreateVerboseTranscriptionBody["response_format"], []string{string(verboseJsonConst)})
+	errs.CheckAllowed("CreateVerboseTranscriptionBody.timestamp_granularities", ap.CreateVerboseTranscriptionBody["timestamp_granularities"], profile.AllowedValues(AllowedTimeStampGranularities))
+	errs.CheckAllowed("CreateVerboseTranslationBody.response_format", ap.CreateVerboseTranslationBody["response_format"], []string{string(verboseJsonConst)})
+	return
 }
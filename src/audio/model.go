@@ -1,5 +1,7 @@
 package audio
 
+import "github.com/ephex2/go-gpt-cli/client"
+
 type verboseJson string
 
 const (
@@ -14,6 +16,22 @@ var AllowedSpeechModels = struct {
 	TTS1HD: "tts-1-hd",
 }
 
+var AllowedSpeechResponseFormats = struct {
+	Mp3  string
+	Opus string
+	Aac  string
+	Flac string
+	Wav  string
+	Pcm  string
+}{
+	Mp3:  "mp3",
+	Opus: "opus",
+	Aac:  "aac",
+	Flac: "flac",
+	Wav:  "wav",
+	Pcm:  "pcm",
+}
+
 var AllowedVoices = struct {
 	Alloy   string
 	Echo    string
@@ -133,50 +151,15 @@ var CreateVerboseTranslationBody = map[string]string{
 	"temperature":     "0",
 }
 
-type CreateSpeechBody struct {
-	Model          string   `json:"model"`
-	Input          string   `json:"input"`
-	Voice          string   `json:"voice"`
-	ResponseFormat *string  `json:"response_format,omitempty"`
-	Speed          *float64 `json:"speed,omitempty"`
-}
-
-type CreateTranscriptionJsonResponse struct {
-	Text string `json:"text"`
-}
-
-type CreateTranslationJsonResponse struct {
-	Text string `json:"text"`
-}
-
-type CreateVerboseTranscriptionResponse struct {
-	Task     string    `json:"task"`
-	Language string    `json:"language"`
-	Duration float64   `json:"duration"`
-	Text     string    `json:"text"`
-	Segments []Segment `json:"segments"`
-}
-
-type CreateVerboseTranslationResponse struct {
-	Task     string    `json:"task"`
-	Language string    `json:"language"`
-	Duration float64   `json:"duration"`
-	Text     string    `json:"text"`
-	Segments []Segment `json:"segments"`
-}
-
-type Segment struct {
-	ID               int     `json:"id"`
-	Seek             int     `json:"seek"`
-	Start            float64 `json:"start"`
-	End              float64 `json:"end"`
-	Text             string  `json:"text"`
-	Tokens           []int   `json:"tokens"`
-	Temperature      float64 `json:"temperature"`
-	AvgLogprob       float64 `json:"avg_logprob"`
-	CompressionRatio float64 `json:"compression_ratio"`
-	NoSpeechProb     float64 `json:"no_speech_prob"`
-}
+// Request and response types are the ones of the client package.
+type (
+	CreateSpeechBody                   = client.SpeechRequest
+	CreateTranscriptionJsonResponse    = client.TranscriptionResponse
+	CreateTranslationJsonResponse      = client.TranscriptionResponse
+	CreateVerboseTranscriptionResponse = client.VerboseTranscriptionResponse
+	CreateVerboseTranslationResponse   = client.VerboseTranscriptionResponse
+	Segment                            = client.Segment
+)
 
 func DefaultCreateSpeechBody() CreateSpeechBody {
 	format := new(string)
@@ -19,6 +19,7 @@ type AudioProfile struct {
 	CreateVerboseTranslationBody   map[string]string
 	SaveDirectory                  string // Blank by default, results in temporary files being created if blank
     Url                            string
+    profile.Options
 }
 
 func (a AudioProfile) Name() string {
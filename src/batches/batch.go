@@ -1,14 +1,16 @@
 package batches
 
 import (
+	"context"
 	"encoding/json"
     "net/http"
     "io"
 
 	"github.com/ephex2/go-gpt-cli/api"
+	"github.com/ephex2/go-gpt-cli/client"
 )
 
-const BaseBatchesRoute string = "/v1/batches"
+const BaseBatchesRoute string = client.BatchesRoute
 
 func CreateBatch(fileid string, apiEndpoint string) (resp Batch, err error) {
 	p, err := getDefaultProfile()
@@ -19,27 +21,12 @@ func CreateBatch(fileid string, apiEndpoint string) (resp Batch, err error) {
     p.CreateBatchBody.FileId = fileid
     p.CreateBatchBody.Endpoint = apiEndpoint
 
-    err = p.CreateBatchBody.Validate()
+    err = validateBody(p.CreateBatchBody)
     if err != nil {
         return
     }
 
-    marshal, err := json.Marshal(p.CreateBatchBody)
-    if err != nil {
-        return
-    }
-
-    buf, err := api.GenericRequest(nil, marshal, BaseBatchesRoute, "POST", p.OverrideUrl())
-    if err != nil {
-        return
-    }
-
-	err = json.Unmarshal(buf, &resp)
-	if err != nil {
-		return
-	}
-
-	return
+    return api.NewClient(p).CreateBatch(context.Background(), p.CreateBatchBody)
 }
 
 func CancelBatch(batchId string) (b Batch, err error) {
@@ -48,18 +35,7 @@ func CancelBatch(batchId string) (b Batch, err error) {
         return
     }
 
-    route := BaseBatchesRoute + "/" + batchId + "/cancel"
-    buf, err := api.GenericRequest(nil, nil, route, "POST", p.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-    err = json.Unmarshal(buf, &b)
-    if err != nil {
-        return
-    }
-
-	return
+	return api.NewClient(p).CancelBatch(context.Background(), batchId)
 }
 
 func GetBatch(batchId string) (b Batch, err error) {
@@ -68,18 +44,7 @@ func GetBatch(batchId string) (b Batch, err error) {
         return
     }
 
-	route := BaseBatchesRoute + "/" + batchId
-    buf, err := api.GenericRequest(nil, nil, route, "GET", p.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-    err = json.Unmarshal(buf, &b)
-    if err != nil {
-        return
-    }
-
-	return
+	return api.NewClient(p).RetrieveBatch(context.Background(), batchId)
 }
 
 func ListBatches() (batches BatchList, err error) {
@@ -88,17 +53,7 @@ func ListBatches() (batches BatchList, err error) {
         return
     }
 
-	buf, err := api.GenericRequest(nil, nil, BaseBatchesRoute, "GET", p.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-	err = json.Unmarshal(buf, &batches)
-	if err != nil {
-		return
-	}
-
-	return
+	return api.NewClient(p).ListBatches(context.Background(), client.ListParams{})
 }
 
 type listBatchesPaginator struct {
@@ -38,5 +38,21 @@ func (e batchEndpoint) ProfileFromJsonBuf(buf []byte) (p profile.Profile, err er
 // Why Init() ? The initialization of main seemed to only call init in packages imported by main.
 // This function is meant to be called by the init function in the audio cobra package
 func Init() {
-	profile.EndpointRegistry.Add(bEndpoint)
+	Register(&profile.EndpointRegistry)
+}
+
+// Adds the endpoint to a registry other than profile.EndpointRegistry, such as the registry of an app.Runtime.
+func Register(r *profile.Registry) {
+	r.Add(bEndpoint)
+}
+
+func (e batchEndpoint) ValidateProfile(p profile.Profile) (errs profile.ValidationErrors) {
+	bp, ok := p.(BatchProfile)
+	if !ok {
+		return
+	}
+
+	// The input file and endpoint are set at run time, only the completion window comes from the profile
+	errs.CheckAllowed("CreateBatchBody.completion_window", bp.CreateBatchBody.CompletionWindow, allowedCompletionWindows)
+	return
 }
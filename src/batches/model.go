@@ -2,6 +2,8 @@ package batches
 
 import (
     "errors"
+
+    "github.com/ephex2/go-gpt-cli/client"
 )
 
 var allowedBatchApiEndpoints = []string{
@@ -55,72 +57,24 @@ func stringAllowed(in string, allowedValues []string) (err error) {
     return
 }
 
-type CreateBatchBody struct {
-    FileId string `json:"input_file_id"`
-    Endpoint string `json:"endpoint"`
-    CompletionWindow string `json:"completion_window"`
-    Metadata map[string]string `json:"metadata,omitempty"`
-}
-
-func (cbb CreateBatchBody) Validate() error {
-    var err error
-    err = AllowedBatchApiEndpoint(cbb.Endpoint)
-    err = AllowedCompletionWindow(cbb.CompletionWindow)
-
-    return err
-}
-
-// Response objects //
-
-
-type BatchList struct {
-    Object string `json:"object"`
-    Data []Batch `json:"data"`
-    FirstId string `json:"first_id,omitempty"`
-    LastId string `json:"last_id,omitempty"`
-    HasMore bool `json:"has_more"`
-}
-
-type Batch struct {
-	ID              string    `json:"id"`
-	Object          string    `json:"object"`
-	Endpoint        string    `json:"endpoint"`
-	Errors          BatchError     `json:"errors,omitempty"`
-	InputFileID     string    `json:"input_file_id"`
-	CompletionWindow string   `json:"completion_window"`
-	Status          string    `json:"status"`
-	OutputFileID    string    `json:"output_file_id,omitempty"`
-	ErrorFileID     string    `json:"error_file_id,omitempty"`
-	CreatedAt       int64     `json:"created_at,omitempty"`
-	InProgressAt    int64     `json:"in_progress_at,omitempty"`
-	ExpiresAt       int64     `json:"expires_at,omitempty"`
-	FinalizingAt    int64     `json:"finalizing_at,omitempty"`
-	CompletedAt     int64     `json:"completed_at,omitempty"`
-	FailedAt        int64     `json:"failed_at,omitempty"`
-	ExpiredAt       int64     `json:"expired_at,omitempty"`
-	CancellingAt    int64     `json:"cancelling_at,omitempty"`
-	CancelledAt     int64     `json:"cancelled_at,omitempty"`
-	RequestCounts   struct {
-		Total     int `json:"total,omitempty"`
-		Completed int `json:"completed,omitempty"`
-		Failed    int `json:"failed,omitempty"`
-	} `json:"request_counts"`
-	Metadata map[string]string `json:"metadata,omitempty"`
-}
+// Request and response types are the ones of the client package.
+type (
+    CreateBatchBody = client.BatchRequest
+    BatchList = client.BatchList
+    Batch = client.Batch
+    BatchError = client.BatchError
+    BatchErrorData = client.BatchErrorData
+)
 
-type BatchError struct {
-    Object string `json:"object"`
-    Data []BatchErrorData `json:"data"`
-}
+func validateBody(cbb CreateBatchBody) error {
+    err := AllowedBatchApiEndpoint(cbb.Endpoint)
+    if err != nil {
+        return err
+    }
 
-type BatchErrorData struct {
-    Code string `json:"code"`
-    Message string `json:"message"`
-    Param string `json:"param,omitempty"`
-    Line int `json:"line,omitempty"`
+    return AllowedCompletionWindow(cbb.CompletionWindow)
 }
 
-
 // -------------------------- //
 
 
@@ -11,6 +11,7 @@ type BatchProfile struct {
 	ProfileName    string
 	CreateBatchBody CreateBatchBody
     Url            string
+    profile.Options
 }
 
 func (p BatchProfile) Name() string {
@@ -39,11 +40,11 @@ func (p BatchProfile) Endpoint() profile.Endpoint {
 }
 
 func (p BatchProfile) Validate() error {
-    var err error
-    err = AllowedCompletionWindow(p.CreateBatchBody.CompletionWindow)
-    err = AllowedBatchApiEndpoint(p.CreateBatchBody.Endpoint)
-    
-    return err
+    if errs := bEndpoint.ValidateProfile(p); len(errs) > 0 {
+        return errs
+    }
+
+    return nil
 }
 
 func (p *BatchProfile) Load(profileName string) (err error) {
@@ -1,6 +1,7 @@
 package chat
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 
@@ -27,17 +28,9 @@ func CreateChatCompletion(prompt []string) (content string, err error) {
 
 	log.Debug("Config is : %s\n", string(bufConfig))
 
-	buf, err := api.GenericRequest(nil, bufConfig, "/v1/chat/completions", "POST", chatProfile.OverrideUrl())
+	completionResponse, err := api.NewClient(chatProfile).CreateChatCompletion(context.Background(), chatProfile.CreateCompletionBody)
 	if err != nil {
 		return
-	}
-
-	// Return completion response object.
-	var completionResponse CompletionResponse
-	err = json.Unmarshal(buf, &completionResponse)
-	if err != nil {
-		err = errors.New("unable to parse completion response.\nError is: " + err.Error())
-		return
 	} else if len(completionResponse.Choices) < 1 {
 		err = errors.New("no choices returned for completion prompt")
 		return
@@ -73,15 +66,8 @@ func CreateVisionChatCompletion(imagePath string, prompt []string) (resp string,
 
 	log.Debug("Config is : %s\n", string(bufConfig))
 
-	buf, err := api.GenericRequest(nil, bufConfig, "/v1/chat/completions", "POST", p.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-	var completionResponse CompletionResponse
-	err = json.Unmarshal(buf, &completionResponse)
+	completionResponse, err := api.NewClient(p).CreateVisionCompletion(context.Background(), p.CreateVisionCompletionBody)
 	if err != nil {
-		err = errors.New("unable to parse completion response.\nError is: " + err.Error())
 		return
 	} else if len(completionResponse.Choices) < 1 {
 		err = errors.New("no choices returned for completion prompt")
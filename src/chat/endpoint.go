@@ -3,6 +3,7 @@ package chat
 import (
 	//	"github.com/ephex2/go-gpt-cli/config/repository"
 	"encoding/json"
+	"fmt"
 
 	"github.com/ephex2/go-gpt-cli/config/profile"
 )
@@ -43,5 +44,38 @@ func (ce chatEndpoint) ProfileFromJsonBuf(buf []byte) (p profile.Profile, err er
 // Why Init() ? The initialization of main seemed to only call init in packages imported by main.
 // This function is meant to be called by the init function in the audio cobra package
 func Init() {
-	profile.EndpointRegistry.Add(cEndpoint)
+	Register(&profile.EndpointRegistry)
+}
+
+// Adds the endpoint to a registry other than profile.EndpointRegistry, such as the registry of an app.Runtime.
+func Register(r *profile.Registry) {
+	r.Add(cEndpoint)
+}
+
+var allowedRoles = []string{"system", "user", "assistant", "tool"}
+
+func (ce chatEndpoint) ValidateProfile(p profile.Profile) (errs profile.ValidationErrors) {
+	cp, ok := p.(ChatProfile)
+	if !ok {
+		return
+	}
+
+	body := cp.CreateCompletionBody
+	for i, m := range body.Messages {
+		errs.CheckAllowed(fmt.Sprintf("CreateCompletionBody.messages[%d].role", i), m.Role, allowedRoles)
+	}
+	errs.CheckRange("CreateCompletionBody.temperature", body.Temperature, 0, 2)
+	errs.CheckRange("CreateCompletionBody.top_p", body.TopP, 0, 1)
+	errs.CheckRange("CreateCompletionBody.frequency_penalty", body.FrequencyPenalty, -2, 2)
+	errs.CheckRange("CreateCompletionBody.presence_penalty", body.PresencePenalty, -2, 2)
+
+	vision := cp.CreateVisionCompletionBody
+	for i, m := range vision.Messages {
+		errs.CheckAllowed(fmt.Sprintf("CreateVisionCompletionBody.messages[%d].role", i), m.Role, allowedRoles)
+	}
+	errs.CheckRange("CreateVisionCompletionBody.temperature", vision.Temperature, 0, 2)
+	errs.CheckRange("CreateVisionCompletionBody.top_p", vision.TopP, 0, 1)
+	errs.CheckRange("CreateVisionCompletionBody.frequency_penalty", vision.FrequencyPenalty, -2, 2)
+	errs.CheckRange("CreateVisionCompletionBody.presence_penalty", vision.PresencePenalty, -2, 2)
+	return
 }
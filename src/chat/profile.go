@@ -5,6 +5,7 @@ import (
 	"errors"
 	"strings"
 
+	"github.com/ephex2/go-gpt-cli/api"
 	"github.com/ephex2/go-gpt-cli/config"
 	"github.com/ephex2/go-gpt-cli/config/profile"
 )
@@ -15,6 +16,7 @@ type ChatProfile struct {
 	CreateVisionCompletionBody CreateVisionCompletionBody
 	MessageHistory       bool
     Url                  string
+    profile.Options
 }
 
 func (c ChatProfile) Name() string {
@@ -43,22 +45,56 @@ func (c ChatProfile) Endpoint() profile.Endpoint {
 }
 
 func (c *ChatProfile) AddCompletionMessage(msg Message) (err error) {
-	c.CreateCompletionBody.Messages = append(c.CreateCompletionBody.Messages, msg)
-
-	if c.MessageHistory {
-		err = c.ProfileRepository().Update(c)
+	if !c.MessageHistory {
+		c.CreateCompletionBody.Messages = append(c.CreateCompletionBody.Messages, msg)
+		return
 	}
 
-	return
+	return c.updateHistory(func(stored *ChatProfile) {
+		stored.CreateCompletionBody.Messages = append(stored.CreateCompletionBody.Messages, msg)
+	})
 }
 
 func (c *ChatProfile) AddVisionMessage(msg VisionMessage) (err error) {
-	c.CreateVisionCompletionBody.Messages = append(c.CreateVisionCompletionBody.Messages, msg)
+	if !c.MessageHistory {
+		c.CreateVisionCompletionBody.Messages = append(c.CreateVisionCompletionBody.Messages, msg)
+		return
+	}
+
+	return c.updateHistory(func(stored *ChatProfile) {
+		stored.CreateVisionCompletionBody.Messages = append(stored.CreateVisionCompletionBody.Messages, msg)
+	})
+}
+
+// Other invocations may have added messages since the profile was loaded, the history is changed on the stored profile
+// while it is locked so that none are lost. The messages of c are refreshed with the stored ones.
+func (c *ChatProfile) updateHistory(change func(stored *ChatProfile)) (err error) {
+	// Dry runs send nothing, the stored history is left as it is
+	if api.DryRun() {
+		change(c)
+		return
+	}
+
+	unlock, err := c.ProfileRepository().Lock(c.Endpoint().Name(), c.Name())
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	var stored ChatProfile
+	err = stored.Load(c.Name())
+	if err != nil {
+		return
+	}
 
-	if c.MessageHistory {
-		err = c.ProfileRepository().Update(c)
+	change(&stored)
+	err = c.ProfileRepository().UpdateLocked(&stored)
+	if err != nil {
+		return
 	}
 
+	c.CreateCompletionBody.Messages = stored.CreateCompletionBody.Messages
+	c.CreateVisionCompletionBody.Messages = stored.CreateVisionCompletionBody.Messages
 	return
 }
 
@@ -76,6 +112,12 @@ func (c *ChatProfile) Load(profileName string) (err error) {
 
 // Keep all system messages by defauly, assuming that they are customized to initialize prompts / conversations.
 func (c *ChatProfile) ClearMessageHistory() (err error) {
+	unlock, err := c.ProfileRepository().Lock(c.Endpoint().Name(), c.Name())
+	if err != nil {
+		return
+	}
+	defer unlock()
+
 	err = c.Load(c.Name())
 	if err != nil {
 		return
@@ -94,7 +136,7 @@ func (c *ChatProfile) ClearMessageHistory() (err error) {
 	}
 
 	c.CreateCompletionBody.Messages = defaultSystemMessage
-	err = c.ProfileRepository().Update(c)
+	err = c.ProfileRepository().UpdateLocked(c)
 	return
 }
 
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+const (
+	SpeechRoute         string = "/v1/audio/speech"
+	TranscriptionsRoute string = "/v1/audio/transcriptions"
+	TranslationsRoute   string = "/v1/audio/translations"
+)
+
+type SpeechRequest struct {
+	Model          string   `json:"model"`
+	Input          string   `json:"input"`
+	Voice          string   `json:"voice"`
+	ResponseFormat *string  `json:"response_format,omitempty"`
+	Speed          *float64 `json:"speed,omitempty"`
+}
+
+// Transcription and translation requests, sent as multipart forms. Fields holds form fields which have no field of
+// their own, fields which are set take priority over it.
+type AudioRequest struct {
+	FileName               string
+	File                   io.Reader
+	Model                  string
+	Prompt                 string
+	ResponseFormat         string // json, text, srt, verbose_json or vtt
+	Language               string // Transcriptions only
+	Temperature            *float64
+	TimestampGranularities []string // Requires the verbose_json response format
+	Fields                 map[string]string
+}
+
+// Json response of transcriptions and translations.
+type TranscriptionResponse struct {
+	Text string `json:"text"`
+}
+
+// Verbose json response of transcriptions and translations.
+type VerboseTranscriptionResponse struct {
+	Task     string    `json:"task"`
+	Language string    `json:"language"`
+	Duration float64   `json:"duration"`
+	Text     string    `json:"text"`
+	Segments []Segment `json:"segments"`
+}
+
+type Segment struct {
+	ID               int     `json:"id"`
+	Seek             int     `json:"seek"`
+	Start            float64 `json:"start"`
+	End              float64 `json:"end"`
+	Text             string  `json:"text"`
+	Tokens           []int   `json:"tokens"`
+	Temperature      float64 `json:"temperature"`
+	AvgLogprob       float64 `json:"avg_logprob"`
+	CompressionRatio float64 `json:"compression_ratio"`
+	NoSpeechProb     float64 `json:"no_speech_prob"`
+}
+
+func (r AudioRequest) fields() map[string]string {
+	fields := make(map[string]string)
+	for k, v := range r.Fields {
+		fields[k] = v
+	}
+
+	set := func(k string, v string) {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+
+	set("model", r.Model)
+	set("prompt", r.Prompt)
+	set("response_format", r.ResponseFormat)
+	set("language", r.Language)
+	if r.Temperature != nil {
+		fields["temperature"] = strconv.FormatFloat(*r.Temperature, 'f', -1, 64)
+	}
+
+	// Form fields are a map, only one granularity can be sent
+	if len(r.TimestampGranularities) > 0 {
+		fields["timestamp_granularities[]"] = r.TimestampGranularities[0]
+	}
+
+	return fields
+}
+
+// Returns the generated audio, in the response format of the request, mp3 by default.
+func (c *Client) CreateSpeech(ctx context.Context, r SpeechRequest) (audio []byte, err error) {
+	return c.doJson(ctx, http.MethodPost, SpeechRoute, nil, r, nil)
+}
+
+// Returns the transcription in the response format of the request, see TranscriptionResponse and
+// VerboseTranscriptionResponse for json formats.
+func (c *Client) CreateTranscription(ctx context.Context, r AudioRequest) (buf []byte, err error) {
+	return c.doForm(ctx, TranscriptionsRoute, r.fields(), []FormFile{{FieldName: "file", FileName: r.FileName, Content: r.File}}, nil)
+}
+
+// Translates audio to english, the response is in the response format of the request as with transcriptions.
+func (c *Client) CreateTranslation(ctx context.Context, r AudioRequest) (buf []byte, err error) {
+	return c.doForm(ctx, TranslationsRoute, r.fields(), []FormFile{{FieldName: "file", FileName: r.FileName, Content: r.File}}, nil)
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+const BatchesRoute string = "/v1/batches"
+
+type BatchRequest struct {
+	FileId           string            `json:"input_file_id"`
+	Endpoint         string            `json:"endpoint"`
+	CompletionWindow string            `json:"completion_window"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+}
+
+type BatchList struct {
+	Object  string  `json:"object"`
+	Data    []Batch `json:"data"`
+	FirstId string  `json:"first_id,omitempty"`
+	LastId  string  `json:"last_id,omitempty"`
+	HasMore bool    `json:"has_more"`
+}
+
+type Batch struct {
+	ID               string     `json:"id"`
+	Object           string     `json:"object"`
+	Endpoint         string     `json:"endpoint"`
+	Errors           BatchError `json:"errors,omitempty"`
+	InputFileID      string     `json:"input_file_id"`
+	CompletionWindow string     `json:"completion_window"`
+	Status           string     `json:"status"`
+	OutputFileID     string     `json:"output_file_id,omitempty"`
+	ErrorFileID      string     `json:"error_file_id,omitempty"`
+	CreatedAt        int64      `json:"created_at,omitempty"`
+	InProgressAt     int64      `json:"in_progress_at,omitempty"`
+	ExpiresAt        int64      `json:"expires_at,omitempty"`
+	FinalizingAt     int64      `json:"finalizing_at,omitempty"`
+	CompletedAt      int64      `json:"completed_at,omitempty"`
+	FailedAt         int64      `json:"failed_at,omitempty"`
+	ExpiredAt        int64      `json:"expired_at,omitempty"`
+	CancellingAt     int64      `json:"cancelling_at,omitempty"`
+	CancelledAt      int64      `json:"cancelled_at,omitempty"`
+	RequestCounts    struct {
+		Total     int `json:"total,omitempty"`
+		Completed int `json:"completed,omitempty"`
+		Failed    int `json:"failed,omitempty"`
+	} `json:"request_counts"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type BatchError struct {
+	Object string           `json:"object"`
+	Data   []BatchErrorData `json:"data"`
+}
+
+type BatchErrorData struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`
+	Line    int    `json:"line,omitempty"`
+}
+
+// Pagination of list requests: the page starts after the object whose id is After, and holds at most Limit objects.
+// Zero values use the defaults of the API.
+type ListParams struct {
+	After string
+	Limit int
+}
+
+func (p ListParams) query() map[string]string {
+	q := make(map[string]string)
+	if p.After != "" {
+		q["after"] = p.After
+	}
+
+	if p.Limit > 0 {
+		q["limit"] = strconv.Itoa(p.Limit)
+	}
+
+	return q
+}
+
+func (c *Client) CreateBatch(ctx context.Context, r BatchRequest) (res Batch, err error) {
+	_, err = c.doJson(ctx, http.MethodPost, BatchesRoute, nil, r, &res)
+	return
+}
+
+func (c *Client) RetrieveBatch(ctx context.Context, id string) (res Batch, err error) {
+	_, err = c.doJson(ctx, http.MethodGet, BatchesRoute+"/"+id, nil, nil, &res)
+	return
+}
+
+func (c *Client) CancelBatch(ctx context.Context, id string) (res Batch, err error) {
+	_, err = c.doJson(ctx, http.MethodPost, BatchesRoute+"/"+id+"/cancel", nil, nil, &res)
+	return
+}
+
+// Returns a page of batches, see BatchList.HasMore and BatchList.LastId for the next page.
+func (c *Client) ListBatches(ctx context.Context, p ListParams) (res BatchList, err error) {
+	_, err = c.doJson(ctx, http.MethodGet, BatchesRoute, p.query(), nil, &res)
+	return
+}
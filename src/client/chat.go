@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+const ChatCompletionsRoute string = "/v1/chat/completions"
+
+// Ref: https://platform.openai.com/docs/api-reference/chat/create
+type ChatCompletionRequest struct {
+	Messages         []Message       `json:"messages"`
+	Model            string          `json:"model"`
+	FrequencyPenalty *float64        `json:"frequency_penalty,omitempty"`
+	LogitBias        map[string]int  `json:"logit_bias,omitempty"`
+	Logprobs         *bool           `json:"logprobs,omitempty"`
+	TopLogprobs      *int            `json:"top_logprobs,omitempty"`
+	MaxTokens        *int            `json:"max_tokens,omitempty"`
+	N                *int            `json:"n,omitempty"`
+	PresencePenalty  *float64        `json:"presence_penalty,omitempty"`
+	ResponseFormat   *ResponseFormat `json:"response_format,omitempty"`
+	Seed             *int            `json:"seed,omitempty"`
+	Stop             []string        `json:"stop,omitempty"`
+	Stream           *bool           `json:"stream,omitempty"`
+	Temperature      *float64        `json:"temperature,omitempty"`
+	TopP             *float64        `json:"top_p,omitempty"`
+	Tools            []Tool          `json:"tools,omitempty"`
+	ToolChoice       *interface{}    `json:"tool_choice,omitempty"` // can be "auto", "none", nil, or a Tool{}
+	User             string          `json:"user,omitempty"`
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ResponseFormat struct {
+	Type string `json:"type"` // must be "text" or "json_object"
+}
+
+type Tool struct {
+	Type     string   `json:"type"`
+	Function Function `json:"function"`
+}
+
+type Function struct {
+	Description string `json:"description"`
+	Name        string `json:"name"`
+	Parameters  string `json:"parameters"` // json string of function parameters. Varies per function.
+}
+
+type ChatCompletionResponse struct {
+	Id                string    `json:"id"`
+	Object            string    `json:"object"`
+	Created           int       `json:"created"`
+	Model             string    `json:"model"`
+	SystemFingerprint string    `json:"system_fingerprint"`
+	Choices           []Choice  `json:"choices"`
+	Usage             ChatUsage `json:"usage"`
+}
+
+type Choice struct {
+	Index         int     `json:"index"`
+	Message       Message `json:"message"`
+	Logprobs      bool    `json:"logprobs"`
+	FinishSession string  `json:"finish_session"`
+}
+
+type ChatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Chat completion request whose messages hold images along with text.
+type VisionCompletionRequest struct {
+	Messages         []VisionMessage `json:"messages"`
+	Model            string          `json:"model"`
+	FrequencyPenalty *float64        `json:"frequency_penalty,omitempty"`
+	LogitBias        map[string]int  `json:"logit_bias,omitempty"`
+	Logprobs         *bool           `json:"logprobs,omitempty"`
+	TopLogprobs      *int            `json:"top_logprobs,omitempty"`
+	MaxTokens        *int            `json:"max_tokens,omitempty"`
+	N                *int            `json:"n,omitempty"`
+	PresencePenalty  *float64        `json:"presence_penalty,omitempty"`
+	ResponseFormat   *ResponseFormat `json:"response_format,omitempty"`
+	Seed             *int            `json:"seed,omitempty"`
+	Stop             []string        `json:"stop,omitempty"`
+	Stream           *bool           `json:"stream,omitempty"`
+	Temperature      *float64        `json:"temperature,omitempty"`
+	TopP             *float64        `json:"top_p,omitempty"`
+	Tools            []Tool          `json:"tools,omitempty"`
+	ToolChoice       *interface{}    `json:"tool_choice,omitempty"` // can be "auto", "none", nil, or a Tool{}
+	User             string          `json:"user,omitempty"`
+}
+
+type VisionMessage struct {
+	Role    string          `json:"role"`
+	Content []VisionContent `json:"content"`
+}
+
+type VisionContent struct {
+	Type     string   `json:"type"` // text or image_url. image_url can be a literal url to the image or "data:image/png;base64,<<base64 image data>>"
+	Text     *string  `json:"text,omitempty"`
+	ImageUrl ImageUrl `json:"image_url,omitempty"`
+}
+
+type ImageUrl struct {
+	Url string `json:"url"`
+}
+
+func (c *Client) CreateChatCompletion(ctx context.Context, r ChatCompletionRequest) (res ChatCompletionResponse, err error) {
+	_, err = c.doJson(ctx, http.MethodPost, ChatCompletionsRoute, nil, r, &res)
+	return
+}
+
+func (c *Client) CreateVisionCompletion(ctx context.Context, r VisionCompletionRequest) (res ChatCompletionResponse, err error) {
+	_, err = c.doJson(ctx, http.MethodPost, ChatCompletionsRoute, nil, r, &res)
+	return
+}
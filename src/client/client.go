@@ -0,0 +1,338 @@
+package client
+
+// The client package is a Go client of the OpenAI API, which the CLI is built on. Requests take explicit request
+// structs and contexts, nothing is read from settings or profiles:
+//
+//	c := client.New(client.DefaultBaseUrl, os.Getenv("OPENAI_API_KEY"))
+//	res, err := c.CreateChatCompletion(ctx, client.ChatCompletionRequest{Model: "gpt-4o-mini", Messages: msgs})
+//
+// The CLI applies profiles, adapters, caching and usage records through the Url and Authorize hooks and middlewares.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+const DefaultBaseUrl string = "https://api.openai.com"
+
+type Client struct {
+	BaseUrl    string
+	ApiKey     string
+	HTTPClient *http.Client
+
+	// Sent as the OpenAI-Organization and OpenAI-Project headers when set
+	Organization string
+	Project      string
+	// Extra headers set on every request
+	Headers map[string]string
+
+	// Returns the url of a route, BaseUrl followed by the route when nil. The model of the request is provided when
+	// known, for APIs which route requests by model, such as Azure OpenAI deployments.
+	Url func(route string, model string) (string, error)
+	// Sets the authentication headers of a request, a bearer token of ApiKey when nil.
+	Authorize func(req *http.Request) error
+	// Wrap the sending of requests, the first middleware being the outermost one.
+	Middlewares []Middleware
+}
+
+// A request to a route of the API, as seen by middlewares.
+type Request struct {
+	Method      string
+	Route       string
+	Url         string // Set by the client before middlewares run
+	Query       map[string]string
+	ContentType string
+	Body        []byte
+	Params      map[string]any // Fields of a json body or of a form, ex: the model requested
+}
+
+// Sends a request and returns the body of a successful response.
+type Sender func(ctx context.Context, r *Request) ([]byte, error)
+
+type Middleware func(ctx context.Context, r *Request, next Sender) ([]byte, error)
+
+// Returned when the API answers with another status than 200.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+	RequestId  string // The x-request-id header of the response, to quote when reporting an issue with the API
+}
+
+func (e *APIError) Error() string {
+	body := string(e.Body)
+	if body == "" {
+		body = "EMPTY"
+	}
+
+	msg := "Response from API does not indicate success: " + e.Status
+	if e.RequestId != "" {
+		msg += " (request id " + e.RequestId + ")"
+	}
+
+	return msg + "\nBody of response: " + body
+}
+
+func New(baseUrl string, apiKey string) *Client {
+	return &Client{
+		BaseUrl:    baseUrl,
+		ApiKey:     apiKey,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Sends a request through the middlewares, returning the body of a successful response or an *APIError.
+func (c *Client) Send(ctx context.Context, r *Request) (buf []byte, err error) {
+	r.Method = strings.ToUpper(r.Method)
+	if r.Url == "" {
+		model, _ := r.Params["model"].(string)
+		r.Url, err = c.url(r.Route, model)
+		if err != nil {
+			return
+		}
+	}
+
+	send := c.send
+	for i := len(c.Middlewares) - 1; i >= 0; i-- {
+		middleware, next := c.Middlewares[i], send
+		send = func(ctx context.Context, r *Request) ([]byte, error) {
+			return middleware(ctx, r, next)
+		}
+	}
+
+	return send(ctx, r)
+}
+
+func (c *Client) send(ctx context.Context, r *Request) (buf []byte, err error) {
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.Url, bytes.NewReader(r.Body))
+	if err != nil {
+		err = errors.New("Error while initializing http request, error is: " + err.Error())
+		return
+	}
+
+	if r.ContentType != "" {
+		req.Header.Set("Content-Type", r.ContentType)
+	}
+
+	err = c.authorize(req)
+	if err != nil {
+		return
+	}
+
+	setQuery(req, r.Query)
+
+	res, err := c.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	err = CheckResponse(res)
+	if err != nil {
+		return
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+// Builds an authenticated request to a route, for callers reading responses themselves such as paginators.
+func (c *Client) NewRequest(ctx context.Context, method string, route string, query map[string]string, body []byte) (req *http.Request, err error) {
+	rawUrl, err := c.url(route, "")
+	if err != nil {
+		return
+	}
+
+	req, err = http.NewRequestWithContext(ctx, strings.ToUpper(method), rawUrl, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	err = c.authorize(req)
+	if err != nil {
+		return
+	}
+
+	setQuery(req, query)
+	return
+}
+
+// Sends a request with the http client of the client, without middlewares.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if c.HTTPClient == nil {
+		return http.DefaultClient.Do(req)
+	}
+
+	return c.HTTPClient.Do(req)
+}
+
+// Returns an *APIError for unsuccessful responses, consuming their body.
+func CheckResponse(res *http.Response) error {
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	body, _ := io.ReadAll(res.Body)
+	return &APIError{StatusCode: res.StatusCode, Status: res.Status, Body: body, RequestId: res.Header.Get("x-request-id")}
+}
+
+func (c *Client) url(route string, model string) (string, error) {
+	if c.Url != nil {
+		return c.Url(route, model)
+	}
+
+	return strings.TrimSuffix(c.BaseUrl, "/") + route, nil
+}
+
+func (c *Client) authorize(req *http.Request) error {
+	if c.Organization != "" {
+		req.Header.Set("OpenAI-Organization", c.Organization)
+	}
+
+	if c.Project != "" {
+		req.Header.Set("OpenAI-Project", c.Project)
+	}
+
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+
+	if c.Authorize != nil {
+		return c.Authorize(req)
+	}
+
+	if c.ApiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.ApiKey)
+	}
+
+	return nil
+}
+
+func setQuery(req *http.Request, query map[string]string) {
+	if len(query) == 0 {
+		return
+	}
+
+	q := req.URL.Query()
+	for k, v := range query {
+		q.Set(k, v)
+	}
+	req.URL.RawQuery = q.Encode()
+}
+
+// Sends a json request, in is not sent when nil, and decodes the response into out when it is not nil.
+func (c *Client) doJson(ctx context.Context, method string, route string, query map[string]string, in any, out any) (buf []byte, err error) {
+	var body []byte
+	if in != nil {
+		body, err = json.Marshal(in)
+		if err != nil {
+			return
+		}
+	}
+
+	buf, err = c.Send(ctx, &Request{
+		Method:      method,
+		Route:       route,
+		Query:       query,
+		ContentType: "application/json",
+		Body:        body,
+		Params:      JsonParams(body),
+	})
+
+	if err != nil || out == nil {
+		return
+	}
+
+	err = json.Unmarshal(buf, out)
+	return
+}
+
+// A file sent in a multipart form.
+type FormFile struct {
+	FieldName string // Name of the form field, ex: file, image or mask
+	FileName  string
+	Content   io.Reader
+}
+
+// Encodes form fields and files as a multipart form.
+func EncodeForm(fields map[string]string, files []FormFile) (contentType string, body []byte, err error) {
+	buf := new(bytes.Buffer)
+	w := multipart.NewWriter(buf)
+
+	for key, val := range fields {
+		err = w.WriteField(key, val)
+		if err != nil {
+			return
+		}
+	}
+
+	for _, f := range files {
+		if f.FileName == "" {
+			err = errors.New("no file name provided for form field " + f.FieldName)
+			return
+		}
+
+		var part io.Writer
+		part, err = w.CreateFormFile(f.FieldName, f.FileName)
+		if err != nil {
+			return
+		}
+
+		_, err = io.Copy(part, f.Content)
+		if err != nil {
+			return
+		}
+	}
+
+	err = w.Close()
+	if err != nil {
+		return
+	}
+
+	return w.FormDataContentType(), buf.Bytes(), nil
+}
+
+// Sends a multipart form request, and decodes the response into out when it is not nil.
+func (c *Client) doForm(ctx context.Context, route string, fields map[string]string, files []FormFile, out any) (buf []byte, err error) {
+	contentType, body, err := EncodeForm(fields, files)
+	if err != nil {
+		return
+	}
+
+	buf, err = c.Send(ctx, &Request{
+		Method:      http.MethodPost,
+		Route:       route,
+		ContentType: contentType,
+		Body:        body,
+		Params:      FormParams(fields),
+	})
+
+	if err != nil || out == nil {
+		return
+	}
+
+	err = json.Unmarshal(buf, out)
+	return
+}
+
+// Returns the fields of a json body, or nil when it is not a json object.
+func JsonParams(body []byte) (params map[string]any) {
+	json.Unmarshal(body, &params)
+	return
+}
+
+// Returns the fields of a form as request parameters.
+func FormParams(fields map[string]string) (params map[string]any) {
+	params = make(map[string]any, len(fields))
+	for k, v := range fields {
+		params[k] = v
+	}
+
+	return
+}
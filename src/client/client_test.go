@@ -0,0 +1,214 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Starts a server answering every request with the handler, closed when the test ends.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSendMiddlewareOrder(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("response"))
+	})
+
+	var calls []string
+	middleware := func(name string) Middleware {
+		return func(ctx context.Context, r *Request, next Sender) ([]byte, error) {
+			calls = append(calls, name+" before")
+			buf, err := next(ctx, r)
+			calls = append(calls, name+" after")
+			return buf, err
+		}
+	}
+
+	c := New(server.URL, "sk-test")
+	c.Middlewares = []Middleware{middleware("outer"), middleware("inner")}
+
+	buf, err := c.Send(context.Background(), &Request{Method: "get", Route: "/v1/models"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(buf) != "response" {
+		t.Errorf("expected the body of the response, got: %q", buf)
+	}
+
+	expected := []string{"outer before", "inner before", "inner after", "outer after"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected middlewares to run as %v, got %v", expected, calls)
+	}
+}
+
+func TestSendMiddlewareSeesRequest(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the request should not be sent")
+	})
+
+	c := New(server.URL+"/", "sk-test")
+	c.Middlewares = []Middleware{func(ctx context.Context, r *Request, next Sender) ([]byte, error) {
+		if r.Method != http.MethodPost || r.Url != server.URL+"/v1/chat/completions" || r.Params["model"] != "gpt-4o-mini" {
+			t.Errorf("unexpected request: %s %s %v", r.Method, r.Url, r.Params)
+		}
+
+		// Middlewares may answer requests themselves, such as caches
+		return []byte(`{"id": "cached"}`), nil
+	}}
+
+	res, err := c.CreateChatCompletion(context.Background(), ChatCompletionRequest{Model: "gpt-4o-mini"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Id != "cached" {
+		t.Errorf("expected the response of the middleware, got: %s", res.Id)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-request-id", "req-123")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"message": "slow down"}}`))
+	})
+
+	_, err := New(server.URL, "sk-test").ListModels(context.Background())
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an *APIError, got: %v", err)
+	}
+
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RequestId != "req-123" || !strings.Contains(string(apiErr.Body), "slow down") {
+		t.Errorf("unexpected error: %+v", apiErr)
+	}
+
+	if !strings.Contains(err.Error(), "req-123") || !strings.Contains(err.Error(), "slow down") {
+		t.Errorf("expected the message to quote the request id and the body, got: %s", err)
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	ok := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("body"))}
+	if err := CheckResponse(ok); err != nil {
+		t.Errorf("expected no error for status 200, got: %s", err)
+	}
+
+	// Bodies of successful responses are left for the caller
+	buf, _ := io.ReadAll(ok.Body)
+	if string(buf) != "body" {
+		t.Errorf("expected the body to be left unread, got: %q", buf)
+	}
+
+	empty := &http.Response{StatusCode: http.StatusInternalServerError, Status: "500 Internal Server Error", Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}
+	err := CheckResponse(empty)
+	if err == nil || !strings.Contains(err.Error(), "EMPTY") || strings.Contains(err.Error(), "request id") {
+		t.Errorf("expected an error for an empty body without a request id, got: %v", err)
+	}
+}
+
+func TestSetQuery(t *testing.T) {
+	tests := []struct {
+		url      string
+		query    map[string]string
+		expected string
+	}{
+		{"https://api.openai.com/v1/batches", nil, ""},
+		{"https://api.openai.com/v1/batches", map[string]string{"limit": "10"}, "limit=10"},
+		// Azure OpenAI urls carry the api version in their query
+		{"https://res.openai.azure.com/openai/deployments/gpt/batches?api-version=2024-02-01", map[string]string{"limit": "10", "after": "batch_1"}, "after=batch_1&api-version=2024-02-01&limit=10"},
+		{"https://api.openai.com/v1/batches?limit=20", map[string]string{"limit": "10"}, "limit=10"},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodGet, test.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		setQuery(req, test.query)
+		if req.URL.RawQuery != test.expected {
+			t.Errorf("%s with %v: expected query %q, got %q", test.url, test.query, test.expected, req.URL.RawQuery)
+		}
+	}
+}
+
+func TestListQueryWithUrlHook(t *testing.T) {
+	var query string
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"object": "list"}`))
+	})
+
+	c := New("", "")
+	c.Url = func(route string, model string) (string, error) {
+		return server.URL + route + "?api-version=2024-02-01", nil
+	}
+
+	_, err := c.ListBatches(context.Background(), ListParams{Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if query != "api-version=2024-02-01&limit=5" {
+		t.Errorf("expected the api version to be kept along with the limit, got: %s", query)
+	}
+}
+
+func TestEncodeForm(t *testing.T) {
+	contentType, body, err := EncodeForm(map[string]string{"model": "whisper-1", "language": "en"}, []FormFile{
+		{FieldName: "file", FileName: "speech.mp3", Content: bytes.NewReader([]byte("audio"))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("expected a multipart content type, got: %s", contentType)
+	}
+
+	form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if form.Value["model"][0] != "whisper-1" || form.Value["language"][0] != "en" {
+		t.Errorf("unexpected fields: %v", form.Value)
+	}
+
+	files := form.File["file"]
+	if len(files) != 1 || files[0].Filename != "speech.mp3" {
+		t.Fatalf("expected a single file named speech.mp3, got: %v", files)
+	}
+
+	f, err := files[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	content, _ := io.ReadAll(f)
+	if string(content) != "audio" {
+		t.Errorf("expected the content of the file, got: %q", content)
+	}
+
+	_, _, err = EncodeForm(nil, []FormFile{{FieldName: "file", Content: bytes.NewReader(nil)}})
+	if err == nil {
+		t.Error("expected an error for a file without a name")
+	}
+}
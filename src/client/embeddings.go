@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+const EmbeddingsRoute string = "/v1/embeddings"
+
+/*
+	Note: the input can technically be a string, array of strings, integer, or array of integers. Simplified for the current case.
+
+See the 'reducing embedding dimensions' section here for more information on Dimensions: https://platform.openai.com/docs/guides/embeddings/use-cases
+*/
+type EmbeddingRequest struct {
+	Input          []string `json:"input"`
+	Model          string   `json:"model"`
+	EncodingFormat *string  `json:"encoding_format,omitempty"`
+	Dimensions     *int     `json:"dimensions,omitempty"`
+	User           string   `json:"user,omitempty"`
+}
+
+type EmbeddingResponse struct {
+	Object string         `json:"object"`
+	Data   []Embedding    `json:"data"`
+	Model  string         `json:"model"`
+	Usage  EmbeddingUsage `json:"usage"`
+}
+
+type Embedding struct {
+	Object    string    `json:"object"`
+	Embedding []float64 `json:"embedding"`
+	Index     int       `json:"index"`
+}
+
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+func (c *Client) CreateEmbedding(ctx context.Context, r EmbeddingRequest) (res EmbeddingResponse, err error) {
+	_, err = c.doJson(ctx, http.MethodPost, EmbeddingsRoute, nil, r, &res)
+	return
+}
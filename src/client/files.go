@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+const FilesRoute string = "/v1/files"
+
+type UploadFileRequest struct {
+	FileName string
+	File     io.Reader
+	Purpose  string // assistants, batch or fine-tune
+}
+
+type FileList struct {
+	Object string `json:"object"`
+	Data   []File `json:"data"`
+}
+
+type File struct {
+	ID        string `json:"id"`
+	Bytes     int    `json:"bytes"`
+	CreatedAt int    `json:"created_at"`
+	FileName  string `json:"filename"`
+	Object    string `json:"object"`
+	Purpose   string `json:"purpose"`
+}
+
+type DeleteStatus struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Deleted bool   `json:"deleted"`
+}
+
+func (c *Client) UploadFile(ctx context.Context, r UploadFileRequest) (res File, err error) {
+	fields := map[string]string{"purpose": r.Purpose}
+	_, err = c.doForm(ctx, FilesRoute, fields, []FormFile{{FieldName: "file", FileName: r.FileName, Content: r.File}}, &res)
+	return
+}
+
+func (c *Client) ListFiles(ctx context.Context) (res FileList, err error) {
+	_, err = c.doJson(ctx, http.MethodGet, FilesRoute, nil, nil, &res)
+	return
+}
+
+func (c *Client) RetrieveFile(ctx context.Context, id string) (res File, err error) {
+	_, err = c.doJson(ctx, http.MethodGet, FilesRoute+"/"+id, nil, nil, &res)
+	return
+}
+
+func (c *Client) RetrieveFileContent(ctx context.Context, id string) (content []byte, err error) {
+	return c.doJson(ctx, http.MethodGet, FilesRoute+"/"+id+"/content", nil, nil, nil)
+}
+
+func (c *Client) DeleteFile(ctx context.Context, id string) (res DeleteStatus, err error) {
+	_, err = c.doJson(ctx, http.MethodDelete, FilesRoute+"/"+id, nil, nil, &res)
+	return
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+const FineTuningJobsRoute string = "/v1/fine_tuning/jobs"
+
+type FineTuningRequest struct {
+	Model           string          `json:"model"`
+	TrainingFile    string          `json:"training_file"`
+	HyperParameters HyperParameters `json:"hyperparameters"`
+	Suffix          *string         `json:"suffix,omitempty"`
+	ValidationFile  *string         `json:"validation_file,omitempty"`
+}
+
+type HyperParameters struct {
+	BatchSize              string `json:"batch_size,omitempty"`               // string or integer
+	LearningRateMultiplier string `json:"learning_rate_multiplier,omitempty"` // string or number
+	NEpochs                string `json:"n_epochs,omitempty"`                 // string or integer
+}
+
+type FineTuningJob struct {
+	ID             string           `json:"id"`
+	CreatedAt      int              `json:"created_at"`
+	Error          *FineTuningError `json:"error,omitempty"`
+	FineTunedModel *string          `json:"fine_tuned_model,omitempty"`
+	FinishedAt     *int             `json:"finished_at,omitempty"`
+	Model          string           `json:"model"`
+	Object         string           `json:"object"`
+	OrganizationID string           `json:"organization_id"`
+	ResultFiles    []string         `json:"result_files"`
+	Status         string           `json:"status"`
+	TrainedTokens  *int             `json:"trained_tokens,omitempty"`
+	TrainingFile   string           `json:"training_file"`
+	ValidationFile *string          `json:"validation_file,omitempty"`
+}
+
+type FineTuningJobList struct {
+	Object  string          `json:"object"`
+	Data    []FineTuningJob `json:"data"`
+	HasMore bool            `json:"has_more"`
+}
+
+type FineTuningError struct {
+	Code    string  `json:"code"`
+	Message string  `json:"message"`
+	Param   *string `json:"param"`
+}
+
+type FineTuningEvent struct {
+	ID        string `json:"id"`
+	CreatedAt int    `json:"created_at"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+	Object    string `json:"object"`
+}
+
+type FineTuningEventList struct {
+	Object  string            `json:"object"`
+	Data    []FineTuningEvent `json:"data"`
+	HasMore bool              `json:"has_more"`
+}
+
+func (c *Client) CreateFineTuningJob(ctx context.Context, r FineTuningRequest) (res FineTuningJob, err error) {
+	_, err = c.doJson(ctx, http.MethodPost, FineTuningJobsRoute, nil, r, &res)
+	return
+}
+
+func (c *Client) RetrieveFineTuningJob(ctx context.Context, id string) (res FineTuningJob, err error) {
+	_, err = c.doJson(ctx, http.MethodGet, FineTuningJobsRoute+"/"+id, nil, nil, &res)
+	return
+}
+
+func (c *Client) CancelFineTuningJob(ctx context.Context, id string) (res FineTuningJob, err error) {
+	_, err = c.doJson(ctx, http.MethodPost, FineTuningJobsRoute+"/"+id+"/cancel", nil, nil, &res)
+	return
+}
+
+// Returns a page of jobs, the next page starts after the id of the last job when HasMore is set.
+func (c *Client) ListFineTuningJobs(ctx context.Context, p ListParams) (res FineTuningJobList, err error) {
+	_, err = c.doJson(ctx, http.MethodGet, FineTuningJobsRoute, p.query(), nil, &res)
+	return
+}
+
+// Returns a page of the events of a job, the next page starts after the id of the last event when HasMore is set.
+func (c *Client) ListFineTuningEvents(ctx context.Context, id string, p ListParams) (res FineTuningEventList, err error) {
+	_, err = c.doJson(ctx, http.MethodGet, FineTuningJobsRoute+"/"+id+"/events", p.query(), nil, &res)
+	return
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+const (
+	ImageGenerationsRoute string = "/v1/images/generations"
+	ImageEditsRoute       string = "/v1/images/edits"
+	ImageVariationsRoute  string = "/v1/images/variations"
+)
+
+type ImageRequest struct {
+	Prompt         string  `json:"prompt"`
+	Model          *string `json:"model,omitempty"`
+	N              *int    `json:"n,omitempty"`
+	ResponseFormat *string `json:"response_format,omitempty"`
+	Size           *string `json:"size,omitempty"`
+	User           string  `json:"user,omitempty"`
+	Mode           *int    `json:"mode,omitempty"`
+	Step           *int    `json:"step,omitempty"`
+}
+
+// Image request of dall-e-3, which supports qualities and styles.
+type Dalle3ImageRequest struct {
+	Prompt         string  `json:"prompt"`
+	Model          *string `json:"model,omitempty"`
+	N              *int    `json:"n,omitempty"`
+	Quality        *string `json:"quality,omitempty"`
+	ResponseFormat *string `json:"response_format,omitempty"`
+	Size           *string `json:"size,omitempty"`
+	Style          *string `json:"style,omitempty"`
+	User           string  `json:"user,omitempty"`
+}
+
+type ImagesResponse struct {
+	Created int     `json:"created"`
+	Data    []Image `json:"data"`
+}
+
+// A generated image, either its url or its content depending on the response format of the request.
+type Image struct {
+	Url           string `json:"url,omitempty"`
+	B64Json       string `json:"b64_json,omitempty"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// Edit and variation requests, sent as multipart forms. Fields holds the other form fields: prompt (edits only),
+// model, n, size, response_format and user.
+type ImageFileRequest struct {
+	FileName     string
+	File         io.Reader
+	MaskFileName string    // Edits only, optional
+	Mask         io.Reader // Transparent areas of the mask are the ones edited
+	Fields       map[string]string
+}
+
+func (c *Client) CreateImage(ctx context.Context, r ImageRequest) (res ImagesResponse, err error) {
+	_, err = c.doJson(ctx, http.MethodPost, ImageGenerationsRoute, nil, r, &res)
+	return
+}
+
+func (c *Client) CreateDalle3Image(ctx context.Context, r Dalle3ImageRequest) (res ImagesResponse, err error) {
+	_, err = c.doJson(ctx, http.MethodPost, ImageGenerationsRoute, nil, r, &res)
+	return
+}
+
+func (c *Client) EditImage(ctx context.Context, r ImageFileRequest) (res ImagesResponse, err error) {
+	files := []FormFile{{FieldName: "image", FileName: r.FileName, Content: r.File}}
+	if r.Mask != nil {
+		files = append(files, FormFile{FieldName: "mask", FileName: r.MaskFileName, Content: r.Mask})
+	}
+
+	_, err = c.doForm(ctx, ImageEditsRoute, r.Fields, files, &res)
+	return
+}
+
+func (c *Client) CreateImageVariation(ctx context.Context, r ImageFileRequest) (res ImagesResponse, err error) {
+	_, err = c.doForm(ctx, ImageVariationsRoute, r.Fields, []FormFile{{FieldName: "image", FileName: r.FileName, Content: r.File}}, &res)
+	return
+}
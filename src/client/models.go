@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+const ModelsRoute string = "/v1/models"
+
+/*
+Describes an OpenAI model offering that can be used with the API.
+
+id: string
+The model identifier, which can be referenced in the API endpoints.
+
+created: integer
+The Unix timestamp (in seconds) when the model was created.
+
+object: string
+The object type, which is always "model".
+
+owned_by: string
+The organization that owns the model.
+*/
+type Model struct {
+	Id      string `json:"id"`
+	Object  string `json:"object"`
+	Created int    `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type ModelList struct {
+	Object string  `json:"object"`
+	Data   []Model `json:"data"`
+}
+
+type DeleteModelResponse struct {
+	Id      string `json:"id"`
+	Object  string `json:"object"`
+	Deleted bool   `json:"deleted"`
+}
+
+func (c *Client) ListModels(ctx context.Context) (res ModelList, err error) {
+	_, err = c.doJson(ctx, http.MethodGet, ModelsRoute, nil, nil, &res)
+	return
+}
+
+func (c *Client) RetrieveModel(ctx context.Context, id string) (res Model, err error) {
+	_, err = c.doJson(ctx, http.MethodGet, ModelsRoute+"/"+id, nil, nil, &res)
+	return
+}
+
+// Deletes a fine-tuned model.
+func (c *Client) DeleteModel(ctx context.Context, id string) (res DeleteModelResponse, err error) {
+	_, err = c.doJson(ctx, http.MethodDelete, ModelsRoute+"/"+id, nil, nil, &res)
+	return
+}
@@ -1,12 +1,10 @@
 package audio
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/ephex2/go-gpt-cli/audio"
-	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
 	"github.com/spf13/cobra"
 )
 
@@ -18,7 +16,7 @@ var AudioCmd = &cobra.Command{
 var speechCmd = &cobra.Command{
 	Use:     "speech",
 	Short:   "Used to create an audio file from text.",
-	Run:     speechFunc,
+	RunE:    speechFunc,
 	Args:    cobra.MinimumNArgs(1),
 	Example: "go-gpt-cli audio speech \"Using quotes here is recommended. Inclusion of files is easy with $(cat filename)\"",
 }
@@ -26,7 +24,7 @@ var speechCmd = &cobra.Command{
 var promptCmd = &cobra.Command{
 	Use:     "prompt",
 	Short:   "Used get create a chat completion from a prompt, then read it over over the speaker. *Uses the default chat profile to generate the chat completion*",
-	Run:     promptFunc,
+	RunE:    promptFunc,
 	Args:    cobra.MinimumNArgs(1),
 	Example: "go-gpt-cli audio prompt Feel free to add as many strings as you like but 'terminals will act best if you enclose your prompt in quotes'",
 }
@@ -35,7 +33,7 @@ var playCmd = &cobra.Command{
 	Use:     "play",
 	Short:   "Plays an audio file from a provided path.",
 	Long:    "Plays an audio file from a provided path. Only supports .mp3, .wav, and .flac files",
-	Run:     playFunc,
+	RunE:    playFunc,
 	Args:    cobra.ExactArgs(1),
 	Example: "go-gpt-cli audio play ./mySong.mp3",
 }
@@ -43,7 +41,7 @@ var playCmd = &cobra.Command{
 var transcriptionCmd = &cobra.Command{
 	Use:     "transcript",
 	Short:   "Sends an audio file to the API in order to generate a text transcript. A prompt can be included in order to specify the textual 'style' of the transcript.",
-	Run:     transcriptFunc,
+	RunE:    transcriptFunc,
 	Args:    cobra.MinimumNArgs(1),
 	Example: "go-gpt-cli audio transcript ./mySong.mp3 'This will be the text 'style' for the transcript. It should include proper punctuation, capitalization, and include the use of commas when appropriate'",
 }
@@ -51,7 +49,7 @@ var transcriptionCmd = &cobra.Command{
 var verboseTranscriptionCmd = &cobra.Command{
 	Use:     "verbose_transcript",
 	Short:   "Same as 'transcript', but returns a much more verbose API response, including details about the segments of the transcribed text.",
-	Run:     verboseTranscriptFunc,
+	RunE:    verboseTranscriptFunc,
 	Args:    cobra.MinimumNArgs(1),
 	Example: "go-gpt-cli audio transcript ./mySong.mp3 'This will be the text 'style' for the transcript. It should include proper punctuation, capitalization, and include the use of commas when appropriate'",
 }
@@ -59,7 +57,7 @@ var verboseTranscriptionCmd = &cobra.Command{
 var translationCmd = &cobra.Command{
 	Use:     "translation",
 	Short:   "Sends an audio file to the API in order to generate a text translation to English. A prompt can be included in order to specify the textual 'style' of the transcript.",
-	Run:     translationFunc,
+	RunE:    translationFunc,
 	Args:    cobra.MinimumNArgs(1),
 	Example: "go-gpt-cli audio translation ./mySpeech.mp3 'This will be the text 'style' for the transcript. It should include proper punctuation, capitalization, and include the use of commas when appropriate'",
 }
@@ -67,7 +65,7 @@ var translationCmd = &cobra.Command{
 var verboseTranslationCmd = &cobra.Command{
 	Use:     "verbose_translation",
 	Short:   "Same as 'translation', but returns a much more verbose API response, including details about the segments of the transcribed text.",
-	Run:     verboseTranslationFunc,
+	RunE:    verboseTranslationFunc,
 	Args:    cobra.MinimumNArgs(1),
 	Example: "go-gpt-cli audio translation ./mySpeech.mp3 'This will be the text 'style' for the transcript. It should include proper punctuation, capitalization, and include the use of commas when appropriate'",
 }
@@ -82,34 +80,56 @@ func Execute(cmd *cobra.Command, args []string) (err error) {
 	return
 }
 
-func speechFunc(cmd *cobra.Command, args []string) {
+// Outputs of commands, stable for scripting. Transcripts and translations in the text format of the profile are
+// printed as is in the text output.
+type speech struct {
+	Path string
+}
+
+type spokenCompletion struct {
+	Content string
+	Path    string
+}
+
+type transcript struct {
+	Text string
+}
+
+func speechFunc(cmd *cobra.Command, args []string) (err error) {
 	s, err := audio.CreateSpeech(args)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(s)
+	err = output.PrintLine(speech{Path: s}, s)
+	if err != nil {
+		return err
+	}
+
+	return
 }
 
-func promptFunc(cmd *cobra.Command, args []string) {
-	err := audio.ReadAudioPrompt(args)
+func promptFunc(cmd *cobra.Command, args []string) (err error) {
+	err = audio.ReadAudioPrompt(args, func(msg string, speechPath string) error {
+		return output.PrintLine(spokenCompletion{Content: msg, Path: speechPath}, msg)
+	})
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
+
+	return
 }
 
-func playFunc(cmd *cobra.Command, args []string) {
-	err := audio.PlayAudioFile(args[0])
+func playFunc(cmd *cobra.Command, args []string) (err error) {
+	err = audio.PlayAudioFile(args[0])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
+
+	return
 }
 
-func transcriptFunc(cmd *cobra.Command, args []string) {
-	var err error
+func transcriptFunc(cmd *cobra.Command, args []string) (err error) {
 	var s string
 
 	if len(args) > 1 {
@@ -119,15 +139,18 @@ func transcriptFunc(cmd *cobra.Command, args []string) {
 	}
 
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
+	}
+
+	err = output.PrintLine(transcript{Text: s}, s)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println(s)
+	return
 }
 
-func verboseTranscriptFunc(cmd *cobra.Command, args []string) {
-	var err error
+func verboseTranscriptFunc(cmd *cobra.Command, args []string) (err error) {
 	var resp audio.CreateVerboseTranscriptionResponse
 
 	if len(args) > 1 {
@@ -137,21 +160,18 @@ func verboseTranscriptFunc(cmd *cobra.Command, args []string) {
 	}
 
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(resp, "", "    ")
+	err = output.Print(resp, nil)
 	if err != nil {
-		log.Warning("Unable to marshal json from response, here is the unmarshalled response:")
-		fmt.Println(resp)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
-func translationFunc(cmd *cobra.Command, args []string) {
-	var err error
+func translationFunc(cmd *cobra.Command, args []string) (err error) {
 	var s string
 
 	if len(args) > 1 {
@@ -161,15 +181,18 @@ func translationFunc(cmd *cobra.Command, args []string) {
 	}
 
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
+	}
+
+	err = output.PrintLine(transcript{Text: s}, s)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println(s)
+	return
 }
 
-func verboseTranslationFunc(cmd *cobra.Command, args []string) {
-	var err error
+func verboseTranslationFunc(cmd *cobra.Command, args []string) (err error) {
 	var resp audio.CreateVerboseTranslationResponse
 
 	if len(args) > 1 {
@@ -179,17 +202,15 @@ func verboseTranslationFunc(cmd *cobra.Command, args []string) {
 	}
 
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(resp, "", "    ")
+	err = output.Print(resp, nil)
 	if err != nil {
-		log.Warning("Unable to marshal json from response, here is the unmarshalled response:")
-		fmt.Println(resp)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
 func init() {
@@ -0,0 +1,35 @@
+package audio_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/cmd/cmdtest"
+	"github.com/ephex2/go-gpt-cli/mock"
+)
+
+func TestTranscript(t *testing.T) {
+	env := cmdtest.New(t, mock.Options{})
+
+	path := filepath.Join(t.TempDir(), "speech.mp3")
+	err := os.WriteFile(path, []byte("ID3 not really an mp3"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := env.MustRun("audio", "transcript", path)
+	if strings.TrimSpace(out) == "" {
+		t.Error("expected the transcript to be printed")
+	}
+
+	requests := env.Mock.Requests()
+	if len(requests) != 1 || requests[0].Route != "/v1/audio/transcriptions" {
+		t.Fatalf("expected a single transcription request, got %d requests", len(requests))
+	}
+
+	if !strings.HasPrefix(requests[0].Header.Get("Content-Type"), "multipart/form-data") {
+		t.Errorf("expected a multipart request, got: %s", requests[0].Header.Get("Content-Type"))
+	}
+}
@@ -1,12 +1,10 @@
 package batches
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/ephex2/go-gpt-cli/batches"
-	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +17,7 @@ var createCmd = &cobra.Command{
 	Use:     "create",
 	Short:   "Used to create a batches job for a model",
 	Long:    "Used to create a batches job for a given api endpoint. Must specify both a file containing jsonl data that has been uploaded, and an endpoint string.\nThe batch will not complete immediately, and other commands can be used to check the status of the batch.",
-	Run:     createFunc,
+	RunE:    createFunc,
 	Args:    cobra.ExactArgs(2),
 	Example: "go-gpt-cli batches create fileId targetEndPoint",
 }
@@ -28,7 +26,7 @@ var cancelCmd = &cobra.Command{
 	Use:     "cancel",
 	Short:   "Used to cancel a specific batches job.",
 	Long:    "Used to cancel a specific batches job. Must specify the ID when performing the call.",
-	Run:     cancelFunc,
+	RunE:    cancelFunc,
 	Args:    cobra.ExactArgs(1),
 	Example: "go-gpt-cli batches cancel batchId-abc123",
 }
@@ -37,7 +35,7 @@ var getCmd = &cobra.Command{
 	Use:     "get",
 	Short:   "Used to get a specific batches job started by the vendor.",
 	Long:    "Used to get a specific batches job started by the vendor. Must specify the ID when performing the call, which is returned for each batches job with the jobs command",
-	Run:     getFunc,
+	RunE:    getFunc,
 	Args:    cobra.ExactArgs(1),
 	Example: "go-gpt-cli batches get batchId-abc123",
 }
@@ -46,7 +44,7 @@ var listCmd = &cobra.Command{
 	Use:     "list",
 	Short:   "Used to list all jobs running.",
 	Long:    "Used to list all jobs running. Is limited to your organization when calling the OpenAI api.",
-	Run:     listFunc,
+	RunE:    listFunc,
 	Args:    cobra.ExactArgs(0),
 	Example: "go-gpt-cli batches list",
 }
@@ -60,7 +58,7 @@ func Execute(cmd *cobra.Command, args []string) (err error) {
 	return
 }
 
-func createFunc(cmd *cobra.Command, args []string) {
+func createFunc(cmd *cobra.Command, args []string) (err error) {
 	var id string
     var endpoint string 
 
@@ -69,66 +67,57 @@ func createFunc(cmd *cobra.Command, args []string) {
 
 	batches, err := batches.CreateBatch(id, endpoint)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(batches, "", "    ")
+	err = output.Print(batches, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
-func cancelFunc(cmd *cobra.Command, args []string) {
+func cancelFunc(cmd *cobra.Command, args []string) (err error) {
 	job, err := batches.CancelBatch(args[0])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(job, "", "    ")
+	err = output.Print(job, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
-func getFunc(cmd *cobra.Command, args []string) {
+func getFunc(cmd *cobra.Command, args []string) (err error) {
 	job, err := batches.GetBatch(args[0])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(job, "", "    ")
+	err = output.Print(job, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	// prints out actual batches contents
-	fmt.Println(string(buf))
+	return
 }
 
-func listFunc(cmd *cobra.Command, args []string) {
+func listFunc(cmd *cobra.Command, args []string) (err error) {
 	jobs, err := batches.ListBatches()
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(jobs, "", "    ")
+	err = output.Print(jobs, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
 func init() {
@@ -0,0 +1,28 @@
+package batches_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/cmd/cmdtest"
+	"github.com/ephex2/go-gpt-cli/mock"
+)
+
+func TestCreateGetCancel(t *testing.T) {
+	env := cmdtest.New(t, mock.Options{})
+
+	out := env.MustRun("batches", "create", "file-abc", "/v1/chat/completions")
+	if !strings.Contains(out, `"input_file_id": "file-abc"`) {
+		t.Fatalf("expected the created batch to be printed, got: %q", out)
+	}
+
+	out = env.MustRun("batches", "get", "batch-mock1")
+	if !strings.Contains(out, `"status": "validating"`) {
+		t.Errorf("expected the batch to be validating, got: %q", out)
+	}
+
+	out = env.MustRun("batches", "cancel", "batch-mock1")
+	if !strings.Contains(out, `"status": "cancel`) {
+		t.Errorf("expected the batch to be cancelled, got: %q", out)
+	}
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/api"
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/output"
+	"github.com/spf13/cobra"
+)
+
+var CacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Allows you to inspect and manage the cache of API responses",
+	Long:  "Responses to deterministic requests (temperature of 0, or a seed set) are cached on disk when the --cache flag is used, or when the Cache option of the profile is true.",
+}
+
+var statsCmd = &cobra.Command{
+	Use:     "stats",
+	Short:   "Shows the number of cached responses, their size and the cache limits.",
+	RunE:    statsFunc,
+	Args:    cobra.ExactArgs(0),
+	Example: "go-gpt-cli cache stats",
+}
+
+var clearCmd = &cobra.Command{
+	Use:     "clear",
+	Short:   "Removes cached responses.",
+	RunE:    clearFunc,
+	Args:    cobra.ExactArgs(0),
+	Example: "go-gpt-cli cache clear --expired",
+}
+
+var limitsCmd = &cobra.Command{
+	Use:     "limits",
+	Short:   "Sets the duration for which responses are cached and the max size of the cache.",
+	RunE:    limitsFunc,
+	Args:    cobra.ExactArgs(0),
+	Example: "go-gpt-cli cache limits --ttl 72h --max-size 500",
+}
+
+var expiredOnly bool
+var ttl time.Duration
+var maxSizeMB int64
+
+func Execute(cmd *cobra.Command, args []string) (err error) {
+	if len(args) < 1 {
+		cmd.Help()
+	}
+
+	err = cmd.Execute()
+	return
+}
+
+func statsFunc(cmd *cobra.Command, args []string) (err error) {
+	stats, err := api.GetCacheStats()
+	if err != nil {
+		return err
+	}
+
+	err = output.Print(stats, nil)
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func clearFunc(cmd *cobra.Command, args []string) (err error) {
+	removed, err := api.ClearCache(expiredOnly)
+	if err != nil {
+		return err
+	}
+
+	err = output.PrintLine(map[string]int{"Removed": removed}, fmt.Sprintf("Removed %d cached responses", removed))
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func limitsFunc(cmd *cobra.Command, args []string) (err error) {
+	newTTL := config.CacheTTL()
+	if cmd.Flags().Changed("ttl") {
+		newTTL = ttl
+	}
+
+	newMaxSize := config.CacheMaxSize()
+	if cmd.Flags().Changed("max-size") {
+		newMaxSize = maxSizeMB * 1024 * 1024
+	}
+
+	err = config.SetCacheLimits(newTTL, newMaxSize)
+	if err != nil {
+		return err
+	}
+
+	limits := cacheLimits{TTL: newTTL.String(), MaxSizeMB: newMaxSize / 1024 / 1024}
+	err = output.PrintLine(limits, fmt.Sprintf("Responses are cached for %s, in a cache of at most %d MB", newTTL, limits.MaxSizeMB))
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+type cacheLimits struct {
+	TTL       string
+	MaxSizeMB int64
+}
+
+func init() {
+	clearCmd.Flags().BoolVar(&expiredOnly, "expired", false, "Only remove the responses older than the cache ttl")
+	limitsCmd.Flags().DurationVar(&ttl, "ttl", 0, "Duration for which responses are served from the cache, ex: 24h")
+	limitsCmd.Flags().Int64Var(&maxSizeMB, "max-size", 0, "Max size of the cache in MB, the least recently used responses are evicted first")
+
+	CacheCmd.AddCommand(statsCmd)
+	CacheCmd.AddCommand(clearCmd)
+	CacheCmd.AddCommand(limitsCmd)
+}
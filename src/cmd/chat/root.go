@@ -1,11 +1,9 @@
 package chat
 
 import (
-	"fmt"
-	"os"
 
 	"github.com/ephex2/go-gpt-cli/chat"
-	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
 	"github.com/spf13/cobra"
 )
 
@@ -18,7 +16,7 @@ var ChatCmd = &cobra.Command{
 var promptCmd = &cobra.Command{
 	Use:     "prompt",
 	Short:   "Used to get a prompt from a chat completion, then create a .mp3 file using the audio create speech endpoint. *Reads the file over the speaker*",
-	Run:     promptFunc,
+	RunE:    promptFunc,
 	Args:    cobra.MinimumNArgs(1),
 	Example: "go-gpt-cli chat prompt Feel free to add as many strings as you like but 'terminals will act best if you enclose your prompt in quotes'",
 }
@@ -28,7 +26,7 @@ var visionCmd = &cobra.Command{
 	Use:     "vision",
 	Short:   "Used to make vision requests to multi-modal LLMs.",
 	Long:    "Used to make vision requests to multi-modal LLMs. The first argument is a path to a valid image file, all other arguments are concatenated as a prompt.",
-	Run:     visionFunc,
+	RunE:    visionFunc,
 	Args:    cobra.MinimumNArgs(2),
 	Example: "go-gpt-cli chat vision ./myimage.png'terminals will act best if you enclose your prompt in quotes'",
 }
@@ -38,7 +36,7 @@ var clearCmd = &cobra.Command{
     Use: "clear",
 	Short:   "Used to clear all historical messages when message history is enabled in the profile.",
 	Long:   "Used to clear all historical messages when message history is enabled in the profile. Should have no effect when the chat profile does not support history",
-	Run:     clearFunc,
+	RunE:    clearFunc,
 	Args:    cobra.ExactArgs(0),
 	Example: "go-gpt-cli chat clear",
     
@@ -55,34 +53,48 @@ func Execute(cmd *cobra.Command, args []string) (err error) {
 }
 
 
-func promptFunc(cmd *cobra.Command, args []string) {
+// Output of completions, stable for scripting.
+type completion struct {
+	Content string
+}
+
+func promptFunc(cmd *cobra.Command, args []string) (err error) {
 	s, err := chat.CreateChatCompletion(args)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
+	}
+
+	err = output.PrintLine(completion{Content: s}, s)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println(s)
+	return
 }
 
 
-func visionFunc(cmd *cobra.Command, args []string) {
+func visionFunc(cmd *cobra.Command, args []string) (err error) {
 	s, err := chat.CreateVisionChatCompletion(args[0], args[1:])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(s)
+	err = output.PrintLine(completion{Content: s}, s)
+	if err != nil {
+		return err
+	}
+
+	return
 }
 
 
-func clearFunc(cmd *cobra.Command, args []string) {
-    err := chat.ClearMessageHistory()
+func clearFunc(cmd *cobra.Command, args []string) (err error) {
+    err = chat.ClearMessageHistory()
     if err != nil {
-        log.Critical(err.Error() + "\n")
-        os.Exit(1)
+        return err
     }
+
+	return
 }
 
 
@@ -0,0 +1,18 @@
+package chat_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/cmd/cmdtest"
+	"github.com/ephex2/go-gpt-cli/mock"
+)
+
+func TestPrompt(t *testing.T) {
+	env := cmdtest.New(t, mock.Options{Mode: mock.ModeEcho})
+
+	out := env.MustRun("chat", "prompt", "Hello", "there")
+	if !strings.Contains(out, "Hello there") {
+		t.Errorf("expected the prompt to be echoed, got: %q", out)
+	}
+}
@@ -0,0 +1,70 @@
+package cmdtest
+
+// The cmdtest package runs command lines of the CLI from tests, against a mock server, with the settings, profiles and
+// usage records of a runtime kept in memory:
+//
+//	env := cmdtest.New(t, mock.Options{})
+//	out, err := env.Run("chat", "prompt", "Hello")
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/app"
+	"github.com/ephex2/go-gpt-cli/cmd"
+	"github.com/ephex2/go-gpt-cli/mock"
+	"github.com/ephex2/go-gpt-cli/output"
+)
+
+const ApiKey string = "sk-test"
+
+type Env struct {
+	Runtime *app.Runtime
+	Mock    *mock.Server // Holds the requests received, see mock.Server.Requests
+	Server  *httptest.Server
+	t       *testing.T
+}
+
+// Starts a mock server, closed when the test ends, and builds an in-memory runtime whose requests are sent to it.
+func New(t *testing.T, options mock.Options) *Env {
+	t.Helper()
+
+	m := mock.NewServer(options)
+	server := httptest.NewServer(m)
+	t.Cleanup(server.Close)
+
+	rt, err := app.NewMemory(map[string]string{"BaseUrl": server.URL, "ApiKey": ApiKey}, server.Client())
+	if err != nil {
+		t.Fatalf("unable to build the runtime: %s", err)
+	}
+
+	return &Env{Runtime: rt, Mock: m, Server: server, t: t}
+}
+
+// Runs a command line and returns what it printed. Command lines run one after the other share the runtime, profiles
+// created by one are read by the next.
+func (e *Env) Run(args ...string) (out string, err error) {
+	e.t.Helper()
+
+	var buf bytes.Buffer
+	previous := output.Writer
+	output.Writer = &buf
+	defer func() { output.Writer = previous }()
+
+	err = cmd.ExecuteWith(e.Runtime, args)
+	out = buf.String()
+	return
+}
+
+// Same as Run, failing the test when the command fails.
+func (e *Env) MustRun(args ...string) (out string) {
+	e.t.Helper()
+
+	out, err := e.Run(args...)
+	if err != nil {
+		e.t.Fatalf("%v failed: %s", args, err)
+	}
+
+	return
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/ephex2/go-gpt-cli/api"
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/output"
+	"github.com/spf13/cobra"
+)
+
+var adapterCmd = &cobra.Command{
+	Use:   "adapter",
+	Short: "Sets how requests are routed and authenticated, for APIs compatible with OpenAI's such as Azure OpenAI. Shows the current adapter settings without arguments.",
+	Long: "Sets how requests are routed and authenticated, in the active context or in the global settings when no context is active. Profiles can override these settings with their Adapter, AuthScheme, AuthHeader, ApiVersion and Deployment options.\n\n" +
+		"The " + api.AdapterAzure + " adapter rewrites routes to /openai/deployments/{deployment}/... with the api-version query parameter, the deployment being the model of the request unless --deployment is set. It authenticates with the api-key header unless another --auth-scheme is chosen.",
+	RunE:    adapterFunc,
+	Args:    cobra.MaximumNArgs(1),
+	Example: "go-gpt-cli config adapter\ngo-gpt-cli config adapter azure --api-version 2024-06-01\ngo-gpt-cli config adapter openai --auth-scheme header --auth-header X-Api-Key",
+}
+
+var adapterSettings config.AdapterSettings
+
+func adapterFunc(cmd *cobra.Command, args []string) (err error) {
+	if len(args) == 0 {
+		err = output.Print(config.Adapter(), nil)
+		if err != nil {
+			return err
+		}
+
+		return
+	}
+
+	adapterSettings.Adapter = args[0]
+	err = api.ValidateAdapter(adapterSettings)
+	if err == nil {
+		err = config.SetAdapter(adapterSettings)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+// Registers the flags of the adapter settings, shared by the adapter and context create commands.
+func adapterFlags(cmd *cobra.Command, s *config.AdapterSettings) {
+	cmd.Flags().StringVar(&s.AuthScheme, "auth-scheme", "", "How the api key is sent, one of: "+strings.Join(api.AuthSchemes(), ", ")+". Defaults to the scheme of the adapter")
+	cmd.Flags().StringVar(&s.AuthHeader, "auth-header", "", "Header holding the api key with the "+api.AuthHeader+" auth scheme")
+	cmd.Flags().StringVar(&s.ApiVersion, "api-version", "", "api-version query parameter sent to Azure")
+	cmd.Flags().StringVar(&s.Deployment, "deployment", "", "Azure deployment requests are sent to, the model of the request is used when empty")
+}
+
+func init() {
+	adapterFlags(adapterCmd, &adapterSettings)
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ephex2/go-gpt-cli/api"
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/secret"
+	"github.com/ephex2/go-gpt-cli/output"
+	"github.com/spf13/cobra"
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Allows you to manage named contexts, each bundling a base url, api key, organization, project, headers and default profiles",
+	Long:  "Contexts bundle the settings of a connection. While a context is active, its settings take priority over the global ones, and commands such as 'config seturl', 'config apikey' and 'profile default' write to it. Use the --context flag to select a context for a single command, or '" + config.GlobalContext + "' for the global settings.",
+}
+
+var contextCreateCmd = &cobra.Command{
+	Use:     "create",
+	Short:   "Creates a context. The api key follows the same sources as 'config apikey'.",
+	RunE:    contextCreateFunc,
+	Args:    cobra.ExactArgs(1),
+	Example: "go-gpt-cli config context create local --url http://127.0.0.1:8080\ngo-gpt-cli config context create azure --url https://my-resource.openai.azure.com --adapter azure --api-version 2024-06-01 --apikey AZURE_OPENAI_API_KEY --apikey-source env\ngo-gpt-cli config context create work --apikey WORK_OPENAI_KEY --apikey-source env --organization org-123 --project proj_456 --use",
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:     "use",
+	Short:   "Makes a context the current one, '" + config.GlobalContext + "' goes back to the global settings.",
+	RunE:    contextUseFunc,
+	Args:    cobra.ExactArgs(1),
+	Example: "go-gpt-cli config context use local",
+}
+
+var contextListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "Lists contexts, the current one is marked with *.",
+	RunE:    contextListFunc,
+	Args:    cobra.ExactArgs(0),
+	Example: "go-gpt-cli config context list",
+}
+
+var contextDeleteCmd = &cobra.Command{
+	Use:     "delete",
+	Short:   "Deletes a context and its settings.",
+	RunE:    contextDeleteFunc,
+	Args:    cobra.ExactArgs(1),
+	Example: "go-gpt-cli config context delete local",
+}
+
+var contextCurrentCmd = &cobra.Command{
+	Use:     "current",
+	Short:   "Shows the active context and its settings, secrets are masked unless --show-secrets is used.",
+	RunE:    contextCurrentFunc,
+	Args:    cobra.ExactArgs(0),
+	Example: "go-gpt-cli config context current",
+}
+
+var contextUrl string
+var contextApiKey string
+var contextApiKeySource string
+var contextOrganization string
+var contextProject string
+var contextHeaders []string
+var contextUse bool
+var contextAdapter config.AdapterSettings
+
+func contextCreateFunc(cmd *cobra.Command, args []string) (err error) {
+	c := config.Context{
+		Name:            args[0],
+		BaseUrl:         contextUrl,
+		Organization:    contextOrganization,
+		Project:         contextProject,
+		Headers:         make(map[string]string),
+		AdapterSettings: contextAdapter,
+	}
+
+	err = parseHeaders(contextHeaders, c.Headers)
+	if err == nil && c.Adapter != "" {
+		err = api.ValidateAdapter(c.AdapterSettings)
+	}
+
+	if err == nil && contextApiKey != "" {
+		c.ApiKey, err = secret.Reference(contextApiKeySource, c.Name+".ApiKey", contextApiKey)
+	}
+
+	if err == nil {
+		err = config.CreateContext(c)
+	}
+
+	if err == nil && contextUse {
+		err = config.UseContext(c.Name)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+// Parses headers formatted as Name=Value.
+func parseHeaders(values []string, headers map[string]string) (err error) {
+	for _, h := range values {
+		name, value, found := strings.Cut(h, "=")
+		if !found || name == "" {
+			err = errors.New("invalid header " + h + ", headers must be formatted as Name=Value")
+			return
+		}
+
+		headers[name] = value
+	}
+
+	return
+}
+
+func contextUseFunc(cmd *cobra.Command, args []string) (err error) {
+	err = config.UseContext(args[0])
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func contextListFunc(cmd *cobra.Command, args []string) (err error) {
+	current := config.CurrentContext()
+	if current == "" {
+		current = config.GlobalContext
+	}
+
+	var contexts []contextEntry
+	var lines []string
+	for _, name := range append([]string{config.GlobalContext}, config.ListContexts()...) {
+		contexts = append(contexts, contextEntry{Name: name, Current: name == current})
+		if name == current {
+			lines = append(lines, "* "+name)
+		} else {
+			lines = append(lines, "  "+name)
+		}
+	}
+
+	err = output.PrintLines(contexts, lines)
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+type contextEntry struct {
+	Name    string
+	Current bool
+}
+
+func contextDeleteFunc(cmd *cobra.Command, args []string) (err error) {
+	err = config.DeleteContext(args[0])
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func contextCurrentFunc(cmd *cobra.Command, args []string) (err error) {
+	name := config.CurrentContext()
+	if name == "" {
+		err = output.PrintLine(config.Context{Name: config.GlobalContext}, config.GlobalContext)
+		if err != nil {
+			return err
+		}
+
+		return
+	}
+
+	c, err := config.GetContext(name)
+	if err != nil {
+		return err
+	}
+
+	if !showSecrets(cmd) && c.ApiKey != "" {
+		c.ApiKey = secret.Mask(c.ApiKey)
+	}
+
+	err = output.Print(c, nil)
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func init() {
+	contextCreateCmd.Flags().StringVar(&contextUrl, "url", "", "Base url of the context, the global base url is used when empty")
+	contextCreateCmd.Flags().StringVar(&contextApiKey, "apikey", "", "Api key of the context, or the environment variable, file or command providing it depending on --apikey-source")
+	contextCreateCmd.Flags().StringVar(&contextApiKeySource, "apikey-source", secret.SourcePlain, "Where the api key is stored, one of: "+strings.Join(secret.Sources, ", "))
+	contextCreateCmd.Flags().StringVar(&contextOrganization, "organization", "", "Organization id sent in the OpenAI-Organization header")
+	contextCreateCmd.Flags().StringVar(&contextProject, "project", "", "Project id sent in the OpenAI-Project header")
+	contextCreateCmd.Flags().StringArrayVar(&contextHeaders, "header", nil, "Extra header sent with every request, formatted as Name=Value. Can be repeated")
+	contextCreateCmd.Flags().StringVar(&contextAdapter.Adapter, "adapter", "", "How requests are routed, one of: "+strings.Join(api.Adapters(), ", ")+". The global adapter is used when empty")
+	adapterFlags(contextCreateCmd, &contextAdapter)
+	contextCreateCmd.Flags().BoolVar(&contextUse, "use", false, "Make the new context the current one")
+
+	contextCmd.AddCommand(contextCreateCmd)
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextListCmd)
+	contextCmd.AddCommand(contextDeleteCmd)
+	contextCmd.AddCommand(contextCurrentCmd)
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/secret"
+	"github.com/ephex2/go-gpt-cli/output"
+	"github.com/spf13/cobra"
+)
+
+var explainCmd = &cobra.Command{
+	Use:     "explain",
+	Short:   "Shows each effective setting and the layer which supplied it, secrets are masked unless --show-secrets is used.",
+	Long:    "Shows each effective setting and the layer which supplied it. Layers override each other in this order: default < global < context < project (" + config.ProjectFileName + " found walking up from the working directory) < env < flag. Settings can be set from the environment with GO_GPT_CLI_<SETTING>, ex: GO_GPT_CLI_BASE_URL, as well as OPENAI_API_KEY, OPENAI_BASE_URL, OPENAI_ORG_ID, OPENAI_PROJECT_ID and OPENAI_API_VERSION.",
+	RunE:    explainFunc,
+	Args:    cobra.ExactArgs(0),
+	Example: "go-gpt-cli config explain",
+}
+
+func explainFunc(cmd *cobra.Command, args []string) (err error) {
+	resolutions := config.Explain()
+	for i, r := range resolutions {
+		if !showSecrets(cmd) && config.IsSecretKey(r.Key) {
+			resolutions[i].Value = secret.Mask(r.Value)
+		}
+	}
+
+	err = output.Print(resolutions, func(out io.Writer) error {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SETTING\tVALUE\tLAYER\tSOURCE")
+
+		for _, r := range resolutions {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Key, r.Value, r.Layer, r.Source)
+		}
+
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	return
+}
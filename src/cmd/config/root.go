@@ -1,12 +1,14 @@
 package config
 
 import (
-	"encoding/json"
-	"fmt"
-	"os"
+	"errors"
+	"strings"
 
 	"github.com/ephex2/go-gpt-cli/config"
-	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/config/format"
+	"github.com/ephex2/go-gpt-cli/config/repository"
+	"github.com/ephex2/go-gpt-cli/config/secret"
+	"github.com/ephex2/go-gpt-cli/output"
 	"github.com/spf13/cobra"
 )
 
@@ -17,55 +19,115 @@ var ConfigCmd = &cobra.Command{
 
 var setKeyCmd = &cobra.Command{
 	Use:     "apikey",
-	Short:   "Used to set the apikey which will be used when authenticating to API endpoints. It is not encrypted or otherwise protected at rest.",
-	Run:     setKeyFunc,
-	Args:    cobra.ExactArgs(1),
+	Short:   "Used to set the apikey which will be used when authenticating to API endpoints. Stored as plaintext unless another source is chosen with --source.",
+	Long:    "Used to set the apikey which will be used when authenticating to API endpoints. With --source plain (default) or encrypted, the argument is the key itself, and it is prompted for when omitted. The encrypted source stores the key in a store encrypted with a passphrase, which is read from $" + secret.PassphraseEnv + " or prompted for. With --source env, file or cmd, the argument is an environment variable name, a file path, or a command printing the key, which are read each time the key is needed.",
+	RunE:    setKeyFunc,
+	Args:    cobra.MaximumNArgs(1),
     Aliases: []string{"setkey"},
-	Example: "go-gpt-cli config apikey 12345",
+	Example: "go-gpt-cli config apikey 12345\ngo-gpt-cli config apikey --source encrypted\ngo-gpt-cli config apikey OPENAI_API_KEY --source env\ngo-gpt-cli config apikey \"pass show openai\" --source cmd",
 }
 
 var setUrlCmd = &cobra.Command{
 	Use:     "seturl",
 	Short:   "Used to set the base url which will be used when calling API endpoints. Defaults to the OpenAI API url.",
-	Run:     setUrlFunc,
+	RunE:    setUrlFunc,
 	Args:    cobra.ExactArgs(1),
     Example: "go-gpt-cli config seturl http://my.alternative.name:port",
 }
 
+var profileFormatCmd = &cobra.Command{
+	Use:     "profileformat",
+	Short:   "Sets the format new profiles are stored in, one of: " + strings.Join(format.Formats, ", ") + ". Existing profiles keep their format.",
+	RunE:    profileFormatFunc,
+	Args:    cobra.ExactArgs(1),
+	Example: "go-gpt-cli config profileformat yaml",
+}
+
+var dirsCmd = &cobra.Command{
+	Use:     "dirs",
+	Short:   "Lists the directories holding the settings and profiles, local data such as the usage ledger, and the cache. They follow the XDG base directories, or " + repository.HomeEnv + " when set",
+	RunE:    dirsFunc,
+	Args:    cobra.ExactArgs(0),
+	Example: "go-gpt-cli config dirs",
+}
+
 var getCmd = &cobra.Command{
 	Use:     "get",
-	Short:   "Lists the current global settings",
-	Run:     getFunc,
+	Short:   "Lists the current global settings, secrets are masked unless --show-secrets is used",
+	RunE:    getFunc,
 	Args:    cobra.ExactArgs(0),
 	Example: "go-gpt-cli config get",
 }
 
-func setKeyFunc(cmd *cobra.Command, args []string) {
-	err := config.SetApiKey(args[0])
+var keySource string
+
+// Secrets are masked unless the global --show-secrets flag is used.
+func showSecrets(cmd *cobra.Command) bool {
+	show, _ := cmd.Flags().GetBool("show-secrets")
+	return show
+}
+
+func setKeyFunc(cmd *cobra.Command, args []string) (err error) {
+	var value string
+	if len(args) == 1 {
+		value = args[0]
+	} else if keySource == secret.SourcePlain || keySource == secret.SourceEncrypted {
+		value, err = secret.ReadSecret("Api key: ")
+	} else {
+		err = errors.New("an argument is required with the " + keySource + " source")
+	}
+
+	if err == nil {
+		err = config.SetApiKeyFrom(keySource, value)
+	}
+
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
+
+	return
 }
 
-func setUrlFunc(cmd *cobra.Command, args []string) {
-	err := config.SetBaseUrl(args[0])
+func setUrlFunc(cmd *cobra.Command, args []string) (err error) {
+	err = config.SetBaseUrl(args[0])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
+
+	return
+}
+
+func profileFormatFunc(cmd *cobra.Command, args []string) (err error) {
+	err = config.SetProfileFormat(args[0])
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func dirsFunc(cmd *cobra.Command, args []string) (err error) {
+	dirs := map[string]string{"Config": config.ConfigDir, "Data": config.DataDir, "Cache": config.CacheDir}
+	err = output.PrintLines(dirs, []string{"config: " + config.ConfigDir, "data:   " + config.DataDir, "cache:  " + config.CacheDir})
+	if err != nil {
+		return err
+	}
+
+	return
 }
 
-func getFunc(cmd *cobra.Command, args []string) {
-	tempConfig := config.RuntimeConfig
+func getFunc(cmd *cobra.Command, args []string) (err error) {
+	settings := config.MaskedSettings()
+	if showSecrets(cmd) {
+		settings = config.RuntimeConfig.Settings
+	}
 
-	buf, err := json.MarshalIndent(tempConfig.Settings, "", "  ")
+	err = output.Print(settings, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
 func Execute(cmd *cobra.Command, args []string) (err error) {
@@ -80,7 +142,14 @@ func Execute(cmd *cobra.Command, args []string) (err error) {
 }
 
 func init() {
+	setKeyCmd.Flags().StringVar(&keySource, "source", secret.SourcePlain, "Where the key is stored, one of: "+strings.Join(secret.Sources, ", "))
+
 	ConfigCmd.AddCommand(setKeyCmd)
 	ConfigCmd.AddCommand(setUrlCmd)
+	ConfigCmd.AddCommand(profileFormatCmd)
 	ConfigCmd.AddCommand(getCmd)
+	ConfigCmd.AddCommand(dirsCmd)
+	ConfigCmd.AddCommand(contextCmd)
+	ConfigCmd.AddCommand(explainCmd)
+	ConfigCmd.AddCommand(adapterCmd)
 }
@@ -0,0 +1,25 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/cmd/cmdtest"
+	"github.com/ephex2/go-gpt-cli/mock"
+)
+
+func TestShowSecrets(t *testing.T) {
+	env := cmdtest.New(t, mock.Options{})
+
+	for _, args := range [][]string{{"config", "get"}, {"config", "explain"}} {
+		out := env.MustRun(args...)
+		if strings.Contains(out, cmdtest.ApiKey) {
+			t.Errorf("%v: expected the api key to be masked, got: %s", args, out)
+		}
+
+		out = env.MustRun(append(args, "--show-secrets")...)
+		if !strings.Contains(out, cmdtest.ApiKey) {
+			t.Errorf("%v: expected the api key to be shown with --show-secrets, got: %s", args, out)
+		}
+	}
+}
@@ -1,12 +1,10 @@
 package embeddings
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/ephex2/go-gpt-cli/embeddings"
-	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +17,7 @@ var createCmd = &cobra.Command{
 	Use:     "create",
 	Short:   "Used to create embeddings from an array of strings input.",
 	Long:    "Used to create embeddings from an array of strings input. Each argument passed to the CLI separated by a space will be a separate element in the array.",
-	Run:     createFunc,
+	RunE:    createFunc,
 	Args:    cobra.MinimumNArgs(1),
 	Example: "go-gpt-cli embeddings create Feel free to add as many strings as you like but 'put strings in quotes if you want them to be one element in the array'",
 }
@@ -33,23 +31,18 @@ func Execute(cmd *cobra.Command, args []string) (err error) {
 	return
 }
 
-func createFunc(cmd *cobra.Command, args []string) {
+func createFunc(cmd *cobra.Command, args []string) (err error) {
 	res, err := embeddings.CreateEmbeddings(args)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	// Outputting embedding objects as string for now
-	for _, embedding := range res.Data {
-		buf, err := json.MarshalIndent(embedding, "", "    ")
-		if err != nil {
-			log.Critical(err.Error() + "\n")
-			os.Exit(1)
-		}
-
-		fmt.Println(string(buf))
+	err = output.Print(res.Data, nil)
+	if err != nil {
+		return err
 	}
+
+	return
 }
 
 func init() {
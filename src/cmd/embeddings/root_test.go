@@ -0,0 +1,30 @@
+package embeddings_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/cmd/cmdtest"
+	"github.com/ephex2/go-gpt-cli/mock"
+)
+
+func TestCreate(t *testing.T) {
+	env := cmdtest.New(t, mock.Options{})
+
+	env.MustRun("embeddings", "create", "first", "second")
+
+	requests := env.Mock.Requests()
+	if len(requests) != 1 || requests[0].Route != "/v1/embeddings" {
+		t.Fatalf("expected a single embeddings request, got %d requests", len(requests))
+	}
+
+	var body struct{ Input []string }
+	err := json.Unmarshal(requests[0].Body, &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(body.Input) != 2 || body.Input[0] != "first" || body.Input[1] != "second" {
+		t.Errorf("expected every argument to be an input, got: %v", body.Input)
+	}
+}
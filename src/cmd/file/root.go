@@ -1,12 +1,10 @@
 package file
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/ephex2/go-gpt-cli/file"
-	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +17,7 @@ var createCmd = &cobra.Command{
 	Use:               "create",
 	Short:             "Used to create a file for use in assistants or fine-tuning",
 	Long:              "Used to create a file for use in assistants or fine-tuning. Individual files cannot exceed 512MB or 2 million tokens for Assistants. The fine-tuning API only supports '.jsonl' files. An organization can upload a maximum of 100GB of files.",
-	Run:               createFunc,
+	RunE:              createFunc,
 	Args:              cobra.MinimumNArgs(2),
 	ValidArgsFunction: validFileCreateArgsFunc,
 	Example:           "go-gpt-cli file create fine-tune myFile.jsonl",
@@ -29,7 +27,7 @@ var deleteCmd = &cobra.Command{
 	Use:     "delete",
 	Short:   "Used to delete a specific file uploaded to the vendor.",
 	Long:    "Used to delete a specific file uploaded to the vendor. Must specify the ID when performing the call, which is returned for each file with the list operation",
-	Run:     deleteFunc,
+	RunE:    deleteFunc,
 	Args:    cobra.ExactArgs(1),
 	Example: "go-gpt-cli file delete fileId-abc123",
 }
@@ -38,7 +36,7 @@ var getCmd = &cobra.Command{
 	Use:     "get",
 	Short:   "Used to get a specific file uploaded to the vendor.",
 	Long:    "Used to get a specific file uploaded to the vendor. Must specify the ID when performing the call, which is returned for each file with the list operation",
-	Run:     getFunc,
+	RunE:    getFunc,
 	Args:    cobra.ExactArgs(1),
 	Example: "go-gpt-cli file get fileId-abc123",
 }
@@ -47,7 +45,7 @@ var listCmd = &cobra.Command{
 	Use:     "list",
 	Short:   "Used to list all files uploaded to the vendor.",
 	Long:    "Used to list all files uploaded to the vendor. This will list all files associated with the key's organization.",
-	Run:     listFunc,
+	RunE:    listFunc,
 	Args:    cobra.ExactArgs(0),
 	Example: "go-gpt-cli file list",
 }
@@ -56,7 +54,7 @@ var statCmd = &cobra.Command{
 	Use:     "stat",
 	Short:   "Used to get stats of a specific file uploaded to the vendor.",
 	Long:    "Used to get stats of a specific file uploaded to the vendor. Must specify the ID when performing the call, which is returned for each file with the list operation",
-	Run:     statFunc,
+	RunE:    statFunc,
 	Args:    cobra.ExactArgs(1),
 	Example: "go-gpt-cli file stat fileId-abc123",
 }
@@ -70,79 +68,80 @@ func Execute(cmd *cobra.Command, args []string) (err error) {
 	return
 }
 
-func createFunc(cmd *cobra.Command, args []string) {
+func createFunc(cmd *cobra.Command, args []string) (err error) {
 	file, err := file.CreateFile(args[0], args[1])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(file, "", "    ")
+	err = output.Print(file, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
-func deleteFunc(cmd *cobra.Command, args []string) {
+func deleteFunc(cmd *cobra.Command, args []string) (err error) {
 	del, err := file.DeleteFile(args[0])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(del, "", "    ")
+	err = output.Print(del, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
-func getFunc(cmd *cobra.Command, args []string) {
+func getFunc(cmd *cobra.Command, args []string) (err error) {
 	buf, err := file.GetFile(args[0])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
 	// prints out actual file contents
-	fmt.Println(string(buf))
+	err = output.PrintLine(fileContent{ID: args[0], Content: string(buf)}, string(buf))
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+type fileContent struct {
+	ID      string
+	Content string
 }
 
-func listFunc(cmd *cobra.Command, args []string) {
+func listFunc(cmd *cobra.Command, args []string) (err error) {
 	files, err := file.ListFiles()
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(files, "", "    ")
+	err = output.Print(files, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
-func statFunc(cmd *cobra.Command, args []string) {
+func statFunc(cmd *cobra.Command, args []string) (err error) {
 	file, err := file.StatFile(args[0])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(file, "", "    ")
+	err = output.Print(file, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
 func validFileCreateArgsFunc(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -0,0 +1,38 @@
+package file_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/cmd/cmdtest"
+	"github.com/ephex2/go-gpt-cli/mock"
+)
+
+func TestCreateListDelete(t *testing.T) {
+	env := cmdtest.New(t, mock.Options{})
+
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	err := os.WriteFile(path, []byte(`{"custom_id": "1"}`+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := env.MustRun("file", "create", "batch", path)
+	if !strings.Contains(out, `"filename": "requests.jsonl"`) {
+		t.Fatalf("expected the created file to be printed, got: %q", out)
+	}
+
+	out = env.MustRun("file", "list")
+	if !strings.Contains(out, `"id": "file-mock1"`) {
+		t.Fatalf("expected the created file to be listed, got: %q", out)
+	}
+
+	env.MustRun("file", "delete", "file-mock1")
+
+	out = env.MustRun("file", "list")
+	if strings.Contains(out, "file-mock1") {
+		t.Errorf("expected the deleted file not to be listed, got: %q", out)
+	}
+}
@@ -1,12 +1,10 @@
 package finetuning
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/ephex2/go-gpt-cli/finetuning"
-	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +17,7 @@ var createCmd = &cobra.Command{
 	Use:     "create",
 	Short:   "Used to create a finetuning job for a model",
 	Long:    "Used to create a finetuning job for a model. If no training file is specified, uses the training file from the default profile.\nThe job will not complete immediately, and other commands can be used to check the status of the job and the output model.",
-	Run:     createFunc,
+	RunE:    createFunc,
 	Args:    cobra.MaximumNArgs(1),
 	Example: "go-gpt-cli finetuning create trainingFile",
 }
@@ -28,7 +26,7 @@ var cancelCmd = &cobra.Command{
 	Use:     "cancel",
 	Short:   "Used to cancel a specific finetuning job.",
 	Long:    "Used to cancel a specific finetuning job. Must specify the ID when performing the call, which is returned for each finetuning job with the jobs operation",
-	Run:     cancelFunc,
+	RunE:    cancelFunc,
 	Args:    cobra.ExactArgs(1),
 	Example: "go-gpt-cli finetuning cancel fileId-abc123",
 }
@@ -37,7 +35,7 @@ var eventsCmd = &cobra.Command{
 	Use:     "events",
 	Short:   "Used to list all events for a specific job id.",
 	Long:    "Used to list all events for a specific job id. Is limited to your organization when calling the Open AI api.",
-	Run:     eventsFunc,
+	RunE:    eventsFunc,
 	Args:    cobra.ExactArgs(1),
 	Example: "go-gpt-cli finetuning events ftjob-abc123",
 }
@@ -46,7 +44,7 @@ var getCmd = &cobra.Command{
 	Use:     "get",
 	Short:   "Used to get a specific finetuning job started by the vendor.",
 	Long:    "Used to get a specific finetuning job started by the vendor. Must specify the ID when performing the call, which is returned for each finetuning job with the jobs command",
-	Run:     getFunc,
+	RunE:    getFunc,
 	Args:    cobra.ExactArgs(1),
 	Example: "go-gpt-cli finetuning get fileId-abc123",
 }
@@ -55,7 +53,7 @@ var jobsCmd = &cobra.Command{
 	Use:     "jobs",
 	Short:   "Used to list all jobs running.",
 	Long:    "Used to list all jobs running. Is limited to your organization when calling the Open AI api.",
-	Run:     jobsFunc,
+	RunE:    jobsFunc,
 	Args:    cobra.ExactArgs(0),
 	Example: "go-gpt-cli finetuning jobs",
 }
@@ -69,7 +67,7 @@ func Execute(cmd *cobra.Command, args []string) (err error) {
 	return
 }
 
-func createFunc(cmd *cobra.Command, args []string) {
+func createFunc(cmd *cobra.Command, args []string) (err error) {
 	var id string
 	if len(args) == 1 {
 		id = args[0]
@@ -77,82 +75,71 @@ func createFunc(cmd *cobra.Command, args []string) {
 
 	finetuning, err := finetuning.CreateJob(id)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(finetuning, "", "    ")
+	err = output.Print(finetuning, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
-func cancelFunc(cmd *cobra.Command, args []string) {
+func cancelFunc(cmd *cobra.Command, args []string) (err error) {
 	job, err := finetuning.CancelJob(args[0])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(job, "", "    ")
+	err = output.Print(job, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
-func eventsFunc(cmd *cobra.Command, args []string) {
+func eventsFunc(cmd *cobra.Command, args []string) (err error) {
 	events, err := finetuning.ListEvents(args[0])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(events, "", "    ")
+	err = output.Print(events, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
-func getFunc(cmd *cobra.Command, args []string) {
+func getFunc(cmd *cobra.Command, args []string) (err error) {
 	job, err := finetuning.GetJob(args[0])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(job, "", "    ")
+	err = output.Print(job, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	// prints out actual finetuning contents
-	fmt.Println(string(buf))
+	return
 }
 
-func jobsFunc(cmd *cobra.Command, args []string) {
+func jobsFunc(cmd *cobra.Command, args []string) (err error) {
 	jobs, err := finetuning.ListJobs()
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(jobs, "", "    ")
+	err = output.Print(jobs, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
 func init() {
@@ -0,0 +1,28 @@
+package finetuning_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/cmd/cmdtest"
+	"github.com/ephex2/go-gpt-cli/mock"
+)
+
+func TestCreateJobsCancel(t *testing.T) {
+	env := cmdtest.New(t, mock.Options{})
+
+	out := env.MustRun("finetuning", "create", "file-abc")
+	if !strings.Contains(out, `"training_file": "file-abc"`) {
+		t.Fatalf("expected the created job to be printed, got: %q", out)
+	}
+
+	out = env.MustRun("finetuning", "jobs")
+	if !strings.Contains(out, `"id": "ftjob-mock1"`) {
+		t.Errorf("expected the job to be listed, got: %q", out)
+	}
+
+	out = env.MustRun("finetuning", "cancel", "ftjob-mock1")
+	if !strings.Contains(out, `"status": "cancelled"`) {
+		t.Errorf("expected the job to be cancelled, got: %q", out)
+	}
+}
@@ -1,12 +1,14 @@
 package image
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"sort"
 
 	"github.com/ephex2/go-gpt-cli/image"
 	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/spf13/cobra"
 )
@@ -20,7 +22,7 @@ var createCmd = &cobra.Command{
 	Use:     "create",
 	Short:   "Used to create images from a prompt.",
 	Long:    "Used to create images from an array of strings input. The first string is the folder path where the image will be written. Note that since multiple images can be generated per request to the API, this may return an array of paths.",
-	Run:     createFunc,
+	RunE:    createFunc,
 	Args:    cobra.MinimumNArgs(2),
 	Example: "go-gpt-cli image create outputFolderPath 'image creation text prompt'",
 }
@@ -29,7 +31,7 @@ var dalle3CreateCmd = &cobra.Command{
 	Use:     "dalle3create",
 	Short:   "Used to create images from a prompt using the dalle3 OpenAI model.",
 	Long:    "Used to create images from an array of strings input using the dalle3 OpenAI model. The first string is the folder path where the image will be written. Note that since multiple images can be generated per request to the API, this may return an array of paths.",
-	Run:     dalle3CreateFunc,
+	RunE:    dalle3CreateFunc,
 	Args:    cobra.MinimumNArgs(2),
 	Example: "go-gpt-cli image dalle3create outputFolderPath 'image creation text prompt'",
 }
@@ -38,7 +40,7 @@ var editCmd = &cobra.Command{
 	Use:     "edit",
 	Short:   "Used to edit images from a given square image.",
 	Long:    "Used to edit images from a given square image. The first argument is the output folder path, the next is the path to the image to edit, the third is an optional path to a mask file, and the rest will be included a s aprompt to the API. A mask can be provided whose transparent areas (alpha is zero) determine where the image should be edited. The image file must be square, less than 4MB, and square for the OpenAI API. Image size will be determined dynamically at runtime.",
-	Run:     editFunc,
+	RunE:    editFunc,
 	Args:    cobra.MinimumNArgs(3),
 	Example: "go-gpt-cli image edit outputFolderPath myimage.png mymask.png 'A prompt describing the resulting edited images'",
 }
@@ -47,7 +49,7 @@ var variationCmd = &cobra.Command{
 	Use:     "variation",
 	Short:   "Used to create image variations from a given square image.",
 	Long:    "Used to create image variations from a given square image. The first argument is the output folder path, and the second one will be the image to create a variation for. The image file must be square, less than 4MB, and square for the OpenAI API. Image size will be determined dynamically at runtime.",
-	Run:     variationFunc,
+	RunE:    variationFunc,
 	Args:    cobra.ExactArgs(2),
 	Example: "go-gpt-cli image variation outputFolderPath myimage.png",
 }
@@ -61,35 +63,25 @@ func Execute(cmd *cobra.Command, args []string) (err error) {
 	return
 }
 
-func createFunc(cmd *cobra.Command, args []string) {
+func createFunc(cmd *cobra.Command, args []string) (err error) {
 	paths, revisedPrompt, err := image.CreateImage(args[0], args[1:])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	if revisedPrompt != "" {
-		fmt.Println("Prompt was revised to: " + revisedPrompt)
-	}
-
-	outputPaths(paths)
+	return outputPaths(paths, revisedPrompt)
 }
 
-func dalle3CreateFunc(cmd *cobra.Command, args []string) {
+func dalle3CreateFunc(cmd *cobra.Command, args []string) (err error) {
 	paths, revisedPrompt, err := image.CreateDalle3Image(args[0], args[1:])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
-	}
-
-	if revisedPrompt != "" {
-		fmt.Println("Prompt was revised to: " + revisedPrompt)
+		return err
 	}
 
-	outputPaths(paths)
+	return outputPaths(paths, revisedPrompt)
 }
 
-func editFunc(cmd *cobra.Command, args []string) {
+func editFunc(cmd *cobra.Command, args []string) (err error) {
 	var prompt []string
 	var mask *os.File
 	mask = nil
@@ -105,12 +97,10 @@ func editFunc(cmd *cobra.Command, args []string) {
 				if t.Extension() == ".png" {
 					mask, err = os.Open(potentialMask)
 					if err != nil {
-						log.Critical(err.Error() + "\n")
-                        os.Exit(1)
+						return err
 					}
 				} else {
-                    log.Critical("Only .png images are supported by the OpenAI API. The mask provided does not appear to be a .png file.\n")
-                    os.Exit(1)
+                    return errors.New("Only .png images are supported by the OpenAI API. The mask provided does not appear to be a .png file.")
                 }
 			}
 		}
@@ -119,8 +109,7 @@ func editFunc(cmd *cobra.Command, args []string) {
 	// prompt setup
 	if mask != nil {
 		if len(args) == 3 {
-			log.Critical("Please provide a prompt for your image edit; only a base image and mask were found as arguments.\n")
-			os.Exit(1)
+			return errors.New("Please provide a prompt for your image edit; only a base image and mask were found as arguments.")
 		}
 
 		prompt = append(prompt, args[3:]...)
@@ -130,34 +119,41 @@ func editFunc(cmd *cobra.Command, args []string) {
 
 	paths, err := image.CreateEdit(imagePath, mask, folderPath, prompt)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	outputPaths(paths)
+	return outputPaths(paths, "")
 }
 
-func variationFunc(cmd *cobra.Command, args []string) {
+func variationFunc(cmd *cobra.Command, args []string) (err error) {
     folderPath := args[0]
     filePath := args[1]
 
 	paths, err := image.CreateVariation(filePath, folderPath)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	outputPaths(paths)
+	return outputPaths(paths, "")
+}
+
+// Output of image commands, stable for scripting.
+type images struct {
+	Paths         []string
+	RevisedPrompt string `json:",omitempty"` // dall-e-3 only
 }
 
-func outputPaths(paths []string) {
+func outputPaths(paths []string, revisedPrompt string) error {
 	sort.Strings(paths)
 
 	log.Debug("Created images: ")
 
-	for _, path := range paths {
-		fmt.Println(path)
+	lines := paths
+	if revisedPrompt != "" {
+		lines = append([]string{"Prompt was revised to: " + revisedPrompt}, paths...)
 	}
+
+	return output.PrintLines(images{Paths: paths, RevisedPrompt: revisedPrompt}, lines)
 }
 
 func init() {
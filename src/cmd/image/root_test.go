@@ -0,0 +1,62 @@
+package image_test
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/cmd/cmdtest"
+	"github.com/ephex2/go-gpt-cli/mock"
+)
+
+func TestCreate(t *testing.T) {
+	env := cmdtest.New(t, mock.Options{})
+	dir := t.TempDir()
+
+	out := env.MustRun("image", "create", dir, "a cat")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || filepath.Ext(entries[0].Name()) != ".png" {
+		t.Fatalf("expected a single png image to be written, got: %v", entries)
+	}
+
+	if !strings.Contains(out, entries[0].Name()) {
+		t.Errorf("expected the path of the image to be printed, got: %q", out)
+	}
+}
+
+func TestVariation(t *testing.T) {
+	env := cmdtest.New(t, mock.Options{})
+	dir := t.TempDir()
+
+	path := filepath.Join(t.TempDir(), "image.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = png.Encode(f, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.MustRun("image", "variation", dir, path)
+
+	requests := env.Mock.Requests()
+	// The variation is followed by the download of the image
+	if len(requests) == 0 || requests[0].Route != "/v1/images/variations" {
+		t.Fatalf("expected a variation request, got %d requests", len(requests))
+	}
+
+	if !strings.HasPrefix(requests[0].Header.Get("Content-Type"), "multipart/form-data") {
+		t.Errorf("expected a multipart request, got: %s", requests[0].Header.Get("Content-Type"))
+	}
+}
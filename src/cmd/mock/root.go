@@ -0,0 +1,93 @@
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/mock"
+	"github.com/spf13/cobra"
+)
+
+var MockCmd = &cobra.Command{
+	Use:   "mock",
+	Short: "Allows you to run a local mock server implementing the routes used by this CLI",
+}
+
+var serveCmd = &cobra.Command{
+	Use:     "serve",
+	Short:   "Starts a mock OpenAI compatible server.",
+	Long:    "Starts a mock OpenAI compatible server implementing the chat, audio, images, embeddings, files, batches, fine-tuning and models routes. Use 'go-gpt-cli config seturl' to point the CLI at it. Responses can be canned, echo the request, or be scripted from a json file.",
+	RunE:    serveFunc,
+	Args:    cobra.ExactArgs(0),
+	Example: "go-gpt-cli mock serve --addr 127.0.0.1:8080 --mode echo --latency 200ms --error-rate 0.1",
+}
+
+var addr string
+var mode string
+var latency time.Duration
+var latencyJitter time.Duration
+var errorRate float64
+var errorStatus int
+var apiKey string
+var scriptPath string
+
+func Execute(cmd *cobra.Command, args []string) (err error) {
+	if len(args) < 1 {
+		cmd.Help()
+	}
+
+	err = cmd.Execute()
+	return
+}
+
+func serveFunc(cmd *cobra.Command, args []string) (err error) {
+	if mode != mock.ModeCanned && mode != mock.ModeEcho {
+		return fmt.Errorf("Mode not supported: %s. Supported modes are: %s, %s", mode, mock.ModeCanned, mock.ModeEcho)
+	}
+
+	if errorRate < 0 || errorRate > 1 {
+		return errors.New("The error rate must be between 0 and 1")
+	}
+
+	options := mock.Options{
+		Mode:          mode,
+		Latency:       latency,
+		LatencyJitter: latencyJitter,
+		ErrorRate:     errorRate,
+		ErrorStatus:   errorStatus,
+		ApiKey:        apiKey,
+	}
+
+	if scriptPath != "" {
+		script, err := mock.LoadScript(scriptPath)
+		if err != nil {
+			return err
+		}
+
+		options.Script = script
+	}
+
+	log.Info("Mock server listening on http://%s\n", addr)
+	err = http.ListenAndServe(addr, mock.NewServer(options))
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8080", "Address the mock server listens on")
+	serveCmd.Flags().StringVar(&mode, "mode", mock.ModeCanned, "Either 'canned' for fixed responses or 'echo' to echo request content back")
+	serveCmd.Flags().DurationVar(&latency, "latency", 0, "Latency added to every response, ex: 250ms")
+	serveCmd.Flags().DurationVar(&latencyJitter, "latency-jitter", 0, "Random extra latency added to every response, between 0 and this value")
+	serveCmd.Flags().Float64Var(&errorRate, "error-rate", 0, "Fraction of requests, between 0 and 1, answered with an error")
+	serveCmd.Flags().IntVar(&errorStatus, "error-status", http.StatusInternalServerError, "Status code of injected errors")
+	serveCmd.Flags().StringVar(&apiKey, "api-key", "", "When set, requests must authenticate with this key")
+	serveCmd.Flags().StringVar(&scriptPath, "script", "", "Path to a json file of scripted responses which take priority over built-in responses")
+
+	MockCmd.AddCommand(serveCmd)
+}
@@ -1,11 +1,8 @@
 package model
 
 import (
-	"encoding/json"
-	"fmt"
-	"os"
 
-	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
 	"github.com/ephex2/go-gpt-cli/model"
 	"github.com/spf13/cobra"
 )
@@ -19,7 +16,7 @@ var listCmd = &cobra.Command{
 	Use:     "list",
 	Short:   "Used to list all models",
 	Long:    "Used to list all models hosted in your organization within theOpenAI API",
-	Run:     listFunc,
+	RunE:    listFunc,
 	Args:    cobra.ExactArgs(0),
 	Example: "go-gpt-cli model list",
 }
@@ -28,7 +25,7 @@ var getCmd = &cobra.Command{
 	Use:     "get",
 	Short:   "Retrieves a specific model",
 	Long:    "Retrieves a specific model from within your organization with the OpenAI API",
-	Run:     getFunc,
+	RunE:    getFunc,
 	Args:    cobra.ExactArgs(1),
 	Example: "go-gpt-cli model get <modelName>",
 }
@@ -37,57 +34,51 @@ var deleteCmd = &cobra.Command{
 	Use:     "delete",
 	Short:   "Deletes a specific model",
 	Long:    "Deletes a specific model from within your organization with the OpenAI API",
-	Run:     deleteFunc,
+	RunE:    deleteFunc,
 	Args:    cobra.ExactArgs(1),
 	Example: "go-gpt-cli model delete <modelName>",
 }
 
-func listFunc(cmd *cobra.Command, args []string) {
+func listFunc(cmd *cobra.Command, args []string) (err error) {
 	m, err := model.ListModels()
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(m, "", "    ")
+	err = output.Print(m, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
-func getFunc(cmd *cobra.Command, args []string) {
+func getFunc(cmd *cobra.Command, args []string) (err error) {
 	m, err := model.RetrieveModel(args[0])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(m, "", "    ")
+	err = output.Print(m, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
-func deleteFunc(cmd *cobra.Command, args []string) {
+func deleteFunc(cmd *cobra.Command, args []string) (err error) {
 	m, err := model.DeleteModel(args[0])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	buf, err := json.MarshalIndent(m, "", "    ")
+	err = output.Print(m, nil)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println(string(buf))
+	return
 }
 
 func Execute(cmd *cobra.Command, args []string) (err error) {
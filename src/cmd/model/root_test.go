@@ -0,0 +1,30 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/cmd/cmdtest"
+	"github.com/ephex2/go-gpt-cli/mock"
+)
+
+func TestListGetDelete(t *testing.T) {
+	env := cmdtest.New(t, mock.Options{})
+
+	out := env.MustRun("model", "list")
+	if !strings.Contains(out, `"gpt-3.5-turbo"`) {
+		t.Fatalf("expected gpt-3.5-turbo to be listed, got: %q", out)
+	}
+
+	out = env.MustRun("model", "get", "gpt-3.5-turbo")
+	if !strings.Contains(out, "gpt-3.5-turbo") {
+		t.Errorf("expected the model to be printed, got: %q", out)
+	}
+
+	env.MustRun("model", "delete", "gpt-3.5-turbo")
+
+	out = env.MustRun("model", "list")
+	if strings.Contains(out, `"gpt-3.5-turbo"`) {
+		t.Errorf("expected the deleted model not to be listed, got: %q", out)
+	}
+}
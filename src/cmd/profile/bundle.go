@@ -0,0 +1,198 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
+
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:     "export",
+	Short:   "Exports profiles to a json bundle, which can be shared and imported with 'profile import'",
+	Long:    "Exports profiles to a json bundle. Profiles are selected as endpoint or endpoint/profile, every profile of every endpoint is exported without arguments. Profiles extending others are exported with their parents. Secrets are stripped: api keys, unless they reference an environment variable, and headers whose name contains authorization, key, token, secret or cookie",
+	RunE:    profileExportCommandRun,
+	Example: "go-gpt-cli profile export chat/codereview chat/base embeddings -f team-profiles.json",
+	Args:    cobra.ArbitraryArgs,
+}
+
+var importCmd = &cobra.Command{
+	Use:     "import",
+	Short:   "Imports the profiles of a bundle, or of every bundle in a directory such as a git clone",
+	Long:    "Imports the profiles of a bundle made by 'profile export'. When the path is a directory, every .json bundle it contains is imported, which allows a team to share bundles in a git repository, --pull updates the clone first. Profiles which already exist are skipped, overwritten, or imported as <name>-imported depending on --strategy, a profile is never overwritten by one sending requests to another host. The Url, Adapter, AuthScheme and AuthHeader settings of the profiles are dropped unless --trust is used, along with api keys read from a file, a command or the encrypted store",
+	RunE:    profileImportCommandRun,
+	Example: "go-gpt-cli profile import team-profiles.json --strategy rename\ngo-gpt-cli profile import ~/src/team-profiles --pull --strategy overwrite",
+	Args:    cobra.ExactArgs(1),
+}
+
+var exportFile string
+var importStrategy string
+var importPull bool
+var importTrust bool
+
+func profileExportCommandRun(cmd *cobra.Command, args []string) (err error) {
+	var selections []profile.Selection
+	if len(args) == 0 {
+		for _, e := range runtimeOf(cmd).Registry.Endpoints {
+			selections = append(selections, profile.Selection{Endpoint: e.Name()})
+		}
+	}
+
+	for _, arg := range args {
+		endpointName, profileName, _ := strings.Cut(arg, "/")
+		s := profile.Selection{}
+		s.Endpoint, err = checkEndpoint(cmd, endpointName)
+		if err != nil {
+			return
+		}
+
+		if profileName != "" {
+			s.Profiles = []string{profileName}
+		}
+
+		selections = append(selections, s)
+	}
+
+	b, stripped, err := profile.Export(runtimeOf(cmd).Repository, selections)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range stripped {
+		log.Warning("Stripped secret %s\n", s)
+	}
+
+	if exportFile == "" {
+		err = output.Print(b, nil)
+		if err != nil {
+			return err
+		}
+
+		return
+	}
+
+	buf, err := json.MarshalIndent(b, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(exportFile, buf, 0640)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Exported %d profiles to %s\n", len(b.Profiles), exportFile)
+
+	return
+}
+
+func profileImportCommandRun(cmd *cobra.Command, args []string) (err error) {
+	paths, err := bundlePaths(args[0])
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	var imported []profile.ImportResult
+	var lines []string
+	for _, path := range paths {
+		var b profile.Bundle
+		buf, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(buf, &b)
+		}
+
+		var results []profile.ImportResult
+		if err == nil {
+			results, err = profile.Import(runtimeOf(cmd).Repository, b, importStrategy, importTrust)
+		}
+
+		if err != nil {
+			log.Critical("Unable to import bundle %s: %s\n", path, err.Error())
+			failed = true
+			continue
+		}
+
+		for _, r := range results {
+			for _, w := range r.Warnings {
+				log.Warning("%s/%s: %s\n", r.Endpoint, r.Name, w)
+			}
+
+			switch {
+			case r.Err != nil:
+				log.Critical("%s/%s: %s\n", r.Endpoint, r.Name, r.Err.Error())
+				failed = true
+			case r.NewName != "":
+				lines = append(lines, fmt.Sprintf("%s/%s: %s as %s", r.Endpoint, r.Name, r.Action, r.NewName))
+			default:
+				lines = append(lines, fmt.Sprintf("%s/%s: %s", r.Endpoint, r.Name, r.Action))
+			}
+		}
+
+		imported = append(imported, results...)
+	}
+
+	err = output.PrintLines(imported, lines)
+	if err != nil {
+		return err
+	}
+
+	if failed {
+		return errors.New("some profiles could not be imported")
+	}
+
+	return
+}
+
+// Returns the bundle at a path, or the bundles of a directory, updating the directory first with --pull.
+func bundlePaths(path string) (paths []string, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	if !info.IsDir() {
+		if importPull {
+			err = errors.New("--pull requires the path of a directory cloned with git")
+		}
+
+		return []string{path}, err
+	}
+
+	if importPull {
+		c := exec.Command("git", "-C", path, "pull", "--ff-only")
+		c.Stdout = os.Stderr
+		c.Stderr = os.Stderr
+		err = c.Run()
+		if err != nil {
+			err = errors.New("unable to pull " + path + ": " + err.Error())
+			return
+		}
+	}
+
+	paths, err = filepath.Glob(filepath.Join(path, "*.json"))
+	if err == nil && len(paths) == 0 {
+		err = errors.New("no .json bundle found in " + path)
+	}
+
+	return
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFile, "file", "f", "", "File the bundle is written to, printed when empty")
+	importCmd.Flags().StringVar(&importStrategy, "strategy", profile.ConflictSkip, "What to do with profiles which already exist, one of: "+strings.Join(profile.ConflictStrategies, ", "))
+	importCmd.Flags().BoolVar(&importPull, "pull", false, "Run git pull in the directory before importing its bundles")
+	importCmd.Flags().BoolVar(&importTrust, "trust", false, "Import the Url, Adapter, AuthScheme and AuthHeader of the profiles, which choose where your api key is sent")
+
+	ProfileCmd.AddCommand(exportCmd)
+	ProfileCmd.AddCommand(importCmd)
+}
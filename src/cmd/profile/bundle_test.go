@@ -0,0 +1,38 @@
+package profile_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/cmd/cmdtest"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/mock"
+)
+
+func TestExport(t *testing.T) {
+	env := cmdtest.New(t, mock.Options{})
+	env.MustRun("profile", "create", "chat", "review")
+
+	path := filepath.Join(t.TempDir(), "team-profiles.json")
+	env.MustRun("profile", "export", "chat/review", "-f", path)
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b profile.Bundle
+	err = json.Unmarshal(buf, &b)
+	if err != nil || len(b.Profiles) != 1 || b.Profiles[0].Name != "review" {
+		t.Errorf("expected a bundle holding the review profile, got %s (%v)", buf, err)
+	}
+
+	// --output selects the format of the bundle printed when no file is given
+	out := env.MustRun("profile", "export", "chat/review", "--output", "json")
+	err = json.Unmarshal([]byte(out), &b)
+	if err != nil || len(b.Profiles) != 1 {
+		t.Errorf("expected the bundle to be printed as json, got %s (%v)", out, err)
+	}
+}
@@ -0,0 +1,365 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/ephex2/go-gpt-cli/config/format"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/log"
+
+	"github.com/spf13/cobra"
+)
+
+var setCmd = &cobra.Command{
+	Use:               "set",
+	Short:             "Sets a setting of a profile, addressed by its path",
+	Long:              "Sets a setting of a profile, addressed by its path: keys are separated by dots and array indexes are written in brackets, ex: CreateCompletionBody.messages[0].content. Values are parsed as the type of the setting: strings are taken as is unless quoted, numbers and booleans must be valid, arrays and objects are json and a single value given for an array is its only element. null clears settings which can be null. Setting the index equal to the length of an array appends to it",
+	RunE:              profileSetCommandRun,
+	Example:           "go-gpt-cli profile set chat default CreateCompletionBody.temperature 0.2\ngo-gpt-cli profile set chat default CreateCompletionBody.messages[0].content \"You are a code reviewer\"\ngo-gpt-cli profile set chat default Headers '{\"X-Team\": \"research\"}'",
+	Args:              cobra.ExactArgs(4),
+	ValidArgsFunction: validEndpointAndProfileArgs,
+}
+
+var unsetCmd = &cobra.Command{
+	Use:               "unset",
+	Short:             "Removes a setting of a profile, addressed by its path",
+	Long:              "Removes a setting of a profile, addressed by its path like with the set command. The setting goes back to its zero value, or to the value of the parent for profiles extending another one",
+	RunE:              profileUnsetCommandRun,
+	Example:           "go-gpt-cli profile unset chat codereview CreateCompletionBody.temperature",
+	Args:              cobra.ExactArgs(3),
+	ValidArgsFunction: validEndpointAndProfileArgs,
+}
+
+var editCmd = &cobra.Command{
+	Use:               "edit",
+	Short:             "Opens a profile in $VISUAL or $EDITOR, and saves it once it is validated",
+	Long:              "Opens a copy of a profile in $VISUAL or $EDITOR, vi (notepad on Windows) when neither is set. The profile is saved when the editor exits, if it is still valid. Profiles extending another one are edited as stored, with only the settings which differ from their parent. Profiles are edited as json, or as yaml or toml with --format",
+	RunE:              profileEditCommandRun,
+	Example:           "go-gpt-cli profile edit chat default\ngo-gpt-cli profile edit chat default --format yaml",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: validEndpointAndProfileArgs,
+}
+
+func profileSetCommandRun(cmd *cobra.Command, args []string) (err error) {
+	e, err := getEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
+	name, path := args[1], args[2]
+	value, err := profile.ParseValue(e, path, args[3])
+	if err != nil {
+		return
+	}
+
+	// Set on the resolved profile, so that paths within inherited settings exist
+	err = changeProfile(runtimeOf(cmd).Repository, e, name, true, func(doc map[string]any) error {
+		return profile.SetPath(doc, path, value)
+	}, func(p profile.Profile) error {
+		return checkPath(p, path, value)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func profileUnsetCommandRun(cmd *cobra.Command, args []string) (err error) {
+	e, err := getEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
+	name, path := args[1], args[2]
+
+	// Unset as stored, so that profiles extending another one inherit the setting again
+	err = changeProfile(runtimeOf(cmd).Repository, e, name, false, func(doc map[string]any) error {
+		return profile.UnsetPath(doc, path)
+	}, nil)
+
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+// Applies a change to the json of a profile, resolved or as stored, then validates and saves it. The profile is locked
+// from the read to the update so that changes made meanwhile by other invocations are not lost.
+func changeProfile(repo profile.Repository, e profile.Endpoint, name string, resolved bool, change func(map[string]any) error, check func(profile.Profile) error) (err error) {
+	unlock, err := repo.Lock(e.Name(), name)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	var raw []byte
+	if resolved {
+		raw, err = repo.Read(name, e.Name())
+	} else {
+		raw, err = repo.ReadRaw(name, e.Name())
+	}
+
+	if err != nil {
+		err = errors.New("unable to read profile " + name + ": " + err.Error())
+		return
+	}
+
+	var doc map[string]any
+	err = json.Unmarshal(raw, &doc)
+	if err != nil {
+		return
+	}
+
+	err = change(doc)
+	if err != nil {
+		return
+	}
+
+	raw, err = json.Marshal(doc)
+	if err != nil {
+		return
+	}
+
+	// Back to the settings which differ from the parent, so that settings cleared on the resolved profile are not
+	// inherited again once it is resolved
+	if resolved {
+		raw, err = profile.Overlay(func(n string) ([]byte, error) {
+			return repo.ReadRaw(n, e.Name())
+		}, name, raw)
+		if err != nil {
+			return
+		}
+	}
+
+	p, err := validateProfile(repo, e, name, raw)
+	if err != nil {
+		return
+	}
+
+	if check != nil {
+		err = check(p)
+		if err != nil {
+			return
+		}
+	}
+
+	err = repo.UpdateLocked(p)
+	return
+}
+
+// Returns the profile of stored json, resolving the profiles it extends, when it is a valid profile named name.
+// Errors list every invalid setting with its path.
+func validateProfile(repo profile.Repository, e profile.Endpoint, name string, raw []byte) (p profile.Profile, err error) {
+	var stored struct{ ProfileName string }
+	err = json.Unmarshal(raw, &stored)
+	if err != nil {
+		err = errors.New("invalid " + e.Name() + " profile: " + err.Error())
+		return
+	}
+
+	if stored.ProfileName != name {
+		err = errors.New("the ProfileName of profile " + name + " can not be changed")
+		return
+	}
+
+	read := func(n string) ([]byte, error) {
+		return repo.ReadRaw(n, e.Name())
+	}
+
+	resolved, err := profile.ResolveRaw(read, name, raw)
+	if err != nil {
+		return
+	}
+
+	p, err = profile.Validate(e, resolved)
+	if err != nil {
+		err = errors.New("invalid " + e.Name() + " profile " + name + ":\n" + err.Error())
+	}
+
+	return
+}
+
+// Settings which are not part of the profile are dropped when it is decoded, they are reported instead of being silently ignored.
+func checkPath(p profile.Profile, path string, value any) (err error) {
+	buf, err := json.Marshal(p)
+	if err != nil {
+		return
+	}
+
+	var doc any
+	err = json.Unmarshal(buf, &doc)
+	if err != nil {
+		return
+	}
+
+	_, ok, err := profile.GetPath(doc, path)
+	if err == nil && !ok && !isZero(value) {
+		err = errors.New("profiles of endpoint " + p.Endpoint().Name() + " have no setting at " + path)
+	}
+
+	return
+}
+
+// Zero values are omitted from the json of some settings, so they can not be found once set.
+func isZero(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case int64:
+		return v == 0
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+
+	return false
+}
+
+func profileEditCommandRun(cmd *cobra.Command, args []string) (err error) {
+	e, err := getEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
+	name := args[1]
+
+	err = format.Check(editFormat)
+	if err != nil {
+		return err
+	}
+
+	stored, err := runtimeOf(cmd).Repository.ReadRaw(name, e.Name())
+	if err != nil {
+		return fmt.Errorf("Error while trying to read profile: %s", err.Error())
+	}
+
+	raw := stored
+	var indented bytes.Buffer
+	err = json.Indent(&indented, raw, "", "    ")
+	if err == nil {
+		raw, err = format.FromJson(editFormat, indented.Bytes(), nil)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Error while trying to format profile: %s", err.Error())
+	}
+
+	f, err := os.CreateTemp("", "go-gpt-cli-"+e.Name()+"-"+name+"-*."+format.Extensions(editFormat)[0])
+	if err != nil {
+		return err
+	}
+
+	tmpPath := f.Name()
+	_, err = f.Write(raw)
+	f.Close()
+	if err == nil {
+		err = runEditor(tmpPath)
+	}
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	edited, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(edited, raw) {
+		os.Remove(tmpPath)
+		log.Info("Profile %s was not changed\n", name)
+		return
+	}
+
+	edited, err = format.ToJson(editFormat, edited)
+	if err == nil {
+		err = saveEdited(runtimeOf(cmd).Repository, e, name, stored, edited)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s\nThe profile was not saved, your changes are kept in %s", err.Error(), tmpPath)
+	}
+
+	os.Remove(tmpPath)
+
+	return
+}
+
+// The profile is not locked while the editor is open, which may be for a long time. It is locked once edited, and the
+// edits are only saved when the profile was not changed by another invocation meanwhile.
+func saveEdited(repo profile.Repository, e profile.Endpoint, name string, stored []byte, edited []byte) (err error) {
+	unlock, err := repo.Lock(e.Name(), name)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	current, err := repo.ReadRaw(name, e.Name())
+	if err != nil {
+		return
+	}
+
+	if !bytes.Equal(current, stored) {
+		return errors.New("profile " + name + " was changed by another invocation while it was edited")
+	}
+
+	p, err := validateProfile(repo, e, name, edited)
+	if err != nil {
+		return
+	}
+
+	return repo.UpdateLocked(p)
+}
+
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// Editors are often configured with arguments, ex: "code --wait"
+	fields := strings.Fields(editor)
+	c := exec.Command(fields[0], append(fields[1:], path)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+
+	err := c.Run()
+	if err != nil {
+		return errors.New("error while running the editor " + editor + ": " + err.Error())
+	}
+
+	return nil
+}
+
+var editFormat string
+
+func init() {
+	editCmd.Flags().StringVar(&editFormat, "format", format.Json, "Format the profile is edited in, one of: "+strings.Join(format.Formats, ", "))
+
+	ProfileCmd.AddCommand(setCmd)
+	ProfileCmd.AddCommand(unsetCmd)
+	ProfileCmd.AddCommand(editCmd)
+}
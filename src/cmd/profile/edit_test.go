@@ -0,0 +1,49 @@
+package profile_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/cmd/cmdtest"
+	"github.com/ephex2/go-gpt-cli/mock"
+)
+
+func readResolved(t *testing.T, env *cmdtest.Env, name string) (m map[string]any) {
+	t.Helper()
+
+	raw, err := env.Runtime.Repository.Read(name, "chat")
+	if err == nil {
+		err = json.Unmarshal(raw, &m)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func TestClearInheritedSettings(t *testing.T) {
+	env := cmdtest.New(t, mock.Options{})
+	env.MustRun("profile", "create", "chat", "base")
+	env.MustRun("profile", "set", "chat", "base", "ApiKey", "env:TEAM_KEY")
+	env.MustRun("profile", "set", "chat", "base", "Headers", `{"X-Team": "research"}`)
+	env.MustRun("profile", "create", "chat", "child", "--extends", "base")
+
+	env.MustRun("profile", "set", "chat", "child", "ApiKey", "")
+	env.MustRun("profile", "set", "chat", "child", "Headers", "null")
+
+	child := readResolved(t, env, "child")
+
+	if child["ApiKey"] != nil || child["Headers"] != nil {
+		t.Errorf("expected the child to clear the settings of its parent, got: %v", child["ApiKey"])
+	}
+
+	// Unsetting the settings of the child inherits them again
+	env.MustRun("profile", "unset", "chat", "child", "ApiKey")
+	child = readResolved(t, env, "child")
+
+	if child["ApiKey"] != "env:TEAM_KEY" {
+		t.Errorf("expected the api key of the parent once unset, got: %v", child["ApiKey"])
+	}
+}
@@ -0,0 +1,254 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/output"
+
+	"github.com/spf13/cobra"
+)
+
+var copyCmd = &cobra.Command{
+	Use:               "copy",
+	Short:             "Copies a profile under a new name",
+	RunE:              profileCopyCommandRun,
+	Example:           "go-gpt-cli profile copy chat default codereview",
+	Args:              cobra.ExactArgs(3),
+	Aliases:           []string{"cp"},
+	ValidArgsFunction: validEndpointAndProfileArgs,
+}
+
+var renameCmd = &cobra.Command{
+	Use:               "rename",
+	Short:             "Renames a profile, updating the default profile settings and the profiles extending it",
+	RunE:              profileRenameCommandRun,
+	Example:           "go-gpt-cli profile rename chat codereview review",
+	Args:              cobra.ExactArgs(3),
+	Aliases:           []string{"mv"},
+	ValidArgsFunction: validEndpointAndProfileArgs,
+}
+
+var diffCmd = &cobra.Command{
+	Use:               "diff",
+	Short:             "Shows the settings which differ between two profiles of an endpoint",
+	Long:              "Shows the settings which differ between two profiles of an endpoint, as resolved with the profiles they extend unless --raw is used. A version of a profile can be compared with name@version, see 'profile history'. Settings are shown as + added, - removed or ~ modified",
+	RunE:              profileDiffCommandRun,
+	Example:           "go-gpt-cli profile diff chat default codereview\ngo-gpt-cli profile diff chat codereview@3 codereview",
+	Args:              cobra.ExactArgs(3),
+	ValidArgsFunction: validEndpointAndProfileArgs,
+}
+
+var historyCmd = &cobra.Command{
+	Use:               "history",
+	Short:             "Lists the versions of a profile, a version is kept every time a profile is updated",
+	RunE:              profileHistoryCommandRun,
+	Example:           "go-gpt-cli profile history chat default",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: validEndpointAndProfileArgs,
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:               "rollback",
+	Short:             "Restores a version of a profile, which is saved as a new version",
+	RunE:              profileRollbackCommandRun,
+	Example:           "go-gpt-cli profile rollback chat default 3",
+	Args:              cobra.ExactArgs(3),
+	ValidArgsFunction: validEndpointAndProfileArgs,
+}
+
+var diffRaw bool
+
+func profileCopyCommandRun(cmd *cobra.Command, args []string) (err error) {
+	endpointName, err := checkEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
+
+	err = runtimeOf(cmd).Repository.Copy(endpointName, args[1], args[2])
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func profileRenameCommandRun(cmd *cobra.Command, args []string) (err error) {
+	endpointName, err := checkEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
+
+	err = runtimeOf(cmd).Repository.Rename(endpointName, args[1], args[2])
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func profileDiffCommandRun(cmd *cobra.Command, args []string) (err error) {
+	endpointName, err := checkEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
+
+	from, err := readForDiff(runtimeOf(cmd).Repository, endpointName, args[1])
+	if err != nil {
+		return err
+	}
+
+	to, err := readForDiff(runtimeOf(cmd).Repository, endpointName, args[2])
+	if err != nil {
+		return err
+	}
+
+	changes, err := profile.Diff(from, to)
+	if err != nil {
+		return err
+	}
+
+	var shown []profile.Change
+	var lines []string
+	for _, c := range changes {
+		// Profiles are expected to differ by their name
+		if c.Path == "ProfileName" {
+			continue
+		}
+
+		shown = append(shown, c)
+		lines = append(lines, c.String())
+	}
+
+	err = output.PrintLines(shown, lines)
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+// Reads a profile, or a version of it when the name is formatted as name@version.
+func readForDiff(repo profile.Repository, endpointName string, arg string) (buf []byte, err error) {
+	name, versionStr, isVersion := strings.Cut(arg, "@")
+	if !isVersion {
+		if diffRaw {
+			return repo.ReadRaw(name, endpointName)
+		}
+
+		return repo.Read(name, endpointName)
+	}
+
+	version, err := strconv.Atoi(versionStr)
+	if err != nil {
+		err = fmt.Errorf("invalid version %s of profile %s", versionStr, name)
+		return
+	}
+
+	buf, err = repo.ReadVersion(endpointName, name, version)
+	if err != nil || diffRaw {
+		return
+	}
+
+	read := func(n string) ([]byte, error) {
+		return repo.ReadRaw(n, endpointName)
+	}
+
+	return profile.ResolveRaw(read, name, buf)
+}
+
+func profileHistoryCommandRun(cmd *cobra.Command, args []string) (err error) {
+	endpointName, err := checkEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
+
+	versions, err := runtimeOf(cmd).Repository.History(endpointName, args[1])
+	if err != nil {
+		return err
+	}
+
+	err = output.Print(versions, func(out io.Writer) error {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tSAVED AT\tSIZE")
+		for i, v := range versions {
+			current := ""
+			if i == len(versions)-1 {
+				current = " (current)"
+			}
+
+			fmt.Fprintf(w, "%d%s\t%s\t%d\n", v.Version, current, v.Time.Format(time.DateTime), v.Size)
+		}
+
+		return w.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func profileRollbackCommandRun(cmd *cobra.Command, args []string) (err error) {
+	e, err := getEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
+	name := args[1]
+
+	version, err := strconv.Atoi(args[2])
+	if err != nil {
+		return fmt.Errorf("Invalid version %s, versions are listed by 'profile history'", args[2])
+	}
+
+	repo := runtimeOf(cmd).Repository
+	unlock, err := repo.Lock(e.Name(), name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	raw, err := repo.ReadVersion(e.Name(), name, version)
+	if err != nil {
+		return err
+	}
+
+	// The version may have been saved before the profile was renamed
+	var m map[string]any
+	err = json.Unmarshal(raw, &m)
+	if err == nil {
+		m["ProfileName"] = name
+		raw, err = json.Marshal(m)
+	}
+
+	var p profile.Profile
+	if err == nil {
+		p, err = validateProfile(repo, e, name, raw)
+	}
+
+	if err == nil {
+		err = repo.UpdateLocked(p)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffRaw, "raw", false, "Compare profiles as stored, without the settings of the profiles they extend")
+
+	ProfileCmd.AddCommand(copyCmd)
+	ProfileCmd.AddCommand(renameCmd)
+	ProfileCmd.AddCommand(diffCmd)
+	ProfileCmd.AddCommand(historyCmd)
+	ProfileCmd.AddCommand(rollbackCmd)
+}
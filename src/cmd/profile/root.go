@@ -1,14 +1,19 @@
 package profile
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/ephex2/go-gpt-cli/app"
 	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/format"
 	"github.com/ephex2/go-gpt-cli/config/profile"
 	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
 
 	"github.com/spf13/cobra"
 )
@@ -21,9 +26,9 @@ var ProfileCmd = &cobra.Command{
 var readCmd = &cobra.Command{
 	Use:               "read",
 	Short:             "Reads the contents of a profile out to the terminal",
-	Long:              "Reads the contents of a profile out to the terminal. When writing this to a file, it should be a valid configuration file to be used when performing update commands",
-	Run:               profileReadCommandRun,
-	Example:           "go-gpt-cli profile read endpointName profileName",
+	Long:              "Reads the contents of a profile out to the terminal. When writing this to a file, it should be a valid configuration file to be used when performing update commands. Profiles extending another one are shown as stored, only with the settings which differ from their parent, unless --resolved is used. Profiles are shown as json, or as yaml or toml with --format",
+	RunE:              profileReadCommandRun,
+	Example:           "go-gpt-cli profile read endpointName profileName\ngo-gpt-cli profile read chat codereview --resolved\ngo-gpt-cli profile read chat default --format yaml > default.yaml",
 	Args:              cobra.ExactArgs(2),
 	Aliases:           []string{"get"},
 	ValidArgsFunction: validEndpointAndProfileArgs,
@@ -32,8 +37,9 @@ var readCmd = &cobra.Command{
 var createCmd = &cobra.Command{
 	Use:               "create",
 	Short:             "For a given endpoint, create a new profile",
-	Run:               profileCreateCommandRun,
-	Example:           "go-gpt-cli profile create endpointName profileName",
+	Long:              "For a given endpoint, create a new profile from the default settings of the endpoint, or extending an existing profile with --extends. Profiles extending another one inherit every setting they do not set themselves, and only store the settings which differ from their parent. The profile is stored in the format of the ProfileFormat setting, json by default, or of --format",
+	RunE:              profileCreateCommandRun,
+	Example:           "go-gpt-cli profile create endpointName profileName\ngo-gpt-cli profile create chat codereview --extends base\ngo-gpt-cli profile create chat prompts --format yaml",
 	Args:              cobra.ExactArgs(2),
 	Aliases:           []string{"new"},
 	ValidArgsFunction: validEndpointArgs, // don't autosuggest existing profiles
@@ -42,8 +48,8 @@ var createCmd = &cobra.Command{
 var updateCmd = &cobra.Command{
 	Use:               "update",
 	Short:             "On a given endpoint, update a profile from a provided configuration file",
-	Long:              "On a given endpoint, update a profile from a provided configuration file. Note that given the way that profiles are configured the name of the profile should already be present from the config file itself",
-	Run:               profileUpdateCommandRun,
+	Long:              "On a given endpoint, update a profile from a provided configuration file. Note that given the way that profiles are configured the name of the profile should already be present from the config file itself. The file can be json, yaml or toml, depending on its extension",
+	RunE:              profileUpdateCommandRun,
 	Example:           "go-gpt-cli profile update endpointName configFilePath",
 	Args:              cobra.ExactArgs(2),
 	ValidArgsFunction: validEndpointArgs,
@@ -52,7 +58,7 @@ var updateCmd = &cobra.Command{
 var deleteCmd = &cobra.Command{
 	Use:               "delete",
 	Short:             "Within an endpoint, delete a specified profile",
-	Run:               profileDeleteCommandRun,
+	RunE:              profileDeleteCommandRun,
 	Example:           "go-gpt-cli profile delete endpointName profileName",
 	Args:              cobra.ExactArgs(2),
 	Aliases:           []string{"remove"},
@@ -62,7 +68,7 @@ var deleteCmd = &cobra.Command{
 var getAllCmd = &cobra.Command{
 	Use:               "getall",
 	Short:             "Get all profiles defined for an endpoint",
-	Run:               profileGetAllCommandRun,
+	RunE:              profileGetAllCommandRun,
 	Example:           "go-gpt-cli profile getall endpointName",
 	Args:              cobra.ExactArgs(1),
 	Aliases:           []string{"list"},
@@ -72,7 +78,7 @@ var getAllCmd = &cobra.Command{
 var endpointsCmd = &cobra.Command{
 	Use:               "endpoints",
 	Short:             "Get all endpoints that can use profiles",
-	Run:               endpointsCommandRun,
+	RunE:              endpointsCommandRun,
 	Example:           "go-gpt-cli profile endpoints",
 	Args:              cobra.ExactArgs(0),
 }
@@ -80,13 +86,23 @@ var endpointsCmd = &cobra.Command{
 var defaultCmd = &cobra.Command{
 	Use:               "default",
 	Short:             "Sets the default profile for an endpoint",
-	Run:               profileDefaultCommandRun,
+	RunE:              profileDefaultCommandRun,
 	Example:           "go-gpt-cli profile default endpointName profileName",
 	Args:              cobra.ExactArgs(2),
 	ValidArgsFunction: validEndpointAndProfileArgs,
 }
 
+var readResolved bool
+var readFormat string
+var createExtends string
+var createFormat string
+
 func init() {
+	readCmd.Flags().BoolVar(&readResolved, "resolved", false, "Show the profile merged with the profiles it extends")
+	readCmd.Flags().StringVar(&readFormat, "format", format.Json, "Format the profile is shown in, one of: "+strings.Join(format.Formats, ", "))
+	createCmd.Flags().StringVar(&createExtends, "extends", "", "Name of a profile of the same endpoint to inherit settings from")
+	createCmd.Flags().StringVar(&createFormat, "format", "", "Format of the profile file, one of: "+strings.Join(format.Formats, ", ")+". Defaults to the ProfileFormat setting")
+
 	ProfileCmd.AddCommand(readCmd)
 	ProfileCmd.AddCommand(createCmd)
 	ProfileCmd.AddCommand(updateCmd)
@@ -109,141 +125,268 @@ func Execute(cmd *cobra.Command, args []string) (err error) {
 	return
 }
 
-func profileCreateCommandRun(cmd *cobra.Command, args []string) {
-	e := getEndpoint(args[0])
+func profileCreateCommandRun(cmd *cobra.Command, args []string) (err error) {
+	e, err := getEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
+
+	if createFormat != "" {
+		err = config.SetProfileFormatOverride(createFormat, "format")
+		if err != nil {
+			return err
+		}
+	}
+
+	if createExtends != "" {
+		err = createExtendingProfile(runtimeOf(cmd).Repository, e, args[1], createExtends)
+	} else {
+		err = runtimeOf(cmd).Repository.Create(e, args[1])
+	}
 
-	err := profile.RuntimeRepository.Create(e, args[1])
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
+
+	return
 }
 
-func profileReadCommandRun(cmd *cobra.Command, args []string) {
-	endpoint := getEndpoint(args[0])
+// Creates a profile which inherits every setting of its parent, it is stored with its name and parent only.
+func createExtendingProfile(repo profile.Repository, e profile.Endpoint, profileName string, parentName string) (err error) {
+	unlock, err := repo.Lock(e.Name(), profileName)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	buf, err := repo.Read(parentName, e.Name())
+	if err != nil {
+		err = errors.New("unable to read parent profile " + parentName + ": " + err.Error())
+		return
+	}
+
+	var settings map[string]any
+	err = json.Unmarshal(buf, &settings)
+	if err != nil {
+		return
+	}
+
+	settings["ProfileName"] = profileName
+	settings["Extends"] = parentName
+
+	buf, err = json.Marshal(settings)
+	if err != nil {
+		return
+	}
+
+	p, err := e.ProfileFromJsonBuf(buf)
+	if err != nil {
+		return
+	}
+
+	err = repo.UpdateLocked(p)
+	if err != nil {
+		return
+	}
+
+	err = config.SetDefaultProfile(e.Name(), profileName, false)
+	return
+}
+
+func profileReadCommandRun(cmd *cobra.Command, args []string) (err error) {
+	endpoint, err := getEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
 	profileName := args[1]
 
-	dummyP := endpoint.DefaultProfile() //.SetName(profileName)
-	repo := dummyP.ProfileRepository()
+	err = format.Check(readFormat)
+	if err != nil {
+		return err
+	}
+
+	repo := runtimeOf(cmd).Repository
+
+	if !readResolved {
+		raw, err := repo.ReadRaw(profileName, endpoint.Name())
+		if err != nil {
+			return fmt.Errorf("Error while trying to read profile: %s", err.Error())
+		}
+
+		if profile.Parent(raw) != "" {
+			var out bytes.Buffer
+			err = json.Indent(&out, raw, "", "    ")
+			if err != nil {
+				return fmt.Errorf("Error while trying to format profile: %s", err.Error())
+			}
+
+			return printProfile(out.Bytes())
+		}
+	}
 
 	p, err := repo.Read(profileName, endpoint.Name())
 	if err != nil {
-		log.Critical("Error while trying to read profile: %s\n", err.Error())
-		os.Exit(1)
+		return fmt.Errorf("Error while trying to read profile: %s", err.Error())
 	}
 
 	formattedProfile, err := endpoint.ProfileFromJsonBuf(p)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
 
 	formattedProfileBuf, err := json.MarshalIndent(formattedProfile, "", "    ")
 	if err != nil {
-		log.Critical("Error while trying to format profile: %s", err.Error())
-		os.Exit(1)
+		return fmt.Errorf("Error while trying to format profile: %s", err.Error())
 	}
 
-	fmt.Println(string(formattedProfileBuf))
+	return printProfile(formattedProfileBuf)
 }
 
-func profileUpdateCommandRun(cmd *cobra.Command, args []string) {
-	e := getEndpoint(args[0])
+// Prints indented json in the format of --format, or in the output format when one other than text is selected.
+func printProfile(buf []byte) (err error) {
+	if output.Format() != output.FormatText {
+		return output.Print(json.RawMessage(buf), nil)
+	}
+
+	buf, err = format.FromJson(readFormat, buf, nil)
+	if err != nil {
+		return fmt.Errorf("Error while trying to format profile: %s", err.Error())
+	}
+
+	_, err = fmt.Fprintln(output.Writer, strings.TrimSuffix(string(buf), "\n"))
+	return
+}
+
+func profileUpdateCommandRun(cmd *cobra.Command, args []string) (err error) {
+	e, err := getEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
 
 	newProfilePath := args[1]
 	newProfileBytes, err := os.ReadFile(newProfilePath)
 	if err != nil {
-		log.Critical("Error while trying to read from the new profile path provided: %s\nError: %s\n", newProfilePath, err.Error())
-		os.Exit(1)
+		return fmt.Errorf("Error while trying to read from the new profile path provided: %s\nError: %s", newProfilePath, err.Error())
 	}
 
-	p, err := e.ProfileFromJsonBuf(newProfileBytes)
+	newProfileBytes, err = format.ToJson(format.FromPath(newProfilePath), newProfileBytes)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return fmt.Errorf("Error while trying to parse the new profile: %s", err.Error())
+	}
+
+	var stored struct{ ProfileName string }
+	err = json.Unmarshal(newProfileBytes, &stored)
+	if err != nil {
+		return fmt.Errorf("Error while trying to parse the new profile: %s", err.Error())
 	}
 
-	repo := p.ProfileRepository()
-	err = repo.Update(p)
+	if stored.ProfileName == "" {
+		return errors.New("The new profile has no ProfileName, which names the profile to update")
+	}
+
+	repo := runtimeOf(cmd).Repository
+	unlock, err := repo.Lock(e.Name(), stored.ProfileName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	p, err := validateProfile(repo, e, stored.ProfileName, newProfileBytes)
+	if err != nil {
+		return err
+	}
+
+	err = repo.UpdateLocked(p)
 	if err != nil {
-		log.Critical("Error while updating profile config file: %s\n", err.Error())
-		os.Exit(1)
+		return fmt.Errorf("Error while updating profile config file: %s", err.Error())
 	}
+
+	return
 }
 
-func profileDeleteCommandRun(cmd *cobra.Command, args []string) {
-	endpointName := checkEndpoint(args[0])
+func profileDeleteCommandRun(cmd *cobra.Command, args []string) (err error) {
+	endpointName, err := checkEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
 	profileName := args[1]
 
-	err := profile.RuntimeRepository.Delete(endpointName, profileName)
+	err = runtimeOf(cmd).Repository.Delete(endpointName, profileName)
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
+		return err
 	}
+
+	return
 }
 
-func profileGetAllCommandRun(cmd *cobra.Command, args []string) {
-	endpointName := checkEndpoint(args[0])
+func profileGetAllCommandRun(cmd *cobra.Command, args []string) (err error) {
+	endpointName, err := checkEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
 
-	names, err := profile.RuntimeRepository.GetAll(endpointName)
+	names, err := runtimeOf(cmd).Repository.GetAll(endpointName)
 	if err != nil {
 		log.Debug(err.Error() + "\n")
-		return
+		return nil
 	}
 
-	for _, name := range names {
-		fmt.Println(name)
+	err = output.PrintLines(names, names)
+	if err != nil {
+		return err
 	}
+
+	return
 }
 
-func endpointsCommandRun(cmd *cobra.Command, args []string) {
-	names, err := profile.EndpointRegistry.List()
+func endpointsCommandRun(cmd *cobra.Command, args []string) (err error) {
+	names, err := runtimeOf(cmd).Registry.List()
 	if err != nil {
-		log.Critical(err.Error() + "\n")
-		return
+		return err
 	}
 
-	for _, name := range names {
-		fmt.Println(name)
+	err = output.PrintLines(names, names)
+	if err != nil {
+		return err
 	}
+
+	return
 }
 
-func profileDefaultCommandRun(cmd *cobra.Command, args []string) {
-	endpointName := checkEndpoint(args[0])
+func profileDefaultCommandRun(cmd *cobra.Command, args []string) (err error) {
+	endpointName, err := checkEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
 
 	profileName := args[1]
-	err := config.SetDefaultProfile(endpointName, profileName, true)
+	err = config.SetDefaultProfile(endpointName, profileName, true)
 	if err != nil {
 		log.Critical(err.Error() + "\n")
 	}
+
+	return
 }
 
 // utility
-func checkEndpoint(name string) string {
-	endpointName := strings.ToLower(name)
-	_, err := profile.EndpointRegistry.Get(endpointName)
-	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
-	}
-
-	return endpointName
+// Returns the runtime the command runs against.
+func runtimeOf(cmd *cobra.Command) *app.Runtime {
+	return app.FromContext(cmd.Context())
 }
 
-func getEndpoint(name string) profile.Endpoint {
-	endpointName := strings.ToLower(name)
-	e, err := profile.EndpointRegistry.Get(endpointName)
-	if err != nil {
-		log.Critical(err.Error() + "\n")
-		os.Exit(1)
-	}
+func checkEndpoint(cmd *cobra.Command, name string) (endpointName string, err error) {
+	endpointName = strings.ToLower(name)
+	_, err = runtimeOf(cmd).Registry.Get(endpointName)
+	return
+}
 
-	return e
+func getEndpoint(cmd *cobra.Command, name string) (profile.Endpoint, error) {
+	return runtimeOf(cmd).Registry.Get(strings.ToLower(name))
 }
 
 func validEndpointArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	if len(args) == 0 {
-		endpoints, err := profile.EndpointRegistry.List()
+		endpoints, err := runtimeOf(cmd).Registry.List()
 		if err != nil {
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		}
@@ -257,14 +400,14 @@ func validEndpointArgs(cmd *cobra.Command, args []string, toComplete string) ([]
 
 func validEndpointAndProfileArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	if len(args) == 0 {
-		endpoints, err := profile.EndpointRegistry.List()
+		endpoints, err := runtimeOf(cmd).Registry.List()
 		if err != nil {
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		}
 
 		return endpoints, cobra.ShellCompDirectiveNoFileComp
 	} else if len(args) == 1 {
-		names, err := profile.RuntimeRepository.GetAll(args[0])
+		names, err := runtimeOf(cmd).Repository.GetAll(args[0])
 		if err != nil {
 			log.Debug(err.Error() + "\n")
 			return nil, cobra.ShellCompDirectiveNoFileComp
@@ -0,0 +1,112 @@
+package profile
+
+import (
+	"fmt"
+
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
+
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:               "validate",
+	Short:             "Validates profiles against the schema of their endpoint and the values it allows",
+	Long:              "Validates profiles against the schema of their endpoint, reporting unknown settings and wrong types, then checks the values the endpoint allows, such as voices, image sizes or completion windows. Validates every profile of every endpoint without arguments, every profile of an endpoint with one argument",
+	RunE:              profileValidateCommandRun,
+	Example:           "go-gpt-cli profile validate\ngo-gpt-cli profile validate chat\ngo-gpt-cli profile validate chat default",
+	Args:              cobra.MaximumNArgs(2),
+	ValidArgsFunction: validEndpointAndProfileArgs,
+}
+
+var schemaCmd = &cobra.Command{
+	Use:               "schema",
+	Short:             "Prints the json schema of the profiles of an endpoint",
+	RunE:              profileSchemaCommandRun,
+	Example:           "go-gpt-cli profile schema chat",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: validEndpointArgs,
+}
+
+func profileValidateCommandRun(cmd *cobra.Command, args []string) (err error) {
+	var endpoints []profile.Endpoint
+	if len(args) > 0 {
+		var e profile.Endpoint
+		e, err = getEndpoint(cmd, args[0])
+		if err != nil {
+			return
+		}
+
+		endpoints = append(endpoints, e)
+	} else {
+		endpoints = runtimeOf(cmd).Registry.Endpoints
+	}
+
+	invalid := 0
+	var results []validationResult
+	var lines []string
+	for _, e := range endpoints {
+		var names []string
+		if len(args) == 2 {
+			names = args[1:]
+		} else {
+			names, _ = runtimeOf(cmd).Repository.GetAll(e.Name())
+		}
+
+		for _, name := range names {
+			raw, err := runtimeOf(cmd).Repository.ReadRaw(name, e.Name())
+			if err == nil {
+				_, err = validateProfile(runtimeOf(cmd).Repository, e, name, raw)
+			}
+
+			result := validationResult{Endpoint: e.Name(), Name: name, Valid: err == nil}
+			if err != nil {
+				invalid++
+				result.Error = err.Error()
+				log.Critical("%s/%s: %s\n", e.Name(), name, err.Error())
+			} else {
+				lines = append(lines, fmt.Sprintf("%s/%s: valid", e.Name(), name))
+			}
+
+			results = append(results, result)
+		}
+	}
+
+	err = output.PrintLines(results, lines)
+	if err != nil {
+		return err
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d invalid profiles", invalid)
+	}
+
+	return
+}
+
+type validationResult struct {
+	Endpoint string
+	Name     string
+	Valid    bool
+	Error    string `json:",omitempty"`
+}
+
+func profileSchemaCommandRun(cmd *cobra.Command, args []string) (err error) {
+	e, err := getEndpoint(cmd, args[0])
+	if err != nil {
+		return
+	}
+
+	err = output.Print(profile.Schema(e), nil)
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func init() {
+	ProfileCmd.AddCommand(validateCmd)
+	ProfileCmd.AddCommand(schemaCmd)
+}
@@ -1,54 +1,229 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"os"
+	"strings"
 
+	"github.com/ephex2/go-gpt-cli/api"
+	"github.com/ephex2/go-gpt-cli/app"
 	"github.com/ephex2/go-gpt-cli/cmd/audio"
 	"github.com/ephex2/go-gpt-cli/cmd/batches"
+	"github.com/ephex2/go-gpt-cli/cmd/cache"
 	"github.com/ephex2/go-gpt-cli/cmd/chat"
 	"github.com/ephex2/go-gpt-cli/cmd/config"
 	"github.com/ephex2/go-gpt-cli/cmd/embeddings"
 	"github.com/ephex2/go-gpt-cli/cmd/file"
 	"github.com/ephex2/go-gpt-cli/cmd/finetuning"
 	"github.com/ephex2/go-gpt-cli/cmd/image"
+	"github.com/ephex2/go-gpt-cli/cmd/mock"
 	"github.com/ephex2/go-gpt-cli/cmd/model"
 	"github.com/ephex2/go-gpt-cli/cmd/profile"
-	"github.com/ephex2/go-gpt-cli/config/repository"
+	"github.com/ephex2/go-gpt-cli/cmd/serve"
+	"github.com/ephex2/go-gpt-cli/cmd/storage"
+	"github.com/ephex2/go-gpt-cli/cmd/usage"
+	globalconfig "github.com/ephex2/go-gpt-cli/config"
 	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var debugMode bool
+var logLevel string
+var logFormat string
+var logFile string
+var cacheResponses bool
+var contextName string
+var baseUrl string
+var cassettePath string
+var cassetteMode string
+var trace bool
+var traceFile string
+var dryRun string
+var showSecrets bool
 
 var rootCmd = &cobra.Command{
-	Use:   "go-gpt-cli",
-	Short: "A CLI tool for interacting with the open AI API",
+	Use:               "go-gpt-cli",
+	Short:             "A CLI tool for interacting with the open AI API",
+	PersistentPreRunE: setup,
+	SilenceErrors:     true, // Errors are logged by main, on stderr
 }
 
+// Loads the settings and profiles from disk, then runs the command line.
 func Execute() error {
+	rt, err := app.Load()
+	if err != nil {
+		log.Critical("Unable to load the settings and profiles: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	return ExecuteWith(rt, os.Args[1:])
+}
+
+// Runs a command line against a runtime, such as one built with app.NewMemory which touches no file. Commands read the
+// runtime from their context, see app.FromContext. Command lines may be run one after the other, the flags of a
+// command line do not apply to the next one.
+func ExecuteWith(rt *app.Runtime, args []string) error {
+	rt.Install()
+	resetFlags(rootCmd)
+	globalconfig.ResetFlagSettings()
+	rootCmd.SetArgs(args)
+
+	ctx := app.NewContext(context.Background(), rt)
+	setContext(rootCmd, ctx)
+	err := rootCmd.ExecuteContext(ctx)
+	if errors.Is(err, api.ErrDryRun) {
+		return nil
+	}
+
+	return err
+}
+
+// Commands keep the context of their first execution, they must be given the one of the runtime they run against next.
+func setContext(cmd *cobra.Command, ctx context.Context) {
+	cmd.SetContext(ctx)
+	for _, c := range cmd.Commands() {
+		setContext(c, ctx)
+	}
+}
+
+// Sets the flags of a command and of its subcommands back to their default value.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			s.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+
+		f.Changed = false
+	}
+
+	cmd.PersistentFlags().VisitAll(reset)
+	cmd.LocalNonPersistentFlags().VisitAll(reset)
+	for _, c := range cmd.Commands() {
+		resetFlags(c)
+	}
+}
+
+// Applies the global flags, once cobra has parsed the flags of the whole command line: global flags may follow the
+// local flags of a command.
+func setup(cmd *cobra.Command, args []string) (err error) {
+	// Arguments are valid once here, errors of the command itself do not call for its usage
+	cmd.SilenceUsage = true
+
+	err = setOutputFormat(cmd, args)
+	if err != nil {
+		return
+	}
+
+	err = setLogging()
+	if err != nil {
+		return
+	}
+
+	if contextName != "" {
+		err = globalconfig.SetContextOverride(contextName)
+		if err != nil {
+			return
+		}
+	}
+
+	if baseUrl != "" {
+		err = globalconfig.SetBaseUrlOverride(baseUrl)
+		if err != nil {
+			return
+		}
+	}
+
+	api.SetCacheEnabled(cacheResponses)
+
+	err = api.SetCassette(cassetteMode, cassettePath)
+	if err != nil {
+		return
+	}
+
+	err = api.SetTrace(trace, traceFile)
+	if err != nil {
+		return
+	}
+
+	return api.SetDryRun(dryRun, showSecrets)
+}
+
+// --debug takes precedence over --log-level.
+func setLogging() (err error) {
+	level := log.LevelInfo
+	if logLevel != "" {
+		level, err = log.ParseLevel(logLevel)
+		if err != nil {
+			return
+		}
+	}
+
+	if debugMode {
+		level = log.LevelDebug
+	}
+
+	err = log.SetLogLevel(level)
+	if err != nil {
+		return
+	}
+
+	if logFormat != "" {
+		err = log.SetFormat(logFormat)
+		if err != nil {
+			return
+		}
+	}
+
+	return log.SetFile(logFile)
+}
+
+// Commands may define their own output flag, such as profile export whose output is a file, in which case the format
+// comes from GO_GPT_CLI_OUTPUT.
+func setOutputFormat(cmd *cobra.Command, args []string) error {
+	spec := os.Getenv(output.OutputEnv)
+	if f := cmd.Flags().Lookup("output"); f != nil && f.Changed {
+		spec = f.Value.String()
+	}
+
+	return output.SetFormat(spec)
+}
+
+func init() {
 	rootCmd.AddCommand(audio.AudioCmd)
 	rootCmd.AddCommand(batches.BatchesCmd)
+	rootCmd.AddCommand(cache.CacheCmd)
 	rootCmd.AddCommand(chat.ChatCmd)
 	rootCmd.AddCommand(config.ConfigCmd)
 	rootCmd.AddCommand(embeddings.EmbeddingsCmd)
 	rootCmd.AddCommand(file.FileCmd)
 	rootCmd.AddCommand(finetuning.FineTuningCmd)
 	rootCmd.AddCommand(image.ImageCmd)
+	rootCmd.AddCommand(mock.MockCmd)
 	rootCmd.AddCommand(model.ModelCmd)
 	rootCmd.AddCommand(profile.ProfileCmd)
+	rootCmd.AddCommand(serve.ServeCmd)
+	rootCmd.AddCommand(storage.StorageCmd)
+	rootCmd.AddCommand(usage.UsageCmd)
 
-    rootCmd.PersistentFlags().BoolVarP(&debugMode, "debug", "d", false, "Enable debug logging")
-    err := rootCmd.ParseFlags(os.Args)
-
-    if debugMode {
-        log.SetLogLevel(log.LevelDebug)
-    }
-
-	err = rootCmd.Execute()
-	return err
-}
-
-func init() {
-	repository.Init()
+	rootCmd.PersistentFlags().BoolVarP(&debugMode, "debug", "d", false, "Enable debug logging")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", os.Getenv(log.LevelEnv), "Level of the logs, one of: debug, info, warning, critical. Defaults to $"+log.LevelEnv+", then info")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", os.Getenv(log.FormatEnv), "Format of the logs, either text or json. Defaults to $"+log.FormatEnv+", then text")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", os.Getenv(log.FileEnv), "File the logs are written to instead of stderr, rotated every 10MB. Defaults to $"+log.FileEnv)
+	rootCmd.PersistentFlags().StringVar(&contextName, "context", "", "Context used for this command instead of the current one. Can also be set with $GO_GPT_CLI_CONTEXT")
+	rootCmd.PersistentFlags().StringVar(&baseUrl, "base-url", "", "Base url used for this command, overriding every other setting")
+	rootCmd.PersistentFlags().BoolVar(&cacheResponses, "cache", false, "Cache the responses of deterministic requests, even when the profile's Cache option is false")
+	rootCmd.PersistentFlags().StringVar(&cassettePath, "cassette", os.Getenv("GO_GPT_CLI_CASSETTE"), "Path of a cassette file used to record or replay API interactions. Defaults to $GO_GPT_CLI_CASSETTE")
+	rootCmd.PersistentFlags().StringVar(&cassetteMode, "cassette-mode", os.Getenv("GO_GPT_CLI_CASSETTE_MODE"), "Either 'record' or 'replay'. Defaults to $GO_GPT_CLI_CASSETTE_MODE")
+	rootCmd.PersistentFlags().BoolVar(&trace, "trace", false, "Log the dns, connection, tls, time to first byte and transfer timings of every request, along with its rate limit headers")
+	rootCmd.PersistentFlags().StringVar(&traceFile, "trace-file", os.Getenv("GO_GPT_CLI_TRACE_FILE"), "Path of a file to which the spans of every request are exported, in the OpenTelemetry json format. Defaults to $GO_GPT_CLI_TRACE_FILE")
+	rootCmd.PersistentFlags().StringVar(&dryRun, "dry-run", "", "Print the request of the command instead of sending it, either as a curl command ( default ) or as the raw http request with --dry-run=http")
+	rootCmd.PersistentFlags().Lookup("dry-run").NoOptDefVal = api.DryRunCurl
+	rootCmd.PersistentFlags().BoolVar(&showSecrets, "show-secrets", false, "Print the api key and other secrets, of settings and dry run requests, instead of masking them")
+	rootCmd.PersistentFlags().String("output", "", "Output format, one of: "+strings.Join(output.Formats, ", ")+". Defaults to $GO_GPT_CLI_OUTPUT, then text")
 }
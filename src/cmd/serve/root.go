@@ -0,0 +1,54 @@
+package serve
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/serve"
+	"github.com/spf13/cobra"
+)
+
+var ServeCmd = &cobra.Command{
+	Use:     "serve",
+	Short:   "Starts a local OpenAI compatible server which forwards requests using your profiles.",
+	Long:    "Starts a local OpenAI compatible server which forwards requests to the configured API. The api key is injected into forwarded requests, and the defaults of a profile (system prompt, model, temperature...) are applied to every request. Local tools authenticate with the --token, a random one is generated and printed when none is given, and requests must be addressed to localhost. Tools select a profile with the " + serve.ProfileHeader + " header or by using http://<addr>/profiles/<profile>/v1 as their base url, the endpoint's default profile is used otherwise.",
+	RunE:    serveFunc,
+	Args:    cobra.ExactArgs(0),
+	Example: "go-gpt-cli serve --addr 127.0.0.1:8081 --profile coding --token local-secret",
+}
+
+var addr string
+var profileName string
+var token string
+
+func serveFunc(cmd *cobra.Command, args []string) (err error) {
+	if token == "" {
+		token, err = serve.NewToken()
+		if err != nil {
+			return
+		}
+
+		// Printed rather than logged so that the token never ends up in the log file
+		fmt.Fprintf(cmd.ErrOrStderr(), "No token given, local tools must use this one as their api key: %s\n", token)
+	}
+
+	options := serve.Options{
+		Profile: profileName,
+		Token:   token,
+	}
+
+	log.Info("Serving the OpenAI API on http://%s/v1\n", addr)
+	err = http.ListenAndServe(addr, serve.NewServer(options))
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+func init() {
+	ServeCmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8081", "Address the server listens on")
+	ServeCmd.Flags().StringVar(&profileName, "profile", "", "Name of the profile applied to requests which do not select one, the endpoint's default profile is used when empty")
+	ServeCmd.Flags().StringVar(&token, "token", "", "Token local tools must authenticate with instead of an api key, a random one is generated when empty")
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/ephex2/go-gpt-cli/config/repository"
+	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
+	"github.com/spf13/cobra"
+)
+
+var StorageCmd = &cobra.Command{
+	Use:   "storage",
+	Short: "Allows you to choose where settings, profiles and usage records are stored",
+	Long:  "Settings, profiles with their history and usage records are stored in files by default, or in a sqlite database of the data directory. The storage is selected with 'storage migrate', or with $" + repository.StorageEnv + ".",
+}
+
+var statusCmd = &cobra.Command{
+	Use:     "status",
+	Short:   "Shows the storage in use and where its data is.",
+	RunE:    statusFunc,
+	Args:    cobra.ExactArgs(0),
+	Example: "go-gpt-cli storage status",
+}
+
+var migrateCmd = &cobra.Command{
+	Use:     "migrate",
+	Short:   "Moves settings, profiles and usage records to another storage, one of: " + strings.Join(repository.Storages, ", ") + ", and uses it from then on.",
+	Long:    "Moves settings, profiles with their history and usage records to another storage, one of: " + strings.Join(repository.Storages, ", ") + ", and uses it from then on. The data is removed from the storage it is moved from once it has been read back from the other storage, a backup of it is kept in the data directory. A storage which already holds profiles or usage records is only replaced with --force.",
+	RunE:    migrateFunc,
+	Args:    cobra.ExactArgs(0),
+	Example: "go-gpt-cli storage migrate --to sqlite",
+}
+
+var to string
+var force bool
+
+func Execute(cmd *cobra.Command, args []string) (err error) {
+	if len(args) < 1 {
+		cmd.Help()
+	}
+
+	err = cmd.Execute()
+	return
+}
+
+func statusFunc(cmd *cobra.Command, args []string) (err error) {
+	storage, location := repository.Storage()
+	status := storageStatus{Storage: storage, Location: location}
+	err = output.PrintLines(status, []string{"storage:  " + storage, "location: " + location})
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+type storageStatus struct {
+	Storage  string
+	Location string
+}
+
+func migrateFunc(cmd *cobra.Command, args []string) (err error) {
+	backup, err := repository.Migrate(to, force)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Moved settings, profiles and usage records to the %s storage, a backup of them is kept in %s\n", to, backup)
+
+	return
+}
+
+func init() {
+	migrateCmd.Flags().StringVar(&to, "to", "", "Storage the data is moved to, one of: "+strings.Join(repository.Storages, ", "))
+	migrateCmd.Flags().BoolVar(&force, "force", false, "Replace the profiles and usage records the storage already holds")
+	migrateCmd.MarkFlagRequired("to")
+
+	StorageCmd.AddCommand(statusCmd)
+	StorageCmd.AddCommand(migrateCmd)
+}
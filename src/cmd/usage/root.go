@@ -0,0 +1,224 @@
+package usage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/output"
+	"github.com/ephex2/go-gpt-cli/usage"
+	"github.com/spf13/cobra"
+)
+
+var UsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Allows you to report on the usage and cost of the calls made to the API",
+	Long:  "Every billable call (chat, embeddings, audio, images) is recorded in a local usage ledger, along with its cost computed from a price table.",
+}
+
+var reportCmd = &cobra.Command{
+	Use:     "report",
+	Short:   "Reports usage and cost, grouped by day, month, profile, model or endpoint.",
+	RunE:    reportFunc,
+	Args:    cobra.ExactArgs(0),
+	Example: "go-gpt-cli usage report --group-by model --since 2024-05-01 --csv > usage.csv",
+}
+
+var budgetCmd = &cobra.Command{
+	Use:     "budget",
+	Short:   "Shows the monthly budget in USD and the cost of the current month, or sets the budget when provided. A budget of 0 removes it.",
+	Long:    "Shows the monthly budget in USD and the cost of the current month, or sets the budget when provided. The budget is stored in the active context, like other settings. A budget of 0 removes it. Calls warn when 80% of the budget is spent.",
+	RunE:    budgetFunc,
+	Args:    cobra.MaximumNArgs(1),
+	Example: "go-gpt-cli usage budget 50",
+}
+
+var pricesCmd = &cobra.Command{
+	Use:     "prices",
+	Short:   "Lists the price table used to compute costs. Prices can be overridden in the price file shown.",
+	RunE:    pricesFunc,
+	Args:    cobra.ExactArgs(0),
+	Example: "go-gpt-cli usage prices",
+}
+
+var groupBy string
+var since string
+var until string
+var csvOutput bool
+
+func Execute(cmd *cobra.Command, args []string) (err error) {
+	if len(args) < 1 {
+		cmd.Help()
+	}
+
+	err = cmd.Execute()
+	return
+}
+
+func reportFunc(cmd *cobra.Command, args []string) (err error) {
+	sinceTime, err := parseDate(since)
+	if err != nil {
+		return err
+	}
+
+	untilTime, err := parseDate(until)
+	if err != nil {
+		return err
+	}
+
+	// Until is inclusive, records of the whole day are reported
+	if !untilTime.IsZero() {
+		untilTime = untilTime.AddDate(0, 0, 1)
+	}
+
+	records, err := usage.Read(sinceTime, untilTime)
+	if err != nil {
+		return err
+	}
+
+	rows, total, err := usage.Report(records, groupBy)
+	if err != nil {
+		return err
+	}
+
+	if csvOutput {
+		err = writeCsv(rows)
+	} else {
+		rows = append(rows, total)
+		err = output.Print(rows, func(w io.Writer) error {
+			return writeTable(w, rows)
+		})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+var header = []string{"group", "requests", "prompt_tokens", "completion_tokens", "audio_seconds", "characters", "images", "cost_usd"}
+
+func rowValues(row usage.Row) []string {
+	return []string{
+		row.Group,
+		strconv.Itoa(row.Requests),
+		strconv.Itoa(row.PromptTokens),
+		strconv.Itoa(row.CompletionTokens),
+		strconv.FormatFloat(row.AudioSeconds, 'f', 1, 64),
+		strconv.Itoa(row.Characters),
+		strconv.Itoa(row.Images),
+		strconv.FormatFloat(row.Cost, 'f', 4, 64),
+	}
+}
+
+func writeCsv(rows []usage.Row) (err error) {
+	w := csv.NewWriter(output.Writer)
+	err = w.Write(header)
+	if err != nil {
+		return
+	}
+
+	for _, row := range rows {
+		err = w.Write(rowValues(row))
+		if err != nil {
+			return
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func writeTable(out io.Writer, rows []usage.Row) (err error) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, strings.Join(header, "\t")+"\t")
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(rowValues(row), "\t")+"\t")
+	}
+
+	return w.Flush()
+}
+
+func parseDate(s string) (t time.Time, err error) {
+	if s == "" {
+		return
+	}
+
+	t, err = time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		err = fmt.Errorf("invalid date %s, dates must be formatted as YYYY-MM-DD", s)
+	}
+
+	return
+}
+
+func budgetFunc(cmd *cobra.Command, args []string) (err error) {
+	if len(args) == 1 {
+		budget, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return fmt.Errorf("Invalid budget: %s", args[0])
+		}
+
+		err = usage.SetMonthlyBudget(budget)
+		if err != nil {
+			return err
+		}
+	}
+
+	cost, err := usage.MonthCost(time.Now())
+	if err != nil {
+		return err
+	}
+
+	budget := usage.MonthlyBudget()
+	line := fmt.Sprintf("$%.2f spent this month of a $%.2f budget", cost, budget)
+	if budget == 0 {
+		line = fmt.Sprintf("No monthly budget set, $%.2f spent this month", cost)
+	}
+
+	err = output.PrintLine(budgetStatus{Budget: budget, Spent: cost}, line)
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+// Amounts are in USD, the budget is 0 when none is set.
+type budgetStatus struct {
+	Budget float64
+	Spent  float64
+}
+
+func pricesFunc(cmd *cobra.Command, args []string) (err error) {
+	prices, err := usage.Prices()
+	if err != nil {
+		return err
+	}
+
+	err = output.Print(prices, nil)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Price file: %s\n", usage.PricesPath())
+
+	return
+}
+
+func init() {
+	reportCmd.Flags().StringVar(&groupBy, "group-by", usage.GroupByDay, "One of: "+strings.Join(usage.GroupByValues, ", "))
+	reportCmd.Flags().StringVar(&since, "since", "", "Only report calls made on or after this day, ex: 2024-05-01")
+	reportCmd.Flags().StringVar(&until, "until", "", "Only report calls made on or before this day, ex: 2024-05-31")
+	reportCmd.Flags().BoolVar(&csvOutput, "csv", false, "Export the report as csv")
+
+	UsageCmd.AddCommand(reportCmd)
+	UsageCmd.AddCommand(budgetCmd)
+	UsageCmd.AddCommand(pricesCmd)
+}
@@ -64,7 +64,7 @@ func ColorPrintf(color []byte, format string, a ...any) {
 			panic(err.Error())
 		}
 	} else {
-		_, err := fmt.Printf(string(formatBytes), a)
+		_, err := fmt.Printf(string(formatBytes), a...)
 		if err != nil {
 			panic(err.Error())
 		}
@@ -83,7 +83,7 @@ func ColorSprintf(color []byte, format string, a ...any) (s string) {
 	if a == nil {
 		s = fmt.Sprint(string(formatBytes))
 	} else {
-		s = fmt.Sprintf(string(formatBytes), a)
+		s = fmt.Sprintf(string(formatBytes), a...)
 	}
 
 	return s
@@ -0,0 +1,100 @@
+package config
+
+// Settings selecting how requests are routed and authenticated, for APIs compatible with OpenAI's which expect other
+// routes or headers, such as Azure OpenAI. The adapters themselves are implemented by the api package.
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ephex2/go-gpt-cli/config/profile"
+)
+
+const adapterKeyName string = "Adapter"
+const authSchemeKeyName string = "AuthScheme"
+const authHeaderKeyName string = "AuthHeader"
+const apiVersionKeyName string = "ApiVersion"
+const deploymentKeyName string = "Deployment"
+
+const defaultAdapter string = "openai"
+
+type AdapterSettings struct {
+	Adapter    string `json:",omitempty"` // Routing of requests: openai, azure
+	AuthScheme string `json:",omitempty"` // Authentication of requests: bearer, api-key, header, none. Defaults to the adapter's scheme
+	AuthHeader string `json:",omitempty"` // Header holding the api key with the header scheme
+	ApiVersion string `json:",omitempty"` // api-version query parameter sent to Azure
+	Deployment string `json:",omitempty"` // Azure deployment, the model of the request is used when empty
+}
+
+// Whether the requests made with a profile are sent to the OpenAI API: the profile's url and adapter, or the settings
+// when it overrides neither, select the OpenAI adapter and host. Endpoints only check models against OpenAI's for such
+// requests, other vendors, proxies and Azure deployments have models of their own.
+func TargetsOpenAI(p profile.Profile) bool {
+	baseUrl := BaseUrl()
+	adapter := Adapter().Adapter
+	if p != nil && p.OverrideUrl() != "" {
+		baseUrl = p.OverrideUrl()
+	}
+
+	if p != nil && p.ProfileOptions().Adapter != "" {
+		adapter = p.ProfileOptions().Adapter
+	}
+
+	if adapter != "" && adapter != defaultAdapter {
+		return false
+	}
+
+	target, err := url.Parse(baseUrl)
+	defaultTarget, _ := url.Parse(defaultBaseUrl)
+	return err == nil && strings.EqualFold(target.Hostname(), defaultTarget.Hostname())
+}
+
+// Returns the adapter settings resolved from every layer.
+func Adapter() AdapterSettings {
+	lookup := func(key string) string {
+		value, _ := Lookup(key)
+		return value
+	}
+
+	return AdapterSettings{
+		Adapter:    lookup(adapterKeyName),
+		AuthScheme: lookup(authSchemeKeyName),
+		AuthHeader: lookup(authHeaderKeyName),
+		ApiVersion: lookup(apiVersionKeyName),
+		Deployment: lookup(deploymentKeyName),
+	}
+}
+
+// Stores the adapter settings in the active context, or in the global settings when no context is active.
+// Empty values are removed, so that the defaults apply again.
+func SetAdapter(s AdapterSettings) (err error) {
+	if RuntimeConfig.Settings == nil {
+		RuntimeConfig.Settings = make(map[string]string)
+	}
+
+	prefix := ""
+	if name := CurrentContext(); name != "" {
+		prefix = contextKey(name, "")
+	}
+
+	for k, v := range s.values() {
+		if v == "" {
+			delete(RuntimeConfig.Settings, prefix+k)
+		} else {
+			RuntimeConfig.Settings[prefix+k] = v
+		}
+	}
+
+	err = RuntimeConfig.Repository.Set(RuntimeConfig)
+	return
+}
+
+func (s AdapterSettings) values() map[string]string {
+	return map[string]string{
+		adapterKeyName:    s.Adapter,
+		authSchemeKeyName: s.AuthScheme,
+		authHeaderKeyName: s.AuthHeader,
+		apiVersionKeyName: s.ApiVersion,
+		deploymentKeyName: s.Deployment,
+	}
+}
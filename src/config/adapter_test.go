@@ -0,0 +1,84 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/embeddings"
+	"github.com/ephex2/go-gpt-cli/image"
+)
+
+func TestTargetsOpenAI(t *testing.T) {
+	t.Cleanup(config.ResetFlagSettings)
+
+	tests := []struct {
+		name     string
+		baseUrl  string
+		adapter  string
+		p        embeddings.EmbeddingsProfile
+		expected bool
+	}{
+		{"defaults", "", "", embeddings.EmbeddingsProfile{}, true},
+		{"openai base url", "https://api.openai.com/", "openai", embeddings.EmbeddingsProfile{}, true},
+		{"other base url", "http://localhost:11434", "", embeddings.EmbeddingsProfile{}, false},
+		{"azure adapter", "", "azure", embeddings.EmbeddingsProfile{}, false},
+		{"profile url", "", "", embeddings.EmbeddingsProfile{Url: "https://api.mistral.ai"}, false},
+		{"profile adapter", "", "", embeddings.EmbeddingsProfile{Options: profile.Options{Adapter: "azure"}}, false},
+		// Profiles can send their requests to OpenAI when the settings select another vendor
+		{"profile back to openai", "http://localhost:11434", "", embeddings.EmbeddingsProfile{Url: "https://api.openai.com"}, true},
+	}
+
+	for _, test := range tests {
+		config.ResetFlagSettings()
+		if test.baseUrl != "" {
+			config.SetFlagSetting("BaseUrl", test.baseUrl, "url")
+		}
+
+		if test.adapter != "" {
+			config.SetFlagSetting("Adapter", test.adapter, "adapter")
+		}
+
+		if actual := config.TargetsOpenAI(test.p); actual != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestModelsOfOtherVendors(t *testing.T) {
+	t.Cleanup(config.ResetFlagSettings)
+
+	r := profile.Registry{}
+	embeddings.Register(&r)
+	image.Register(&r)
+
+	model := "nomic-embed-text"
+	validators := []struct {
+		name string
+		p    profile.Profile
+		path string
+	}{
+		{"embeddings", embeddings.EmbeddingsProfile{CreateEmbeddingBody: embeddings.CreateEmbeddingBody{Model: model}}, "CreateEmbeddingBody.model"},
+		{"image", image.ImageProfile{CreateImageBody: image.CreateImageBody{Model: &model}}, "CreateImageBody.model"},
+	}
+
+	for _, test := range validators {
+		e, err := r.Get(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		v := e.(profile.Validator)
+		config.ResetFlagSettings()
+		errs := v.ValidateProfile(test.p)
+		if len(errs) != 1 || errs[0].Path != test.path {
+			t.Errorf("%s: expected the model to be refused for OpenAI, got: %v", test.name, errs)
+		}
+
+		config.SetFlagSetting("BaseUrl", "http://localhost:11434", "url")
+		errs = v.ValidateProfile(test.p)
+		if len(errs) != 0 {
+			t.Errorf("%s: expected the model to be allowed for another vendor, got: %v", test.name, errs)
+		}
+	}
+}
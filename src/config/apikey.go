@@ -2,27 +2,72 @@ package config
 
 import (
 	"errors"
+	"strings"
+
+	"github.com/ephex2/go-gpt-cli/config/secret"
 )
 
 var SetKeyDoc = "go-gpt-cli setKey abc123"
 
+const apiKeyKeyName string = "ApiKey"
+
+// Settings holding secrets, or references to secrets. Their values are masked when settings are displayed.
+var SecretKeys = []string{apiKeyKeyName}
+
+// Returns the api key, resolving it when the setting references a secret (env:, file:, cmd:, encrypted:).
 func GetApiKey() (string, error) {
-	if value, ok := RuntimeConfig.Settings["ApiKey"]; ok {
-		return value, nil
+	if value, ok := Lookup(apiKeyKeyName); ok {
+		return secret.Resolve(value)
 	} else {
 		return "", errors.New("ApiKey not defined in settings. Set it using SetApiKey, ex: " + SetKeyDoc)
 	}
 }
 
 func SetApiKey(key string) (err error) {
-	if RuntimeConfig.Settings == nil {
-		m := make(map[string]string)
-		RuntimeConfig.Settings = m
+	return SetApiKeyFrom(secret.SourcePlain, key)
+}
+
+// Stores the api key from a source: the key itself for plain and encrypted sources, an environment variable name,
+// a file path, or a helper command otherwise.
+func SetApiKeyFrom(source string, value string) (err error) {
+	// Each context has its own secret in the encrypted store
+	name := apiKeyKeyName
+	if context := CurrentContext(); context != "" {
+		name = context + "." + apiKeyKeyName
+	}
+
+	ref, err := secret.Reference(source, name, value)
+	if err != nil {
+		return
 	}
 
-	RuntimeConfig.Settings["ApiKey"] = key
-	return RuntimeConfig.Repository.Set(RuntimeConfig)
+	return storeSetting(apiKeyKeyName, ref)
 
 	// no need to refresh since memory value = disk calue in happy path
 	// error path will need to be determined by callerFile either terminate or refresh and continue.
 }
+
+// Returns whether a setting holds a secret. Contexts hold secrets under the same keys as the global settings.
+func IsSecretKey(key string) bool {
+	for _, secretKey := range SecretKeys {
+		if key == secretKey || (strings.HasPrefix(key, contextKeyPrefix) && strings.HasSuffix(key, "."+secretKey)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns a copy of the settings where secrets are masked.
+func MaskedSettings() map[string]string {
+	masked := make(map[string]string)
+	for k, v := range RuntimeConfig.Settings {
+		if IsSecretKey(k) {
+			v = secret.Mask(v)
+		}
+
+		masked[k] = v
+	}
+
+	return masked
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+const cacheTTLKeyName string = "CacheTTL"
+const cacheMaxSizeKeyName string = "CacheMaxSize"
+
+// Limits of the response cache when they are not set in the settings.
+const defaultCacheTTL = 24 * time.Hour
+const defaultCacheMaxSize int64 = 100 * 1024 * 1024
+
+// Duration for which cached responses are served.
+func CacheTTL() time.Duration {
+	if value, ok := Lookup(cacheTTLKeyName); ok {
+		ttl, err := time.ParseDuration(value)
+		if err == nil {
+			return ttl
+		}
+	}
+
+	return defaultCacheTTL
+}
+
+// Size in bytes the response cache is kept under, the least recently used responses are evicted first.
+func CacheMaxSize() int64 {
+	if value, ok := Lookup(cacheMaxSizeKeyName); ok {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err == nil {
+			return size
+		}
+	}
+
+	return defaultCacheMaxSize
+}
+
+// Stores the cache limits in the active context, or in the global settings when no context is active.
+func SetCacheLimits(ttl time.Duration, maxSize int64) (err error) {
+	if ttl <= 0 {
+		err = errors.New("the cache ttl must be greater than 0")
+		return
+	}
+
+	if maxSize <= 0 {
+		err = errors.New("the cache max size must be greater than 0")
+		return
+	}
+
+	err = storeSetting(cacheTTLKeyName, ttl.String())
+	if err != nil {
+		return
+	}
+
+	err = storeSetting(cacheMaxSizeKeyName, strconv.FormatInt(maxSize, 10))
+	return
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"net/url"
+	"strings"
 
 	"github.com/ephex2/go-gpt-cli/config/profile"
 )
@@ -22,12 +23,18 @@ type Config struct {
 	Repository ConfigRepository
 }
 
+// Directories of the CLI, set by the repository when it is initialized. Settings and profiles are stored in ConfigDir,
+// local data such as the usage ledger in DataDir, and data which can be rebuilt such as cached responses in CacheDir.
+var ConfigDir string
+var DataDir string
+var CacheDir string
+
 // The config to refer to at runtime. It contains settings that can be referenced by all endpoints as well as its own repository.
 // This repository can be used to make modifications to the config.
 var RuntimeConfig Config
 
 func BaseUrl() string {
-	if url, ok := RuntimeConfig.Settings[baseUrlKeyName]; ok {
+	if url, ok := Lookup(baseUrlKeyName); ok {
 		return url
 	}
 
@@ -35,6 +42,28 @@ func BaseUrl() string {
 }
 
 func SetBaseUrl(baseurl string) (err error) {
+	err = validateBaseUrl(baseurl)
+	if err != nil {
+		return
+	}
+
+	// Url ok, set config
+	err = storeSetting(baseUrlKeyName, baseurl)
+	return
+}
+
+// Sets the base url used for this execution only, without changing the settings.
+func SetBaseUrlOverride(baseurl string) (err error) {
+	err = validateBaseUrl(baseurl)
+	if err != nil {
+		return
+	}
+
+	SetFlagSetting(baseUrlKeyName, baseurl, "base-url")
+	return
+}
+
+func validateBaseUrl(baseurl string) (err error) {
 	u, err := url.Parse(baseurl)
 	if err != nil {
 		return
@@ -50,13 +79,6 @@ func SetBaseUrl(baseurl string) (err error) {
 		return
 	}
 
-	// Url ok, set config
-	RuntimeConfig.Settings[baseUrlKeyName] = baseurl
-	err = RuntimeConfig.Repository.Set(RuntimeConfig)
-	if err != nil {
-		return
-	}
-
 	return
 }
 
@@ -74,7 +96,7 @@ func (c *Config) Init(cr ConfigRepository) (err error) {
 // If none exist, creates the default profile associated with that endpoint.
 // This avoids errors when running the tool for the first time.
 func (c *Config) GetDefaultProfile(endpointName string) (s string, err error) {
-	s, ok := c.Settings[endpointName+"DefaultProfile"]
+	s, ok := Lookup(endpointName + "DefaultProfile")
 
 	// if default profile doesn't exist, create it
 	if !ok || s == "" {
@@ -105,11 +127,10 @@ func SetDefaultProfile(endpointName string, profileName string, force bool) (err
 		return
 	}
 
-	retrievedName, ok := RuntimeConfig.Settings[endpointName+"DefaultProfile"]
+	retrievedName, ok := Lookup(endpointName + "DefaultProfile")
 
 	if !ok || force || retrievedName == "" {
-		RuntimeConfig.Settings[endpointName+"DefaultProfile"] = profileName
-		err = RuntimeConfig.Repository.Set(RuntimeConfig)
+		err = storeSetting(endpointName+"DefaultProfile", profileName)
 		if err != nil {
 			return
 		}
@@ -118,8 +139,26 @@ func SetDefaultProfile(endpointName string, profileName string, force bool) (err
 	return
 }
 
+// Points the default profile settings of an endpoint which name oldName to newName, in the global settings and in every context.
+func RenameDefaultProfile(endpointName string, oldName string, newName string) (err error) {
+	key := endpointName + "DefaultProfile"
+	changed := false
+	for k, v := range RuntimeConfig.Settings {
+		if (k == key || (strings.HasPrefix(k, contextKeyPrefix) && strings.HasSuffix(k, "."+key))) && v == oldName {
+			RuntimeConfig.Settings[k] = newName
+			changed = true
+		}
+	}
+
+	if changed {
+		err = RuntimeConfig.Repository.Set(RuntimeConfig)
+	}
+
+	return
+}
+
 // Gets default profile, returns empty string if not found
 func GetDefaultProfile(endpointName string) string {
-	profileName := RuntimeConfig.Settings[endpointName+"DefaultProfile"]
+	profileName, _ := Lookup(endpointName + "DefaultProfile")
 	return profileName
 }
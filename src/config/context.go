@@ -0,0 +1,280 @@
+package config
+
+// Contexts bundle the settings of a connection: base url, api key, organization and project ids, extra headers, adapter
+// and default profiles per endpoint. They are stored in the settings under keys prefixed with "context.<name>.", so that
+// they go through the ConfigRepository like every other setting.
+// While a context is active, its settings take priority over the global ones, and setters (seturl, apikey...) write to it.
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const currentContextKeyName string = "CurrentContext"
+const contextKeyPrefix string = "context."
+
+// Key marking that a context exists, contexts can be created without any setting.
+const contextNameKeyName string = "Name"
+
+const organizationKeyName string = "Organization"
+const projectKeyName string = "Project"
+const headerKeyPrefix string = "Header."
+
+// Name used to refer to the global settings, when no context is active.
+const GlobalContext string = "global"
+
+var contextNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type Context struct {
+	Name         string
+	BaseUrl      string            `json:",omitempty"`
+	ApiKey       string            `json:",omitempty"` // Plaintext key, or a reference to a secret (env:, file:, cmd:, encrypted:)
+	Organization string            `json:",omitempty"`
+	Project      string            `json:",omitempty"`
+	Headers      map[string]string `json:",omitempty"`
+	AdapterSettings
+}
+
+func contextKey(name string, key string) string {
+	return contextKeyPrefix + name + "." + key
+}
+
+// Returns the name of the active context, or an empty string when the global settings are used.
+// Like other settings, the active context can come from a flag, the environment or the project settings.
+func CurrentContext() string {
+	r, _ := resolve(currentContextKeyName, false)
+	if r.Value == GlobalContext {
+		return ""
+	}
+
+	return r.Value
+}
+
+// Selects the context used for this execution only, without changing the current context.
+func SetContextOverride(name string) (err error) {
+	if name != GlobalContext && !ContextExists(name) {
+		err = errors.New("no context named " + name + ", existing contexts are: " + strings.Join(ListContexts(), ", "))
+		return
+	}
+
+	SetFlagSetting(currentContextKeyName, name, "context")
+	return
+}
+
+func ContextExists(name string) bool {
+	_, ok := RuntimeConfig.Settings[contextKey(name, contextNameKeyName)]
+	return ok
+}
+
+func ListContexts() (names []string) {
+	for k := range RuntimeConfig.Settings {
+		if strings.HasPrefix(k, contextKeyPrefix) && strings.HasSuffix(k, "."+contextNameKeyName) {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(k, contextKeyPrefix), "."+contextNameKeyName))
+		}
+	}
+
+	sort.Strings(names)
+	return
+}
+
+// Creates a context. Its api key, when set, must already be a reference or a plaintext key.
+func CreateContext(c Context) (err error) {
+	if c.Name == GlobalContext || !contextNameRegexp.MatchString(c.Name) {
+		err = errors.New("invalid context name " + c.Name + ", names can only contain letters, digits, '-' and '_', and can not be " + GlobalContext)
+		return
+	}
+
+	if ContextExists(c.Name) {
+		err = errors.New("context " + c.Name + " already exists")
+		return
+	}
+
+	if c.BaseUrl != "" {
+		err = validateBaseUrl(c.BaseUrl)
+		if err != nil {
+			return
+		}
+	}
+
+	if RuntimeConfig.Settings == nil {
+		RuntimeConfig.Settings = make(map[string]string)
+	}
+
+	values := map[string]string{
+		contextNameKeyName:  c.Name,
+		baseUrlKeyName:      c.BaseUrl,
+		apiKeyKeyName:       c.ApiKey,
+		organizationKeyName: c.Organization,
+		projectKeyName:      c.Project,
+	}
+
+	for k, v := range c.Headers {
+		values[headerKeyPrefix+k] = v
+	}
+
+	for k, v := range c.AdapterSettings.values() {
+		values[k] = v
+	}
+
+	for k, v := range values {
+		if v != "" {
+			RuntimeConfig.Settings[contextKey(c.Name, k)] = v
+		}
+	}
+
+	err = RuntimeConfig.Repository.Set(RuntimeConfig)
+	return
+}
+
+// Returns the settings of a context, as stored: api keys are not resolved.
+func GetContext(name string) (c Context, err error) {
+	if !ContextExists(name) {
+		err = errors.New("no context named " + name)
+		return
+	}
+
+	c = Context{Name: name, Headers: make(map[string]string)}
+	for key, v := range contextSettings(name) {
+		switch {
+		case key == baseUrlKeyName:
+			c.BaseUrl = v
+		case key == apiKeyKeyName:
+			c.ApiKey = v
+		case key == organizationKeyName:
+			c.Organization = v
+		case key == projectKeyName:
+			c.Project = v
+		case key == adapterKeyName:
+			c.Adapter = v
+		case key == authSchemeKeyName:
+			c.AuthScheme = v
+		case key == authHeaderKeyName:
+			c.AuthHeader = v
+		case key == apiVersionKeyName:
+			c.ApiVersion = v
+		case key == deploymentKeyName:
+			c.Deployment = v
+		case strings.HasPrefix(key, headerKeyPrefix):
+			c.Headers[strings.TrimPrefix(key, headerKeyPrefix)] = v
+		}
+	}
+
+	return
+}
+
+// Makes a context the current one, GlobalContext goes back to the global settings.
+func UseContext(name string) (err error) {
+	if name != GlobalContext && !ContextExists(name) {
+		err = errors.New("no context named " + name)
+		return
+	}
+
+	if name == GlobalContext {
+		delete(RuntimeConfig.Settings, currentContextKeyName)
+	} else {
+		RuntimeConfig.Settings[currentContextKeyName] = name
+	}
+
+	err = RuntimeConfig.Repository.Set(RuntimeConfig)
+	return
+}
+
+// Deletes a context and all of its settings. The global settings are used when the current context is deleted.
+func DeleteContext(name string) (err error) {
+	if !ContextExists(name) {
+		err = errors.New("no context named " + name)
+		return
+	}
+
+	prefix := contextKey(name, "")
+	for k := range RuntimeConfig.Settings {
+		if strings.HasPrefix(k, prefix) {
+			delete(RuntimeConfig.Settings, k)
+		}
+	}
+
+	if RuntimeConfig.Settings[currentContextKeyName] == name {
+		delete(RuntimeConfig.Settings, currentContextKeyName)
+	}
+
+	err = RuntimeConfig.Repository.Set(RuntimeConfig)
+	return
+}
+
+// Stores a setting in the active context, or in the global settings when no context is active.
+func storeSetting(key string, value string) (err error) {
+	if RuntimeConfig.Settings == nil {
+		RuntimeConfig.Settings = make(map[string]string)
+	}
+
+	if name := CurrentContext(); name != "" {
+		key = contextKey(name, key)
+	}
+
+	RuntimeConfig.Settings[key] = value
+	err = RuntimeConfig.Repository.Set(RuntimeConfig)
+	return
+}
+
+// Stores a setting of another package the way the settings of this package are stored. An empty value removes the
+// setting from the active context, or from the global settings, so that the value of the layer below applies again.
+func StoreSetting(key string, value string) (err error) {
+	if value != "" {
+		return storeSetting(key, value)
+	}
+
+	if name := CurrentContext(); name != "" {
+		key = contextKey(name, key)
+	}
+
+	delete(RuntimeConfig.Settings, key)
+	err = RuntimeConfig.Repository.Set(RuntimeConfig)
+	return
+}
+
+func Organization() string {
+	value, _ := Lookup(organizationKeyName)
+	return value
+}
+
+func Project() string {
+	value, _ := Lookup(projectKeyName)
+	return value
+}
+
+// Returns the extra headers sent with every request. Headers are merged across layers, so that a context or project
+// can add headers to the global ones.
+func Headers() map[string]string {
+	layers := []map[string]string{RuntimeConfig.Settings}
+	if name := CurrentContext(); name != "" {
+		layers = append(layers, contextSettings(name))
+	}
+
+	layers = append(layers, ProjectSettings(), flagSettings)
+
+	headers := make(map[string]string)
+	for _, layer := range layers {
+		for k, v := range layer {
+			if strings.HasPrefix(k, headerKeyPrefix) {
+				headers[strings.TrimPrefix(k, headerKeyPrefix)] = v
+			}
+		}
+	}
+
+	return headers
+}
+
+// Returns the settings of a context, without their context prefix.
+func contextSettings(name string) map[string]string {
+	prefix := contextKey(name, "")
+	settings := make(map[string]string)
+	for k, v := range RuntimeConfig.Settings {
+		if strings.HasPrefix(k, prefix) {
+			settings[strings.TrimPrefix(k, prefix)] = v
+		}
+	}
+
+	return settings
+}
@@ -0,0 +1,209 @@
+package format
+
+// Settings and profile files can be written in json, yaml or toml, the format of a file being chosen by its extension.
+// Within the program settings and profiles are exchanged as json, files are converted when they are read and written.
+// Comments of yaml files are kept when they are rewritten, toml comments are lost as the toml encoder can not keep them.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	Json = "json"
+	Yaml = "yaml"
+	Toml = "toml"
+)
+
+var Formats = []string{Json, Yaml, Toml}
+
+// File extensions, without their dot, of each format. The first one is used for new files.
+var extensions = map[string][]string{
+	Json: {"json"},
+	Yaml: {"yaml", "yml"},
+	Toml: {"toml"},
+}
+
+// Returns an error when a format is not supported.
+func Check(format string) error {
+	if _, ok := extensions[format]; !ok {
+		return errors.New("unknown format " + format + ", formats are: " + strings.Join(Formats, ", "))
+	}
+
+	return nil
+}
+
+// Returns the format of a file from its extension, json when the extension is not one of a format.
+func FromPath(path string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	for _, format := range Formats {
+		for _, e := range extensions[format] {
+			if e == ext {
+				return format
+			}
+		}
+	}
+
+	return Json
+}
+
+// Returns the file extensions of a format, without their dot.
+func Extensions(format string) []string {
+	return extensions[format]
+}
+
+// Converts the content of a file to json. Empty files stay empty.
+func ToJson(format string, buf []byte) (out []byte, err error) {
+	if format == Json || len(bytes.TrimSpace(buf)) == 0 {
+		return buf, nil
+	}
+
+	var v any
+	switch format {
+	case Yaml:
+		err = yaml.Unmarshal(buf, &v)
+	case Toml:
+		var m map[string]any
+		err = toml.Unmarshal(buf, &m)
+		v = m
+	default:
+		err = Check(format)
+	}
+
+	if err != nil {
+		return
+	}
+
+	return json.Marshal(v)
+}
+
+// Converts json to the content of a file of a format. When the file replaces a previous yaml file, the comments of the
+// previous file are kept on the settings which still exist.
+func FromJson(format string, buf []byte, previous []byte) (out []byte, err error) {
+	switch format {
+	case Json:
+		return buf, nil
+	case Yaml:
+		return toYaml(buf, previous)
+	case Toml:
+		return toToml(buf)
+	}
+
+	return nil, Check(format)
+}
+
+func toYaml(buf []byte, previous []byte) (out []byte, err error) {
+	// Json is yaml, decoding it as a node keeps the order of its keys
+	var doc yaml.Node
+	err = yaml.Unmarshal(buf, &doc)
+	if err != nil {
+		return
+	}
+
+	blockStyle(&doc)
+
+	var old yaml.Node
+	if len(previous) > 0 && yaml.Unmarshal(previous, &old) == nil {
+		keepComments(&old, &doc)
+	}
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	err = enc.Encode(&doc)
+	if err == nil {
+		err = enc.Close()
+	}
+
+	return b.Bytes(), err
+}
+
+// Removes the json flow style of decoded json, multi-line strings such as prompts are written as literal blocks.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!str" && strings.Contains(n.Value, "\n") {
+		n.Style = yaml.LiteralStyle
+	}
+
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
+
+// Copies the comments of a previous yaml document to the nodes of the same settings of a new one.
+func keepComments(old *yaml.Node, n *yaml.Node) {
+	if n.HeadComment == "" && n.LineComment == "" && n.FootComment == "" {
+		n.HeadComment, n.LineComment, n.FootComment = old.HeadComment, old.LineComment, old.FootComment
+	}
+
+	if old.Kind != n.Kind {
+		return
+	}
+
+	switch n.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for i := 0; i < len(n.Content) && i < len(old.Content); i++ {
+			keepComments(old.Content[i], n.Content[i])
+		}
+	case yaml.MappingNode:
+		oldPairs := make(map[string]int)
+		for i := 0; i+1 < len(old.Content); i += 2 {
+			oldPairs[old.Content[i].Value] = i
+		}
+
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if j, ok := oldPairs[n.Content[i].Value]; ok {
+				keepComments(old.Content[j], n.Content[i])
+				keepComments(old.Content[j+1], n.Content[i+1])
+			}
+		}
+	}
+}
+
+func toToml(buf []byte) (out []byte, err error) {
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+
+	var m map[string]any
+	err = dec.Decode(&m)
+	if err != nil {
+		return
+	}
+
+	return toml.Marshal(tomlValue(m))
+}
+
+// Toml has no null, null settings are left out as they decode to their zero value. Json numbers are written as
+// integers when they are whole, so that integer settings stay integers.
+func tomlValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			if child == nil {
+				delete(t, k)
+				continue
+			}
+
+			t[k] = tomlValue(child)
+		}
+	case []any:
+		for i, child := range t {
+			t[i] = tomlValue(child)
+		}
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n
+		}
+
+		f, _ := t.Float64()
+		return f
+	}
+
+	return v
+}
@@ -0,0 +1,282 @@
+package config
+
+// Settings are resolved from layers, each layer overriding the ones before it:
+//
+//	default < global < context < project < env < flag
+//
+// The global layer is the settings file of the ConfigRepository, the context layer the settings of the active context,
+// and the project layer the first .go-gpt-cli.json found walking up from the working directory.
+// Setters always write to the active context, or to the global settings when no context is active.
+//
+// The environment variables of other OpenAI clients, such as OPENAI_API_KEY, do not override the settings of the
+// active context: selecting a context must not send the OpenAI key, or url, to the backend of that context.
+// Project files come with the directories they are found in, such as cloned repositories, and are not trusted with the
+// settings deciding where requests go and which key they carry, see projectIgnoredSettings.
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ephex2/go-gpt-cli/config/format"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/log"
+)
+
+const (
+	LayerDefault = "default"
+	LayerGlobal  = "global"
+	LayerContext = "context"
+	LayerProject = "project"
+	LayerEnv     = "env"
+	LayerFlag    = "flag"
+)
+
+const ProjectFileName string = ".go-gpt-cli.json"
+
+// Prefix of the environment variables overriding settings, ex: GO_GPT_CLI_BASE_URL for BaseUrl.
+const envPrefix string = "GO_GPT_CLI_"
+
+var defaultSettings = map[string]string{
+	baseUrlKeyName:       defaultBaseUrl,
+	adapterKeyName:       defaultAdapter,
+	profileFormatKeyName: format.Json,
+	cacheTTLKeyName:      defaultCacheTTL.String(),
+	cacheMaxSizeKeyName:  strconv.FormatInt(defaultCacheMaxSize, 10),
+}
+
+// Environment variables commonly used by OpenAI clients, checked after the GO_GPT_CLI_ variable of a setting.
+var envAliases = map[string][]string{
+	apiKeyKeyName:         {"OPENAI_API_KEY"},
+	baseUrlKeyName:        {"OPENAI_BASE_URL"},
+	organizationKeyName:   {"OPENAI_ORG_ID", "OPENAI_ORGANIZATION"},
+	projectKeyName:        {"OPENAI_PROJECT_ID"},
+	apiVersionKeyName:     {"OPENAI_API_VERSION"},
+	currentContextKeyName: {"GO_GPT_CLI_CONTEXT"},
+}
+
+// Settings which are ignored in project files: they would allow any directory to run a command through a secret
+// reference, or to send the api key of the user to a host of its choosing. Contexts are ignored for the same reasons.
+var projectIgnoredSettings = []string{apiKeyKeyName, baseUrlKeyName, adapterKeyName, authSchemeKeyName, authHeaderKeyName, deploymentKeyName}
+
+// Settings provided by flags for this execution.
+var flagSettings = make(map[string]string)
+
+var projectLoaded bool
+var projectPath string
+var projectSettings map[string]string
+
+// Describes where the effective value of a setting comes from.
+type Resolution struct {
+	Key    string
+	Value  string
+	Layer  string
+	Source string `json:",omitempty"` // File, environment variable, flag or context which supplied the value
+}
+
+// Flags which provided the flag settings, by setting.
+var flagSources = make(map[string]string)
+
+// Sets the value of a setting for this execution, taking priority over every other layer.
+func SetFlagSetting(key string, value string, flagName string) {
+	flagSettings[key] = value
+	flagSources[key] = "--" + flagName
+}
+
+// Removes the settings provided by flags, so that the next execution starts without them.
+func ResetFlagSettings() {
+	flagSettings = make(map[string]string)
+	flagSources = make(map[string]string)
+}
+
+// Returns the effective value of a setting.
+func Lookup(key string) (value string, ok bool) {
+	r, ok := Resolve(key)
+	return r.Value, ok
+}
+
+// Returns the effective value of a setting along with the layer which supplied it.
+func Resolve(key string) (r Resolution, ok bool) {
+	return resolve(key, true)
+}
+
+func resolve(key string, includeContext bool) (r Resolution, ok bool) {
+	r.Key = key
+
+	if value, found := flagSettings[key]; found {
+		r.Value, r.Layer, r.Source = value, LayerFlag, flagSources[key]
+		return r, true
+	}
+
+	// The active context is itself a setting, which can not come from a context
+	var contextName, contextValue string
+	var inContext bool
+	if includeContext && key != currentContextKeyName && key != contextNameKeyName {
+		if contextName = CurrentContext(); contextName != "" {
+			contextValue, inContext = RuntimeConfig.Settings[contextKey(contextName, key)]
+		}
+	}
+
+	if value, name, found := envSetting(key, !inContext); found {
+		r.Value, r.Layer, r.Source = value, LayerEnv, name
+		return r, true
+	}
+
+	if value, found := ProjectSettings()[key]; found {
+		r.Value, r.Layer, r.Source = value, LayerProject, projectPath
+		return r, true
+	}
+
+	if inContext {
+		r.Value, r.Layer, r.Source = contextValue, LayerContext, contextName
+		return r, true
+	}
+
+	if value, found := RuntimeConfig.Settings[key]; found {
+		r.Value, r.Layer = value, LayerGlobal
+		return r, true
+	}
+
+	if value, found := defaultSettings[key]; found {
+		r.Value, r.Layer = value, LayerDefault
+		return r, true
+	}
+
+	return r, false
+}
+
+// Returns the name of the environment variable of a setting: BaseUrl is GO_GPT_CLI_BASE_URL, chatDefaultProfile is GO_GPT_CLI_CHAT_DEFAULT_PROFILE.
+func EnvName(key string) string {
+	var b strings.Builder
+	runes := []rune(key)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteRune('_')
+		}
+
+		if r == '.' || r == '-' {
+			r = '_'
+		}
+
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return envPrefix + b.String()
+}
+
+// The variables of other OpenAI clients are only checked when aliases is true.
+func envSetting(key string, aliases bool) (value string, name string, ok bool) {
+	names := []string{EnvName(key)}
+	if aliases {
+		names = append(names, envAliases[key]...)
+	}
+
+	for _, name = range names {
+		value = os.Getenv(name)
+		if value == "" {
+			continue
+		}
+
+		// Clients expect OPENAI_BASE_URL to include the version, while routes of this CLI include it
+		if key == baseUrlKeyName {
+			value = strings.TrimSuffix(strings.TrimSuffix(value, "/"), "/v1")
+		}
+
+		return value, name, true
+	}
+
+	return "", "", false
+}
+
+// Returns the settings of the project file, found by walking up from the working directory. Errors are only logged.
+func ProjectSettings() map[string]string {
+	if projectLoaded {
+		return projectSettings
+	}
+
+	projectLoaded = true
+	projectSettings = make(map[string]string)
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return projectSettings
+	}
+
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		buf, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(buf, &projectSettings)
+			if err != nil {
+				projectSettings = make(map[string]string)
+				log.Warning("Ignoring project settings %s, unable to parse them: %s\n", path, err.Error())
+			}
+
+			for k := range projectSettings {
+				if slices.Contains(projectIgnoredSettings, k) || strings.HasPrefix(k, contextKeyPrefix) {
+					log.Warning("Ignoring the %s setting of project settings %s, it can only be set globally or in a context\n", k, path)
+					delete(projectSettings, k)
+				}
+			}
+
+			projectPath = path
+			return projectSettings
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return projectSettings
+		}
+
+		dir = parent
+	}
+}
+
+// Returns the resolution of every known setting, along with the ones set in any layer. Secrets are not masked.
+func Explain() (resolutions []Resolution) {
+	keys := make(map[string]bool)
+	for _, k := range []string{baseUrlKeyName, apiKeyKeyName, organizationKeyName, projectKeyName, adapterKeyName, currentContextKeyName, cacheTTLKeyName, cacheMaxSizeKeyName, profileFormatKeyName} {
+		keys[k] = true
+	}
+
+	for _, e := range profile.EndpointRegistry.Endpoints {
+		keys[e.Name()+"DefaultProfile"] = true
+	}
+
+	layers := []map[string]string{RuntimeConfig.Settings, ProjectSettings(), flagSettings}
+	for _, layer := range layers {
+		for k := range layer {
+			if !strings.HasPrefix(k, contextKeyPrefix) {
+				keys[k] = true
+			}
+		}
+	}
+
+	if name := CurrentContext(); name != "" {
+		for k := range contextSettings(name) {
+			if k != contextNameKeyName {
+				keys[k] = true
+			}
+		}
+	}
+
+	var sorted []string
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		r, ok := Resolve(k)
+		if ok {
+			resolutions = append(resolutions, r)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,14 @@
+//go:build !unix && !windows
+
+package lockedfile
+
+import "os"
+
+// Platforms without advisory locks only get atomic writes.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
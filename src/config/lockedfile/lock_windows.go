@@ -0,0 +1,20 @@
+//go:build windows
+
+package lockedfile
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// Locks the whole file, whatever its size.
+const allBytes = ^uint32(0)
+
+func lockFile(f *os.File) error {
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, allBytes, allBytes, new(windows.Overlapped))
+}
+
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, allBytes, allBytes, new(windows.Overlapped))
+}
@@ -0,0 +1,110 @@
+package lockedfile
+
+// Settings and profiles are shared by every running invocation of the CLI: a chat with MessageHistory saves its profile
+// while another may be saving it as well. Files are written to a temporary file which is renamed over the file, so that
+// a crash never leaves a truncated file, and read-modify-write cycles hold an advisory lock on a lock file next to the
+// files they change.
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Advisory locks exclude other processes only, the goroutines of this process exclude each other with a mutex per
+// path, taken before the lock file is. The map only grows, with an entry per profile locked by the invocation.
+var (
+	mu    sync.Mutex
+	paths = make(map[string]*sync.Mutex)
+)
+
+// Takes an exclusive lock on a lock file, created when needed, waiting for other goroutines and processes to release
+// it. Locks are not reentrant: a goroutine taking a lock it holds waits for itself. Every call to Lock must be followed
+// by a call to unlock.
+func Lock(path string) (unlock func(), err error) {
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return
+	}
+
+	mu.Lock()
+	pathMu, ok := paths[path]
+	if !ok {
+		pathMu = &sync.Mutex{}
+		paths[path] = pathMu
+	}
+	mu.Unlock()
+
+	pathMu.Lock()
+	defer func() {
+		if err != nil {
+			pathMu.Unlock()
+		}
+	}()
+
+	err = os.MkdirAll(filepath.Dir(path), 0750)
+	if err != nil {
+		return
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+
+	err = lockFile(f)
+	if err != nil {
+		f.Close()
+		return
+	}
+
+	var once sync.Once
+	unlock = func() {
+		once.Do(func() {
+			unlockFile(f)
+			f.Close()
+			pathMu.Unlock()
+		})
+	}
+
+	return
+}
+
+// Replaces the content of a file: the content is written to a temporary file of the same directory, which is then
+// renamed over the file. Readers see either the previous or the new content.
+func WriteFile(path string, buf []byte, perm os.FileMode) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	_, err = tmp.Write(buf)
+	if err != nil {
+		return
+	}
+
+	err = tmp.Chmod(perm)
+	if err != nil {
+		return
+	}
+
+	// The content must be on disk before the file is replaced
+	err = tmp.Sync()
+	if err != nil {
+		return
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,130 @@
+package lockedfile
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockExcludesGoroutines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locks", "profile.lock")
+
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		unlock, err := Lock(path)
+		if err != nil {
+			t.Error(err)
+		}
+
+		close(acquired)
+		unlock()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("expected the lock to be refused to another goroutine while it is held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	// Unlocking twice has no effect
+	unlock()
+
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the lock to be taken once released")
+	}
+}
+
+func TestLockSerializesReadModifyWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "counter")
+	err := WriteFile(path, nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			unlock, err := Lock(path + ".lock")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer unlock()
+
+			buf, err := os.ReadFile(path)
+			if err == nil {
+				err = WriteFile(path, append(buf, 'x'), 0600)
+			}
+
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(buf) != 20 {
+		t.Errorf("expected every write to be kept, got %d of 20", len(buf))
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.json")
+
+	for _, content := range []string{`{"a": 1}`, `{}`} {
+		err := WriteFile(path, []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf, err := os.ReadFile(path)
+		if err != nil || string(buf) != content {
+			t.Errorf("expected %s, got %s (%v)", content, buf, err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Windows only keeps the read-only attribute of permissions
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("expected the file to be readable by its owner only, got %s", info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary file to be left, got: %v", entries)
+	}
+
+	err = WriteFile(filepath.Join(dir, "missing", "settings.json"), nil, 0600)
+	if err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
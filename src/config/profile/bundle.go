@@ -0,0 +1,371 @@
+package profile
+
+// Bundles share profiles across machines: they hold profiles as stored, so that profiles extending others keep their
+// overlays, with their secrets stripped. Bundles are built and imported through the Repository interface, so they work
+// with any repository.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/config/secret"
+)
+
+const bundleVersion = 1
+
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+	ConflictRename    = "rename"
+)
+
+var ConflictStrategies = []string{ConflictSkip, ConflictOverwrite, ConflictRename}
+
+type Bundle struct {
+	Version   int
+	CreatedAt time.Time
+	Profiles  []BundleProfile
+}
+
+type BundleProfile struct {
+	Endpoint string
+	Name     string
+	Profile  json.RawMessage
+}
+
+// Selects the profiles of an endpoint to export, every profile of the endpoint when Profiles is empty.
+type Selection struct {
+	Endpoint string
+	Profiles []string
+}
+
+// Outcome of the import of a profile.
+type ImportResult struct {
+	Endpoint string
+	Name     string
+	Action   string   // imported, overwritten, renamed, skipped or failed
+	NewName  string   `json:",omitempty"`
+	Warnings []string `json:",omitempty"` // Settings of the bundle which were dropped, or deserve a look before the profile is used
+	Err      error    `json:"-"`
+}
+
+// Settings which choose where requests are sent and how the api key is sent along. They are dropped from imported
+// profiles unless the bundle is trusted: a bundle could otherwise have the api key of the user sent to a host of its
+// choosing.
+var routingSettings = []string{"Url", "Adapter", "AuthScheme", "AuthHeader"}
+
+// Header names whose values are stripped from bundles, compared in lower case.
+var secretHeaderWords = []string{"authorization", "key", "token", "secret", "cookie"}
+
+// Exports the selected profiles. Parents of the selected profiles are exported along with them, before them, so that
+// the bundle can be imported on its own. Stripped secrets are returned as endpoint/profile paths.
+func Export(repo Repository, selections []Selection) (b Bundle, stripped []string, err error) {
+	b = Bundle{Version: bundleVersion, CreatedAt: time.Now().UTC()}
+	added := make(map[string]bool)
+
+	var add func(endpointName string, name string, chain []string) error
+	add = func(endpointName string, name string, chain []string) error {
+		key := endpointName + "/" + name
+		if added[key] {
+			return nil
+		}
+
+		for _, n := range chain {
+			if n == name {
+				return errors.New("profile inheritance cycle: " + strings.Join(append(chain, name), " -> "))
+			}
+		}
+
+		raw, err := repo.ReadRaw(name, endpointName)
+		if err != nil {
+			return errors.New("unable to read profile " + key + ": " + err.Error())
+		}
+
+		if parent := Parent(raw); parent != "" {
+			err = add(endpointName, parent, append(chain, name))
+			if err != nil {
+				return err
+			}
+		}
+
+		raw, paths, err := stripSecrets(raw)
+		if err != nil {
+			return errors.New("unable to parse profile " + key + ": " + err.Error())
+		}
+
+		for _, p := range paths {
+			stripped = append(stripped, key+": "+p)
+		}
+
+		added[key] = true
+		b.Profiles = append(b.Profiles, BundleProfile{Endpoint: endpointName, Name: name, Profile: raw})
+		return nil
+	}
+
+	for _, s := range selections {
+		names := s.Profiles
+		if len(names) == 0 {
+			names, err = repo.GetAll(s.Endpoint)
+			if err != nil {
+				return
+			}
+		}
+
+		for _, name := range names {
+			err = add(s.Endpoint, name, nil)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// Removes the api key and secret looking headers of a stored profile. Api keys referencing environment variables are
+// kept, as they hold no secret and are portable.
+func stripSecrets(raw []byte) (out []byte, paths []string, err error) {
+	var m map[string]any
+	err = json.Unmarshal(raw, &m)
+	if err != nil {
+		return
+	}
+
+	if key, ok := m["ApiKey"].(string); ok && key != "" && !strings.HasPrefix(key, secret.PrefixEnv) {
+		delete(m, "ApiKey")
+		paths = append(paths, "ApiKey")
+	}
+
+	if headers, ok := m["Headers"].(map[string]any); ok {
+		for name := range headers {
+			lower := strings.ToLower(name)
+			for _, word := range secretHeaderWords {
+				if strings.Contains(lower, word) {
+					delete(headers, name)
+					paths = append(paths, "Headers."+name)
+					break
+				}
+			}
+		}
+	}
+
+	sort.Strings(paths)
+	if len(paths) == 0 {
+		return raw, nil, nil
+	}
+
+	out, err = json.Marshal(m)
+	return
+}
+
+// Imports the profiles of a bundle. Profiles which already exist are skipped, overwritten, or imported under a new name
+// depending on the conflict strategy, profiles extending a renamed profile are updated to extend its new name.
+// Profiles are validated before they are saved, the ones which fail are reported in the results. The routing settings of
+// the bundle are only imported when it is trusted, see routingSettings.
+func Import(repo Repository, b Bundle, strategy string, trusted bool) (results []ImportResult, err error) {
+	if b.Version != bundleVersion {
+		err = fmt.Errorf("unsupported bundle version %d", b.Version)
+		return
+	}
+
+	// New names of the profiles imported under another name, by endpoint/name
+	renamed := make(map[string]string)
+
+	for _, bp := range b.Profiles {
+		r := ImportResult{Endpoint: bp.Endpoint, Name: bp.Name}
+		r.Action, r.NewName, r.Warnings, r.Err = importProfile(repo, bp, strategy, trusted, renamed)
+		if r.Err != nil {
+			r.Action = "failed"
+		}
+
+		results = append(results, r)
+	}
+
+	return
+}
+
+// Bundles come from other machines and are not trusted: names are checked before they reach the repository, api keys
+// which would read a file or run a command are dropped, see untrustedKey, and so are routing settings unless the bundle
+// is trusted. Profiles are never overwritten by ones sending requests to another host.
+func importProfile(repo Repository, bp BundleProfile, strategy string, trusted bool, renamed map[string]string) (action string, newName string, warnings []string, err error) {
+	e, err := EndpointRegistry.Get(bp.Endpoint)
+	if err != nil {
+		return
+	}
+
+	err = CheckName(bp.Name)
+	if err != nil {
+		return
+	}
+
+	var m map[string]any
+	err = json.Unmarshal(bp.Profile, &m)
+	if err != nil {
+		return
+	}
+
+	if parent, ok := m["Extends"].(string); ok {
+		err = CheckName(parent)
+		if err != nil {
+			return
+		}
+
+		if n, found := renamed[bp.Endpoint+"/"+parent]; found {
+			m["Extends"] = n
+		}
+	}
+
+	if key, ok := m["ApiKey"].(string); ok && untrustedKey(key) {
+		delete(m, "ApiKey")
+		warnings = append(warnings, "dropped ApiKey "+key+", bundles may only reference api keys with "+secret.PrefixEnv+" or hold them in plaintext")
+	}
+
+	for _, setting := range routingSettings {
+		if v, ok := m[setting]; ok && !trusted {
+			delete(m, setting)
+			warnings = append(warnings, fmt.Sprintf("dropped %s %v, routing settings are only imported from trusted bundles", setting, v))
+		}
+	}
+
+	// The profile is locked from the conflict check to its update, profiles imported under another name are locked by Update
+	unlock, err := repo.Lock(bp.Endpoint, bp.Name)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	name := bp.Name
+	action = "imported"
+	var previousUrl string
+	if _, readErr := repo.ReadRaw(name, bp.Endpoint); readErr == nil {
+		switch strategy {
+		case ConflictSkip:
+			return "skipped", "", warnings, nil
+		case ConflictOverwrite:
+			action = "overwritten"
+			previousUrl, err = overrideUrl(e, repo, bp.Endpoint, name)
+			if err != nil {
+				return
+			}
+		case ConflictRename:
+			name, err = freeName(repo, bp.Endpoint, name)
+			if err != nil {
+				return
+			}
+
+			action, newName = "renamed", name
+			renamed[bp.Endpoint+"/"+bp.Name] = name
+		default:
+			err = errors.New("unknown conflict strategy " + strategy + ", strategies are: " + strings.Join(ConflictStrategies, ", "))
+			return
+		}
+	}
+
+	m["ProfileName"] = name
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+
+	read := func(n string) ([]byte, error) {
+		return repo.ReadRaw(n, bp.Endpoint)
+	}
+
+	resolved, err := ResolveRaw(read, name, raw)
+	if err != nil {
+		return
+	}
+
+	p, err := Validate(e, resolved)
+	if err != nil {
+		return
+	}
+
+	if action == "overwritten" && urlHost(p.OverrideUrl()) != urlHost(previousUrl) {
+		err = errors.New("the profile would send its requests to " + describeUrl(p.OverrideUrl()) + " instead of " + describeUrl(previousUrl) + ", delete it first or import the bundle with --strategy rename")
+		return
+	}
+
+	if rawUrl := p.OverrideUrl(); rawUrl != "" {
+		key := "the configured api key"
+		if p.ProfileOptions().ApiKey != "" {
+			key = "the api key " + secret.Mask(p.ProfileOptions().ApiKey)
+		}
+
+		warnings = append(warnings, "requests made with this profile are sent to "+rawUrl+" with "+key)
+	}
+
+	if name != bp.Name {
+		err = repo.Update(p)
+	} else {
+		err = repo.UpdateLocked(p)
+	}
+
+	return
+}
+
+// Returns the url override of a stored profile, resolved with the profiles it extends.
+func overrideUrl(e Endpoint, repo Repository, endpointName string, name string) (rawUrl string, err error) {
+	buf, err := repo.Read(name, endpointName)
+	if err != nil {
+		return
+	}
+
+	p, err := e.ProfileFromJsonBuf(buf)
+	if err != nil {
+		return
+	}
+
+	return p.OverrideUrl(), nil
+}
+
+// Returns the host of a url override, empty when there is none, in which case the configured base url is used.
+func urlHost(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Host == "" {
+		return strings.ToLower(rawUrl)
+	}
+
+	return strings.ToLower(u.Host)
+}
+
+func describeUrl(rawUrl string) string {
+	if rawUrl == "" {
+		return "the configured base url"
+	}
+
+	return rawUrl
+}
+
+// Returns true for api keys read from a file, from the output of a command or from the encrypted store: a bundle could
+// otherwise run a command, or send a local file or secret to the url of its choosing.
+func untrustedKey(key string) bool {
+	for _, prefix := range []string{secret.PrefixCmd, secret.PrefixFile, secret.PrefixEncrypted} {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the first name-imported, name-imported-2... which is not used by a profile of the endpoint.
+func freeName(repo Repository, endpointName string, name string) (string, error) {
+	for i := 1; i < 100; i++ {
+		candidate := name + "-imported"
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d", candidate, i)
+		}
+
+		if _, err := repo.ReadRaw(candidate, endpointName); err != nil {
+			return candidate, nil
+		}
+	}
+
+	return "", errors.New("unable to find a free name to import profile " + name)
+}
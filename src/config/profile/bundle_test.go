@@ -0,0 +1,181 @@
+package profile_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/app"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+)
+
+func newRuntime(t *testing.T) *app.Runtime {
+	t.Helper()
+
+	rt, err := app.NewMemory(map[string]string{"ApiKey": "sk-test"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt.Install()
+	return rt
+}
+
+func bundleOf(profiles ...map[string]any) (b profile.Bundle) {
+	b.Version = 1
+	for _, p := range profiles {
+		raw, _ := json.Marshal(p)
+		b.Profiles = append(b.Profiles, profile.BundleProfile{Endpoint: "chat", Name: p["ProfileName"].(string), Profile: raw})
+	}
+
+	return
+}
+
+func readStored(t *testing.T, rt *app.Runtime, name string) (m map[string]any) {
+	t.Helper()
+
+	raw, err := rt.Repository.ReadRaw(name, "chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = json.Unmarshal(raw, &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func importOne(t *testing.T, rt *app.Runtime, b profile.Bundle, strategy string, trusted bool) profile.ImportResult {
+	t.Helper()
+
+	results, err := profile.Import(rt.Repository, b, strategy, trusted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("expected a single result, got %d", len(results))
+	}
+
+	return results[0]
+}
+
+func TestImportDropsRoutingSettings(t *testing.T) {
+	routed := map[string]any{
+		"ProfileName": "routed",
+		"Url":         "https://attacker.example/v1",
+		"Adapter":     "azure",
+		"AuthScheme":  "header",
+		"AuthHeader":  "X-Key",
+	}
+
+	rt := newRuntime(t)
+	r := importOne(t, rt, bundleOf(routed), profile.ConflictSkip, false)
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+
+	stored := readStored(t, rt, "routed")
+	for _, setting := range []string{"Url", "Adapter", "AuthScheme", "AuthHeader"} {
+		if v, ok := stored[setting]; ok && v != "" {
+			t.Errorf("expected %s to be dropped from an untrusted bundle, got %v", setting, v)
+		}
+	}
+
+	if len(r.Warnings) != 4 {
+		t.Errorf("expected a warning for every dropped setting, got: %v", r.Warnings)
+	}
+
+	rt = newRuntime(t)
+	r = importOne(t, rt, bundleOf(routed), profile.ConflictSkip, true)
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+
+	stored = readStored(t, rt, "routed")
+	if stored["Url"] != "https://attacker.example/v1" || stored["AuthHeader"] != "X-Key" {
+		t.Errorf("expected the routing settings of a trusted bundle to be kept, got: %v", stored)
+	}
+}
+
+func TestImportRefusesOverwriteToAnotherHost(t *testing.T) {
+	rt := newRuntime(t)
+	e, err := rt.Registry.Get("chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = rt.Repository.Create(e, "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	moved := map[string]any{"ProfileName": "work", "Url": "https://attacker.example/v1"}
+	r := importOne(t, rt, bundleOf(moved), profile.ConflictOverwrite, true)
+	if r.Err == nil || r.Action != "failed" {
+		t.Fatalf("expected the overwrite to be refused, got action %s", r.Action)
+	}
+
+	if url, _ := readStored(t, rt, "work")["Url"].(string); url != "" {
+		t.Error("expected the stored profile to be left unchanged")
+	}
+
+	same := map[string]any{"ProfileName": "work", "MessageHistory": true}
+	r = importOne(t, rt, bundleOf(same), profile.ConflictOverwrite, false)
+	if r.Err != nil || r.Action != "overwritten" {
+		t.Fatalf("expected a profile using the same host to be overwritten, got %s: %v", r.Action, r.Err)
+	}
+
+	r = importOne(t, rt, bundleOf(moved), profile.ConflictRename, true)
+	if r.Err != nil || r.NewName != "work-imported" {
+		t.Errorf("expected the profile to be imported under another name, got %s: %v", r.Action, r.Err)
+	}
+}
+
+func TestImportDropsUntrustedApiKeys(t *testing.T) {
+	tests := []struct {
+		key     string
+		dropped bool
+	}{
+		{"cmd:cat ~/.ssh/id_rsa", true},
+		{"file:/etc/passwd", true},
+		{"encrypted:work", true},
+		{"env:TEAM_OPENAI_KEY", false},
+		{"sk-plaintext", false},
+	}
+
+	for _, test := range tests {
+		rt := newRuntime(t)
+		r := importOne(t, rt, bundleOf(map[string]any{"ProfileName": "keyed", "ApiKey": test.key}), profile.ConflictSkip, false)
+		if r.Err != nil {
+			t.Fatalf("%s: %s", test.key, r.Err)
+		}
+
+		key, _ := readStored(t, rt, "keyed")["ApiKey"].(string)
+		if test.dropped && (key != "" || len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0], "ApiKey")) {
+			t.Errorf("%s: expected the key to be dropped with a warning, got key %q and warnings %v", test.key, key, r.Warnings)
+		}
+
+		if !test.dropped && key != test.key {
+			t.Errorf("%s: expected the key to be kept, got %q", test.key, key)
+		}
+	}
+}
+
+func TestImportRefusesPathNames(t *testing.T) {
+	for _, name := range []string{"..", ".", "../settings", `a\b`, ""} {
+		rt := newRuntime(t)
+		r := importOne(t, rt, bundleOf(map[string]any{"ProfileName": name}), profile.ConflictSkip, false)
+		if r.Err == nil {
+			t.Errorf("expected the name %q to be refused", name)
+		}
+	}
+
+	rt := newRuntime(t)
+	r := importOne(t, rt, bundleOf(map[string]any{"ProfileName": "child", "Extends": "../parent"}), profile.ConflictSkip, false)
+	if r.Err == nil {
+		t.Error("expected a parent outside of the endpoint to be refused")
+	}
+}
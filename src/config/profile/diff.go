@@ -0,0 +1,105 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const (
+	ChangeAdded    = "+"
+	ChangeRemoved  = "-"
+	ChangeModified = "~"
+)
+
+// Describes a setting which differs between two profiles.
+type Change struct {
+	Kind string
+	Path string
+	From any `json:",omitempty"`
+	To   any `json:",omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return c.Kind + " " + c.Path + ": " + formatValue(c.To)
+	case ChangeRemoved:
+		return c.Kind + " " + c.Path + ": " + formatValue(c.From)
+	}
+
+	return c.Kind + " " + c.Path + ": " + formatValue(c.From) + " -> " + formatValue(c.To)
+}
+
+func formatValue(v any) string {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(buf)
+}
+
+// Returns the structural differences between two json documents: objects are compared key by key and arrays element
+// by element, changes are sorted by path.
+func Diff(from []byte, to []byte) (changes []Change, err error) {
+	var a, b any
+	err = json.Unmarshal(from, &a)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(to, &b)
+	if err != nil {
+		return
+	}
+
+	diffNode(a, b, "", &changes)
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return
+}
+
+func diffNode(a any, b any, path string, changes *[]Change) {
+	am, aok := a.(map[string]any)
+	bm, bok := b.(map[string]any)
+	if aok && bok {
+		for k, av := range am {
+			if bv, found := bm[k]; found {
+				diffNode(av, bv, joinPath(path, k), changes)
+			} else {
+				*changes = append(*changes, Change{Kind: ChangeRemoved, Path: joinPath(path, k), From: av})
+			}
+		}
+
+		for k, bv := range bm {
+			if _, found := am[k]; !found {
+				*changes = append(*changes, Change{Kind: ChangeAdded, Path: joinPath(path, k), To: bv})
+			}
+		}
+
+		return
+	}
+
+	as, aok := a.([]any)
+	bs, bok := b.([]any)
+	if aok && bok {
+		for i := 0; i < len(as) || i < len(bs); i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(bs):
+				*changes = append(*changes, Change{Kind: ChangeRemoved, Path: p, From: as[i]})
+			case i >= len(as):
+				*changes = append(*changes, Change{Kind: ChangeAdded, Path: p, To: bs[i]})
+			default:
+				diffNode(as[i], bs[i], p, changes)
+			}
+		}
+
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Kind: ChangeModified, Path: path, From: a, To: b})
+	}
+}
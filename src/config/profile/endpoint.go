@@ -18,22 +18,31 @@ type Endpoint interface {
 
 
 // Collects endpoints added at runtime and allows them to be exposed to client code through endpoint.EndpointRegistry
-type endpointRegistry struct {
+// Other registries can be built, for instance to run commands against a subset of the endpoints.
+type Registry struct {
     Endpoints []Endpoint
 }
 
 // The endpoint.EndpointRegistry is to be used by different endpoints that want to be callable by client code.
 // The adding of profiles to the EndpointRegistry should be done in init() functions within the endpoints' respective packages.
 // They should include an implementation of an Endpoint type and then call endpoint.EndpointRegistry.Add(theirImplementation).
-var EndpointRegistry endpointRegistry
+var EndpointRegistry Registry
 
 
 // Add Endpoint to list of Endpoints that will be recognized at runtime
-func (r *endpointRegistry) Add(e Endpoint) {
+// An endpoint added twice replaces the one of the same name.
+func (r *Registry) Add(e Endpoint) {
+    for i, endpoint := range r.Endpoints {
+        if endpoint.Name() == e.Name() {
+            r.Endpoints[i] = e
+            return
+        }
+    }
+
     r.Endpoints = append(r.Endpoints, e)
 }
 
-func (r endpointRegistry) List() (s []string, err error) {
+func (r Registry) List() (s []string, err error) {
     if len(r.Endpoints) == 0 {
         err = errors.New("No endpoints to list") 
         return
@@ -46,7 +55,7 @@ func (r endpointRegistry) List() (s []string, err error) {
     return
 }
 
-func (r endpointRegistry) Get(name string) (e Endpoint, err error) {
+func (r Registry) Get(name string) (e Endpoint, err error) {
     for _, endpoint := range r.Endpoints {
        if endpoint.Name() == name {
             e = endpoint
@@ -0,0 +1,192 @@
+package profile
+
+// Profiles can extend a parent profile of the same endpoint by naming it in their Extends option. A profile extending
+// another only stores the settings which differ from its parent, and is resolved by deep merging it over its ancestors:
+// objects are merged key by key, while other values, arrays included, replace the parent's. Settings the profile removes
+// or clears, which are left out of its json, are stored as null so that they are not inherited again.
+// Resolution works on the json of profiles so that it applies to every Endpoint, repositories call it when reading
+// and writing profiles.
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+const extendsKey string = "Extends"
+const profileNameKey string = "ProfileName"
+
+// Reads the profile stored under a name, as stored: without the settings of its ancestors.
+type RawReader func(name string) ([]byte, error)
+
+// Returns a profile with the settings of its ancestors merged in. Inheritance cycles are reported as errors.
+func Resolve(read RawReader, name string) (buf []byte, err error) {
+	raw, err := read(name)
+	if err != nil {
+		return
+	}
+
+	return ResolveRaw(read, name, raw)
+}
+
+// Resolves a profile from its stored json, which may not have been stored yet.
+func ResolveRaw(read RawReader, name string, raw []byte) (buf []byte, err error) {
+	m, err := decode(raw)
+	if err != nil || parentName(m) == "" {
+		return raw, err
+	}
+
+	m, err = resolve(read, name, m, nil)
+	if err != nil {
+		return
+	}
+
+	return json.Marshal(m)
+}
+
+// Returns the overlay of a profile to store: when the profile extends another, only the settings which differ from its
+// resolved parent are kept, along with its name and parent.
+func Overlay(read RawReader, name string, buf []byte) ([]byte, error) {
+	m, err := decode(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := parentName(m)
+	if parent == "" {
+		return buf, nil
+	}
+
+	pm, err := resolveName(read, parent, []string{name})
+	if err != nil {
+		return nil, err
+	}
+
+	overlay := diff(m, pm)
+	overlay[profileNameKey] = name
+	overlay[extendsKey] = parent
+
+	return json.Marshal(overlay)
+}
+
+// Returns the parent of a stored profile, or an empty string when it does not extend another profile.
+func Parent(raw []byte) string {
+	m, err := decode(raw)
+	if err != nil {
+		return ""
+	}
+
+	return parentName(m)
+}
+
+func resolveName(read RawReader, name string, chain []string) (m map[string]any, err error) {
+	for _, n := range chain {
+		if n == name {
+			err = errors.New("profile inheritance cycle: " + strings.Join(append(chain, name), " -> "))
+			return
+		}
+	}
+
+	raw, err := read(name)
+	if err != nil {
+		err = errors.New("unable to read profile " + name + ": " + err.Error())
+		return
+	}
+
+	m, err = decode(raw)
+	if err != nil {
+		return
+	}
+
+	return resolve(read, name, m, chain)
+}
+
+func resolve(read RawReader, name string, m map[string]any, chain []string) (map[string]any, error) {
+	parent := parentName(m)
+	if parent == "" {
+		return m, nil
+	}
+
+	pm, err := resolveName(read, parent, append(chain, name))
+	if err != nil {
+		return nil, err
+	}
+
+	merged := merge(pm, m)
+	merged[profileNameKey] = name
+	return merged, nil
+}
+
+func decode(buf []byte) (m map[string]any, err error) {
+	err = json.Unmarshal(buf, &m)
+	if err == nil && m == nil {
+		m = make(map[string]any)
+	}
+
+	return
+}
+
+func parentName(m map[string]any) string {
+	parent, _ := m[extendsKey].(string)
+	return parent
+}
+
+// Deep merges child over parent, null values of child remove the settings of parent.
+func merge(parent map[string]any, child map[string]any) map[string]any {
+	merged := make(map[string]any, len(parent))
+	for k, v := range parent {
+		merged[k] = v
+	}
+
+	for k, v := range child {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+
+		pv, pok := merged[k].(map[string]any)
+		cv, cok := v.(map[string]any)
+		if pok && cok {
+			merged[k] = merge(pv, cv)
+		} else if cok {
+			// Null values left by settings the parent no longer has
+			merged[k] = merge(nil, cv)
+		} else {
+			merged[k] = v
+		}
+	}
+
+	return merged
+}
+
+// Returns the settings of child which differ from parent, objects are compared key by key. Settings of parent which
+// child does not have are null, as they are left out of the json of profiles once cleared.
+func diff(child map[string]any, parent map[string]any) map[string]any {
+	d := make(map[string]any)
+	for k, pv := range parent {
+		if _, found := child[k]; !found && pv != nil {
+			d[k] = nil
+		}
+	}
+
+	for k, v := range child {
+		pv, found := parent[k]
+		if !found {
+			d[k] = v
+			continue
+		}
+
+		pm, pok := pv.(map[string]any)
+		cm, cok := v.(map[string]any)
+		if pok && cok {
+			if sub := diff(cm, pm); len(sub) > 0 {
+				d[k] = sub
+			}
+		} else if !reflect.DeepEqual(v, pv) {
+			d[k] = v
+		}
+	}
+
+	return d
+}
@@ -0,0 +1,109 @@
+package profile_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/config/profile"
+)
+
+// Stores overlays the way repositories do, and resolves the profiles back.
+type profiles map[string][]byte
+
+func (ps profiles) read(name string) ([]byte, error) {
+	raw, ok := ps[name]
+	if !ok {
+		return nil, errors.New("profile " + name + " does not exist")
+	}
+
+	return raw, nil
+}
+
+func (ps profiles) store(t *testing.T, name string, m map[string]any) {
+	t.Helper()
+
+	buf, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ps[name], err = profile.Overlay(ps.read, name, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (ps profiles) resolve(t *testing.T, name string) (m map[string]any) {
+	t.Helper()
+
+	buf, err := profile.Resolve(ps.read, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = json.Unmarshal(buf, &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func TestOverlayKeepsClearedSettings(t *testing.T) {
+	ps := profiles{}
+	ps.store(t, "base", map[string]any{
+		"ProfileName": "base",
+		"ApiKey":      "env:TEAM_KEY",
+		"Headers":     map[string]any{"X-Team": "research", "X-Env": "prod"},
+		"Url":         "https://proxy.example/v1",
+	})
+
+	// Profiles are marshaled from their structs, cleared settings with omitempty are left out
+	ps.store(t, "child", map[string]any{
+		"ProfileName": "child",
+		"Extends":     "base",
+		"Headers":     map[string]any{"X-Team": "research"},
+		"Url":         "",
+	})
+
+	resolved := ps.resolve(t, "child")
+	expected := map[string]any{
+		"ProfileName": "child",
+		"Extends":     "base",
+		"Headers":     map[string]any{"X-Team": "research"},
+		"Url":         "",
+	}
+
+	if !reflect.DeepEqual(resolved, expected) {
+		t.Errorf("expected the cleared settings to stay cleared, got: %v", resolved)
+	}
+
+	// Settings the child does not override are still inherited
+	ps.store(t, "base", map[string]any{
+		"ProfileName": "base",
+		"ApiKey":      "env:TEAM_KEY",
+		"Headers":     map[string]any{"X-Team": "research", "X-Env": "prod"},
+		"Url":         "https://proxy.example/v1",
+		"Cache":       true,
+	})
+
+	if resolved := ps.resolve(t, "child"); resolved["Cache"] != true || resolved["ApiKey"] != nil {
+		t.Errorf("expected new settings of the parent to be inherited, and the api key to stay cleared, got: %v", resolved)
+	}
+}
+
+func TestOverlayWithoutParentSetting(t *testing.T) {
+	ps := profiles{
+		"base":  []byte(`{"ProfileName": "base"}`),
+		"child": []byte(`{"ProfileName": "child", "Extends": "base", "Headers": {"X-Env": null, "X-Team": "research"}, "ApiKey": null}`),
+	}
+
+	// Settings cleared by the child which the parent no longer has leave no null behind
+	resolved := ps.resolve(t, "child")
+	expected := map[string]any{"ProfileName": "child", "Extends": "base", "Headers": map[string]any{"X-Team": "research"}}
+	if !reflect.DeepEqual(resolved, expected) {
+		t.Errorf("expected no null settings, got: %v", resolved)
+	}
+}
@@ -0,0 +1,22 @@
+package profile
+
+// Options are settings shared by the profiles of every endpoint.
+// Endpoint profiles embed them, so they are stored at the top level of a profile's json along with the endpoint's own settings.
+type Options struct {
+	Extends      string            `json:",omitempty"` // Parent profile of the same endpoint, whose settings are inherited
+	Cache        bool              // Responses to deterministic requests made with the profile are cached on disk
+	ApiKey       string            `json:",omitempty"` // Plaintext key, or a reference to a secret (env:, file:, cmd:, encrypted:), used instead of the configured one
+	Organization string            `json:",omitempty"` // Sent in the OpenAI-Organization header instead of the configured organization
+	Project      string            `json:",omitempty"` // Sent in the OpenAI-Project header instead of the configured project
+	Headers      map[string]string `json:",omitempty"` // Extra headers, added to the configured ones
+	Adapter      string            `json:",omitempty"` // Routing of requests (openai, azure) instead of the configured adapter
+	AuthScheme   string            `json:",omitempty"` // Authentication of requests (bearer, api-key, header, none) instead of the configured scheme
+	AuthHeader   string            `json:",omitempty"` // Header holding the api key with the header scheme
+	ApiVersion   string            `json:",omitempty"` // api-version query parameter sent to Azure
+	Deployment   string            `json:",omitempty"` // Azure deployment, the model of the request is used when empty
+}
+
+// Promoted to the profiles embedding Options, which allows code working with any profile to read them.
+func (o Options) ProfileOptions() Options {
+	return o
+}
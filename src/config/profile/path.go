@@ -0,0 +1,286 @@
+package profile
+
+// Paths address a setting within the json of a profile, using dots between object keys and brackets for array indexes,
+// ex: CreateCompletionBody.messages[0].content. A leading "$." is accepted, and indexes can also be written as keys.
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type pathSegment struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+func parsePath(path string) (segments []pathSegment, err error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		err = errors.New("empty path")
+		return
+	}
+
+	for _, part := range strings.Split(path, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key == "" && rest == "" {
+			err = errors.New("invalid path " + path + ", keys can not be empty")
+			return
+		}
+
+		if key != "" {
+			if i, convErr := strconv.Atoi(key); convErr == nil {
+				segments = append(segments, pathSegment{key: key, index: i, isIdx: true})
+			} else {
+				segments = append(segments, pathSegment{key: key})
+			}
+		}
+
+		for rest != "" {
+			var idx string
+			var found bool
+			idx, rest, found = strings.Cut(rest, "]")
+			i, convErr := strconv.Atoi(idx)
+			if !found || convErr != nil || i < 0 || (rest != "" && !strings.HasPrefix(rest, "[")) {
+				err = errors.New("invalid path " + path + ", indexes must be written as [n]")
+				return
+			}
+
+			segments = append(segments, pathSegment{key: idx, index: i, isIdx: true})
+			rest = strings.TrimPrefix(rest, "[")
+		}
+	}
+
+	return
+}
+
+// Parses a value given on the command line as the type the schema of the endpoint's profiles has at the path, so that
+// ex: 123 is a string for CreateCompletionBody.user and true a stop sequence for CreateCompletionBody.stop. Strings are
+// taken as is unless quoted, a scalar given for an array is its single element, and null clears nullable settings.
+// Values of paths the schema does not describe are decoded as json when they are json, taken as strings otherwise.
+func ParseValue(e Endpoint, path string, raw string) (value any, err error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return
+	}
+
+	s := Schema(e)
+	for _, segment := range segments {
+		s = childSchema(s, segment)
+	}
+
+	value, err = parseAs(s, raw)
+	if err != nil {
+		err = errors.New("invalid value for " + path + ": " + err.Error())
+	}
+
+	return
+}
+
+// Returns the schema of the values under a segment, empty when the schema does not describe them.
+func childSchema(s JsonSchema, segment pathSegment) JsonSchema {
+	if properties, ok := s["properties"].(map[string]any); ok {
+		if ps, ok := properties[segment.key].(JsonSchema); ok {
+			return ps
+		}
+	}
+
+	if additional, ok := s["additionalProperties"].(JsonSchema); ok {
+		return additional
+	}
+
+	if items, ok := s["items"].(JsonSchema); ok && segment.isIdx {
+		return items
+	}
+
+	return JsonSchema{}
+}
+
+func parseAs(s JsonSchema, raw string) (value any, err error) {
+	types := map[string]bool{}
+	switch t := s["type"].(type) {
+	case string:
+		types[t] = true
+	case []any:
+		for _, typ := range t {
+			name, _ := typ.(string)
+			types[name] = true
+		}
+	}
+
+	if len(types) == 0 {
+		if json.Unmarshal([]byte(raw), &value) != nil {
+			value = raw
+		}
+
+		return value, nil
+	}
+
+	if raw == "null" && types["null"] {
+		return nil, nil
+	}
+
+	switch {
+	case types["string"]:
+		if strings.HasPrefix(raw, `"`) && json.Unmarshal([]byte(raw), &value) == nil {
+			return
+		}
+
+		return raw, nil
+	case types["boolean"]:
+		value, err = strconv.ParseBool(raw)
+		if err != nil {
+			err = errors.New("expected a boolean, got " + raw)
+		}
+	case types["integer"]:
+		value, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			err = errors.New("expected an integer, got " + raw)
+		}
+	case types["number"]:
+		value, err = strconv.ParseFloat(raw, 64)
+		if err != nil {
+			err = errors.New("expected a number, got " + raw)
+		}
+	case types["array"]:
+		var values []any
+		if strings.HasPrefix(strings.TrimSpace(raw), "[") && json.Unmarshal([]byte(raw), &values) == nil {
+			return values, nil
+		}
+
+		items, _ := s["items"].(JsonSchema)
+		value, err = parseAs(items, raw)
+		if err == nil {
+			value = []any{value}
+		}
+	case types["object"]:
+		var m map[string]any
+		err = json.Unmarshal([]byte(raw), &m)
+		if err != nil {
+			err = errors.New("expected a json object, got " + raw)
+		}
+
+		value = m
+	}
+
+	return
+}
+
+// Returns the value at a path of a decoded json document.
+func GetPath(doc any, path string) (value any, ok bool, err error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return
+	}
+
+	value = doc
+	for _, s := range segments {
+		value, ok = child(value, s)
+		if !ok {
+			return nil, false, nil
+		}
+	}
+
+	return value, true, nil
+}
+
+// Sets the value at a path of a decoded json document. Missing objects along the path are created, and an index equal
+// to the length of an array appends to it.
+func SetPath(doc map[string]any, path string, value any) (err error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return
+	}
+
+	_, err = setIn(doc, segments, value, path)
+	return
+}
+
+// Removes the value at a path of a decoded json document, removing a missing value is not an error.
+func UnsetPath(doc map[string]any, path string) (err error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return
+	}
+
+	parent := any(doc)
+	for _, s := range segments[:len(segments)-1] {
+		var ok bool
+		parent, ok = child(parent, s)
+		if !ok {
+			return
+		}
+	}
+
+	last := segments[len(segments)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		delete(p, last.key)
+	case []any:
+		if last.isIdx && last.index < len(p) {
+			err = errors.New("unable to unset " + path + ", array elements can not be removed, set the array instead")
+		}
+	}
+
+	return
+}
+
+func child(node any, s pathSegment) (any, bool) {
+	switch n := node.(type) {
+	case map[string]any:
+		v, ok := n[s.key]
+		return v, ok
+	case []any:
+		if s.isIdx && s.index < len(n) {
+			return n[s.index], true
+		}
+	}
+
+	return nil, false
+}
+
+// Sets value under node, returning the node, which is a new slice when an array was appended to.
+func setIn(node any, segments []pathSegment, value any, path string) (any, error) {
+	if len(segments) == 0 {
+		return value, nil
+	}
+
+	s := segments[0]
+	switch n := node.(type) {
+	case map[string]any:
+		next, ok := n[s.key]
+		if !ok {
+			next = make(map[string]any)
+		}
+
+		v, err := setIn(next, segments[1:], value, path)
+		if err != nil {
+			return nil, err
+		}
+
+		n[s.key] = v
+		return n, nil
+	case []any:
+		if !s.isIdx || s.index > len(n) {
+			return nil, errors.New("unable to set " + path + ", " + s.key + " is not an index of an array of length " + strconv.Itoa(len(n)))
+		}
+
+		if s.index == len(n) {
+			n = append(n, make(map[string]any))
+		}
+
+		v, err := setIn(n[s.index], segments[1:], value, path)
+		if err != nil {
+			return nil, err
+		}
+
+		n[s.index] = v
+		return n, nil
+	case nil:
+		return setIn(make(map[string]any), segments, value, path)
+	}
+
+	return nil, errors.New("unable to set " + path + ", " + s.key + " is not within an object or array")
+}
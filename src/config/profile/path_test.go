@@ -0,0 +1,62 @@
+package profile_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/config/profile"
+)
+
+func TestParseValue(t *testing.T) {
+	rt := newRuntime(t)
+	e, err := rt.Registry.Get("chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		raw      string
+		expected any
+	}{
+		{"CreateCompletionBody.user", "123", "123"},
+		{"CreateCompletionBody.user", `"quoted"`, "quoted"},
+		{"CreateCompletionBody.stop", "true", []any{"true"}},
+		{"CreateCompletionBody.stop", `["a", "b"]`, []any{"a", "b"}},
+		{"CreateCompletionBody.temperature", "0.2", 0.2},
+		{"CreateCompletionBody.temperature", "null", nil},
+		{"CreateCompletionBody.max_tokens", "256", int64(256)},
+		{"CreateCompletionBody.messages[0].content", "42", "42"},
+		{"MessageHistory", "true", true},
+		{"Headers.X-Team", "1", "1"},
+		{"Headers", `{"X-Team": "research"}`, map[string]any{"X-Team": "research"}},
+		// Paths the schema does not describe are left to validation
+		{"Unknown", "12", float64(12)},
+		{"Unknown", "text", "text"},
+	}
+
+	for _, test := range tests {
+		value, err := profile.ParseValue(e, test.path, test.raw)
+		if err != nil {
+			t.Errorf("%s %s: %s", test.path, test.raw, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(value, test.expected) {
+			t.Errorf("%s %s: expected %#v, got %#v", test.path, test.raw, test.expected, value)
+		}
+	}
+
+	for _, invalid := range []struct{ path, raw string }{
+		{"MessageHistory", "yes please"},
+		{"CreateCompletionBody.max_tokens", "0.5"},
+		{"CreateCompletionBody.temperature", "warm"},
+		{"Headers", "X-Team"},
+		{"MessageHistory", "null"},
+	} {
+		_, err := profile.ParseValue(e, invalid.path, invalid.raw)
+		if err == nil {
+			t.Errorf("%s %s: expected an error", invalid.path, invalid.raw)
+		}
+	}
+}
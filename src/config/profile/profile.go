@@ -1,20 +1,55 @@
 package profile
 
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
 type Repository interface {
 	// Disk operations
 	Create(endpoint Endpoint, profileName string) error
+	// Read returns profiles resolved with the settings of the profiles they extend, ReadRaw returns them as stored
 	Read(name string, endpointName string) ([]byte, error)
+	ReadRaw(name string, endpointName string) ([]byte, error)
 	Update(Profile) error
 	Delete(endpointName string, profileName string) error
 	GetAll(endpointName string) ([]string, error)
+	Copy(endpointName string, profileName string, newName string) error
+	Rename(endpointName string, profileName string, newName string) error
+
+	// Every update of a profile is kept as a numbered version, the latest version being the current profile
+	History(endpointName string, profileName string) ([]Version, error)
+	ReadVersion(endpointName string, profileName string, version int) ([]byte, error)
+
+	// Locks a profile against changes by other invocations and goroutines, for read-modify-write cycles. Locks are not
+	// reentrant: Update takes the lock itself, callers holding it update the profile with UpdateLocked.
+	Lock(endpointName string, profileName string) (unlock func(), err error)
+	UpdateLocked(Profile) error
+}
+
+type Version struct {
+	Version int
+	Time    time.Time
+	Size    int64
 }
 
 type Profile interface {
 	Name() string
 	Endpoint() Endpoint
     OverrideUrl() string
+	ProfileOptions() Options
 	ProfileRepository() Repository
 	SetName(string) Profile
 }
 
 var RuntimeRepository Repository
+
+// Profile names end up in paths, they may not be empty, hold separators or refer to a folder and its parent.
+func CheckName(name string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return errors.New("invalid profile name " + name)
+	}
+
+	return nil
+}
@@ -0,0 +1,248 @@
+package profile
+
+// The json schema of an endpoint's profiles is generated from the type of its default profile, so that it follows the
+// profile structs without being maintained separately. Only the subset of json schema needed to describe the structs
+// is generated and validated: types, properties, additional properties and items.
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type JsonSchema map[string]any
+
+const schemaDraft string = "https://json-schema.org/draft/2020-12/schema"
+
+// Returns the json schema of the profiles of an endpoint.
+func Schema(e Endpoint) JsonSchema {
+	s := typeSchema(reflect.TypeOf(e.DefaultProfile()))
+	s["$schema"] = schemaDraft
+	s["title"] = e.Name() + " profile"
+	return s
+}
+
+func typeSchema(t reflect.Type) JsonSchema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := typeSchema(t.Elem())
+		if typ, ok := s["type"].(string); ok {
+			s["type"] = []any{typ, "null"}
+		}
+		return s
+	case reflect.Interface:
+		return JsonSchema{}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		addFields(t, properties)
+		return JsonSchema{"type": "object", "properties": properties, "additionalProperties": false}
+	case reflect.Map:
+		return JsonSchema{"type": []any{"object", "null"}, "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return JsonSchema{"type": []any{"array", "null"}, "items": typeSchema(t.Elem())}
+	case reflect.String:
+		return JsonSchema{"type": "string"}
+	case reflect.Bool:
+		return JsonSchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return JsonSchema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return JsonSchema{"type": "number"}
+	}
+
+	return JsonSchema{}
+}
+
+// Adds the properties of the fields of a struct, following the naming rules of encoding/json: embedded structs without
+// a json name are flattened.
+func addFields(t reflect.Type, properties map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			addFields(f.Type, properties)
+			continue
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		properties[name] = typeSchema(f.Type)
+	}
+}
+
+// Describes a setting of a profile which is not valid.
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+
+	return e.Path + ": " + e.Message
+}
+
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		lines[i] = e.Error()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Returns the settings of a decoded json document which do not follow a schema.
+func (s JsonSchema) Validate(doc any) (errs ValidationErrors) {
+	validateNode(s, doc, "", &errs)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return
+}
+
+func validateNode(s JsonSchema, value any, path string, errs *ValidationErrors) {
+	if !typeMatches(s["type"], value) {
+		*errs = append(*errs, ValidationError{path, fmt.Sprintf("expected %s, got %s", describeType(s["type"]), jsonType(value))})
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := s["properties"].(map[string]any)
+		for k, child := range v {
+			childPath := joinPath(path, k)
+			if ps, ok := properties[k].(JsonSchema); ok {
+				validateNode(ps, child, childPath, errs)
+			} else if additional, ok := s["additionalProperties"].(JsonSchema); ok {
+				validateNode(additional, child, childPath, errs)
+			} else if s["additionalProperties"] == false {
+				*errs = append(*errs, ValidationError{childPath, "unknown setting" + suggestion(k, properties)})
+			}
+		}
+	case []any:
+		if items, ok := s["items"].(JsonSchema); ok {
+			for i, item := range v {
+				validateNode(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func typeMatches(expected any, value any) bool {
+	switch t := expected.(type) {
+	case nil:
+		return true
+	case string:
+		return jsonType(value) == t || (t == "number" && jsonType(value) == "integer")
+	case []any:
+		for _, typ := range t {
+			if typeMatches(typ, value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func describeType(expected any) string {
+	if types, ok := expected.([]any); ok {
+		names := make([]string, len(types))
+		for i, t := range types {
+			names[i] = fmt.Sprint(t)
+		}
+		return strings.Join(names, " or ")
+	}
+
+	return fmt.Sprint(expected)
+}
+
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
+
+// Suggests the closest known setting, so that typos such as temprature are easy to fix.
+func suggestion(key string, properties map[string]any) string {
+	best, bestDistance := "", len(key)/2+1
+	for name := range properties {
+		if d := distance(strings.ToLower(key), strings.ToLower(name)); d < bestDistance || (d == bestDistance && best != "" && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+
+	return ", did you mean " + best + "?"
+}
+
+// Levenshtein distance between two strings.
+func distance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
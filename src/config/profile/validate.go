@@ -0,0 +1,80 @@
+package profile
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Endpoints implementing Validator check the settings of their profiles beyond their schema, such as allowed values.
+type Validator interface {
+	ValidateProfile(p Profile) ValidationErrors
+}
+
+// Returns the profile of a json buffer once it is validated against the schema of its endpoint, then by the endpoint
+// itself when it implements Validator. Errors are ValidationErrors, listing every invalid setting with its path.
+func Validate(e Endpoint, buf []byte) (p Profile, err error) {
+	var doc any
+	err = json.Unmarshal(buf, &doc)
+	if err != nil {
+		err = ValidationErrors{{Message: "invalid json: " + err.Error()}}
+		return
+	}
+
+	if errs := Schema(e).Validate(doc); len(errs) > 0 {
+		err = errs
+		return
+	}
+
+	p, err = e.ProfileFromJsonBuf(buf)
+	if err != nil {
+		return
+	}
+
+	if v, ok := e.(Validator); ok {
+		if errs := v.ValidateProfile(p); len(errs) > 0 {
+			err = errs
+		}
+	}
+
+	return
+}
+
+// Adds an error when a value is not one of the allowed ones. Empty values are left to the API defaults.
+func (errs *ValidationErrors) CheckAllowed(path string, value string, allowed []string) {
+	if value == "" {
+		return
+	}
+
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+
+	*errs = append(*errs, ValidationError{path, "invalid value " + value + ", allowed values are: " + strings.Join(allowed, ", ")})
+}
+
+// Adds an error when a number is out of a range.
+func (errs *ValidationErrors) CheckRange(path string, value *float64, min float64, max float64) {
+	if value != nil && (*value < min || *value > max) {
+		*errs = append(*errs, ValidationError{path, "must be between " + formatFloat(min) + " and " + formatFloat(max)})
+	}
+}
+
+func formatFloat(f float64) string {
+	buf, _ := json.Marshal(f)
+	return string(buf)
+}
+
+// Returns the values of the string fields of a struct of allowed values, such as audio.AllowedVoices.
+func AllowedValues(v any) (values []string) {
+	rv := reflect.ValueOf(v)
+	for i := 0; i < rv.NumField(); i++ {
+		if f := rv.Field(i); f.Kind() == reflect.String {
+			values = append(values, f.String())
+		}
+	}
+
+	return
+}
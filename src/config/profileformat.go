@@ -0,0 +1,37 @@
+package config
+
+import (
+	"github.com/ephex2/go-gpt-cli/config/format"
+)
+
+const profileFormatKeyName string = "ProfileFormat"
+
+// Format of the files of new profiles, existing profiles keep the format of their file.
+func ProfileFormat() string {
+	if value, ok := Lookup(profileFormatKeyName); ok && format.Check(value) == nil {
+		return value
+	}
+
+	return format.Json
+}
+
+func SetProfileFormat(f string) (err error) {
+	err = format.Check(f)
+	if err != nil {
+		return
+	}
+
+	err = storeSetting(profileFormatKeyName, f)
+	return
+}
+
+// Sets the format of the profiles created during this execution only, without changing the settings.
+func SetProfileFormatOverride(f string, flagName string) (err error) {
+	err = format.Check(f)
+	if err != nil {
+		return
+	}
+
+	SetFlagSetting(profileFormatKeyName, f, flagName)
+	return
+}
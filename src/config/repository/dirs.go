@@ -0,0 +1,230 @@
+package repository
+
+// Files are split between directories following the XDG base directory specification: settings and profiles in the
+// config directory, local data such as the usage ledger and the encrypted secrets in the data directory, and data which
+// can be rebuilt, such as cached responses, in the cache directory. GO_GPT_CLI_HOME places the three directories
+// under a single one, for CI and tests.
+//
+// The layout of the directories is versioned: version 1 kept every file in ~/.local/go-gpt-cli, version 2 is the split
+// layout. Files of an earlier layout are moved the first time the CLI runs.
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ephex2/go-gpt-cli/log"
+)
+
+// Environment variable overriding the directories of the CLI.
+const HomeEnv string = "GO_GPT_CLI_HOME"
+
+const appDirName string = "go-gpt-cli"
+
+// Version of the layout written by this version of the CLI, recorded in the config directory.
+const layoutVersion int = 2
+const layoutFileName string = "layout-version"
+
+// Files of the layout 1 which belong in the config directory, other files are moved to the data directory.
+var legacyConfigFiles = []string{"go-gpt-cli.json", "go-gpt-cli.yaml", "go-gpt-cli.yml", "go-gpt-cli.toml", "prices.json"}
+
+type Dirs struct {
+	Config string
+	Data   string
+	Cache  string
+}
+
+// Returns the directories of the CLI, from GO_GPT_CLI_HOME or the XDG base directories.
+func ResolveDirs() (dirs Dirs, err error) {
+	if home := os.Getenv(HomeEnv); home != "" {
+		home, err = filepath.Abs(home)
+		if err != nil {
+			return
+		}
+
+		return Dirs{
+			Config: filepath.Join(home, "config"),
+			Data:   filepath.Join(home, "data"),
+			Cache:  filepath.Join(home, "cache"),
+		}, nil
+	}
+
+	// $XDG_CONFIG_HOME and $XDG_CACHE_HOME on unix systems, their platform equivalent otherwise
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return
+	}
+
+	dataDir, err := userDataDir()
+	if err != nil {
+		return
+	}
+
+	return Dirs{
+		Config: filepath.Join(configDir, appDirName),
+		Data:   filepath.Join(dataDir, appDirName),
+		Cache:  filepath.Join(cacheDir, appDirName),
+	}, nil
+}
+
+// Go has no equivalent of os.UserConfigDir for data, platforms other than unix keep data along with the config.
+func userDataDir() (string, error) {
+	// Relative paths are invalid per the specification and are ignored
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "plan9":
+		return os.UserConfigDir()
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// Directory of the layout 1.
+func legacyDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".local", appDirName), nil
+}
+
+// Creates the directories, moving the files of an earlier layout into them, and records the layout version.
+func prepareDirs(dirs Dirs) (err error) {
+	for _, dir := range []string{dirs.Config, dirs.Data, dirs.Cache} {
+		err = os.MkdirAll(dir, 0750)
+		if err != nil {
+			return
+		}
+	}
+
+	layoutPath := filepath.Join(dirs.Config, layoutFileName)
+	buf, err := os.ReadFile(layoutPath)
+	if err == nil {
+		version, convErr := strconv.Atoi(strings.TrimSpace(string(buf)))
+		if convErr != nil {
+			return errors.New("invalid layout version in " + layoutPath)
+		}
+
+		if version > layoutVersion {
+			return errors.New("the files in " + dirs.Config + " were written by a newer version of go-gpt-cli, layout version " + strconv.Itoa(version))
+		}
+
+		return
+	}
+
+	if !os.IsNotExist(err) {
+		return
+	}
+
+	// Directories set with GO_GPT_CLI_HOME are self contained, files of the home directory are left alone
+	if os.Getenv(HomeEnv) == "" {
+		err = migrateLegacy(dirs)
+		if err != nil {
+			return
+		}
+	}
+
+	return os.WriteFile(layoutPath, []byte(strconv.Itoa(layoutVersion)+"\n"), 0600)
+}
+
+// Moves the files of the layout 1 to the config and data directories. Files which already exist in their new directory
+// are left in place.
+func migrateLegacy(dirs Dirs) (err error) {
+	legacy, err := legacyDir()
+	if err != nil {
+		return
+	}
+
+	entries, err := os.ReadDir(legacy)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		dst := filepath.Join(dirs.Data, entry.Name())
+		// Endpoint folders hold profiles
+		if entry.IsDir() || slices.Contains(legacyConfigFiles, entry.Name()) {
+			dst = filepath.Join(dirs.Config, entry.Name())
+		}
+
+		if _, statErr := os.Stat(dst); statErr == nil {
+			log.Warning("Not moving %s, %s already exists\n", filepath.Join(legacy, entry.Name()), dst)
+			continue
+		}
+
+		err = move(filepath.Join(legacy, entry.Name()), dst)
+		if err != nil {
+			return errors.New("unable to move " + filepath.Join(legacy, entry.Name()) + " to " + dst + ": " + err.Error())
+		}
+	}
+
+	// Only removed once empty
+	os.Remove(legacy)
+
+	log.Info("Moved settings and profiles from %s to %s, and local data to %s\n", legacy, dirs.Config, dirs.Data)
+	return
+}
+
+// Renames a file or directory, copying it when it is moved to another file system.
+func move(src string, dst string) (err error) {
+	if os.Rename(src, dst) == nil {
+		return
+	}
+
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(target, buf, info.Mode().Perm())
+	})
+
+	if err != nil {
+		return
+	}
+
+	return os.RemoveAll(src)
+}
@@ -0,0 +1,381 @@
+package repository
+
+// The memory repository keeps settings and profiles for the lifetime of the process, nothing is read from or written to
+// disk. It backs runtimes built for tests, see package app.
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+)
+
+// Memory implements both the ProfileRepository and ConfigRepository interfaces
+type Memory struct {
+	mu       sync.Mutex
+	settings map[string]string
+	profiles map[string]map[string][]byte // Raw profiles by endpoint, then by name
+	versions map[string]map[string][]memoryVersion
+	locks    map[string]*sync.Mutex
+}
+
+type memoryVersion struct {
+	profile.Version
+	raw []byte
+}
+
+// Returns an empty repository, holding the settings provided.
+func NewMemory(settings map[string]string) *Memory {
+	m := &Memory{
+		settings: make(map[string]string),
+		profiles: make(map[string]map[string][]byte),
+		versions: make(map[string]map[string][]memoryVersion),
+		locks:    make(map[string]*sync.Mutex),
+	}
+
+	for k, v := range settings {
+		m.settings[k] = v
+	}
+
+	return m
+}
+
+// ConfigRepository implementation
+func (m *Memory) Get() (c config.Config, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	settings := make(map[string]string)
+	for k, v := range m.settings {
+		settings[k] = v
+	}
+
+	c = config.Config{
+		Repository: m,
+		Settings:   settings,
+	}
+
+	return
+}
+
+// Settings are replaced by the ones of c, there are no other invocations to merge changes with.
+func (m *Memory) Set(c config.Config) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clear(m.settings)
+	for k, v := range c.Settings {
+		m.settings[k] = v
+	}
+
+	return
+}
+
+// Locks are only held within the process.
+func (m *Memory) Lock(endpointName string, profileName string) (unlock func(), err error) {
+	err = checkNames(endpointName, profileName)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	l, ok := m.locks[endpointName+"/"+profileName]
+	if !ok {
+		l = &sync.Mutex{}
+		m.locks[endpointName+"/"+profileName] = l
+	}
+	m.mu.Unlock()
+
+	l.Lock()
+	var once sync.Once
+	return func() { once.Do(l.Unlock) }, nil
+}
+
+// ProfileRepository implementation
+func (m *Memory) Create(endpoint profile.Endpoint, profileName string) (err error) {
+	err = checkNames(endpoint.Name(), profileName)
+	if err != nil {
+		return
+	}
+
+	p := endpoint.DefaultProfile()
+	p = p.SetName(profileName)
+
+	err = m.Update(p)
+	if err != nil {
+		return
+	}
+
+	err = config.SetDefaultProfile(endpoint.Name(), profileName, false)
+	return
+}
+
+func (m *Memory) Read(name string, endpointName string) (pBytes []byte, err error) {
+	return readValidated(m.rawReader(endpointName), name, endpointName)
+}
+
+func (m *Memory) ReadRaw(name string, endpointName string) (pBytes []byte, err error) {
+	err = checkNames(endpointName, name)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	raw, ok := m.profiles[endpointName][name]
+	if !ok {
+		err = errors.New("profile " + name + " of endpoint " + endpointName + " does not exist")
+		return
+	}
+
+	return append([]byte(nil), raw...), nil
+}
+
+func (m *Memory) rawReader(endpointName string) profile.RawReader {
+	return func(name string) ([]byte, error) {
+		return m.ReadRaw(name, endpointName)
+	}
+}
+
+// Profiles extending another one are stored as an overlay of their parent, as with the other repositories.
+func (m *Memory) Update(p profile.Profile) (err error) {
+	unlock, err := m.Lock(p.Endpoint().Name(), p.Name())
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	return m.UpdateLocked(p)
+}
+
+func (m *Memory) UpdateLocked(p profile.Profile) (err error) {
+	err = checkNames(p.Endpoint().Name(), p.Name())
+	if err != nil {
+		return
+	}
+
+	buf, err := json.Marshal(p)
+	if err != nil {
+		return
+	}
+
+	buf, err = profile.Overlay(m.rawReader(p.Endpoint().Name()), p.Name(), buf)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.write(p.Endpoint().Name(), p.Name(), buf)
+	return
+}
+
+// Stores a profile and its next version, m.mu must be held.
+func (m *Memory) write(endpointName string, profileName string, buf []byte) {
+	if m.profiles[endpointName] == nil {
+		m.profiles[endpointName] = make(map[string][]byte)
+		m.versions[endpointName] = make(map[string][]memoryVersion)
+	}
+
+	m.profiles[endpointName][profileName] = buf
+
+	versions := m.versions[endpointName][profileName]
+	next := 1
+	if len(versions) > 0 {
+		next = versions[len(versions)-1].Version.Version + 1
+	}
+
+	versions = append(versions, memoryVersion{
+		Version: profile.Version{Version: next, Time: time.Now(), Size: int64(len(buf))},
+		raw:     buf,
+	})
+
+	if len(versions) > maxProfileVersions {
+		versions = versions[len(versions)-maxProfileVersions:]
+	}
+
+	m.versions[endpointName][profileName] = versions
+}
+
+func (m *Memory) History(endpointName string, profileName string) (versions []profile.Version, err error) {
+	err = checkNames(endpointName, profileName)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, v := range m.versions[endpointName][profileName] {
+		versions = append(versions, v.Version)
+	}
+
+	return
+}
+
+func (m *Memory) ReadVersion(endpointName string, profileName string, version int) (buf []byte, err error) {
+	err = checkNames(endpointName, profileName)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, v := range m.versions[endpointName][profileName] {
+		if v.Version.Version == version {
+			return append([]byte(nil), v.raw...), nil
+		}
+	}
+
+	err = errors.New("profile " + profileName + " of endpoint " + endpointName + " has no version " + strconv.Itoa(version))
+	return
+}
+
+// Copies a profile as stored, history is not copied.
+func (m *Memory) Copy(endpointName string, profileName string, newName string) (err error) {
+	err = checkNames(endpointName, profileName, newName)
+	if err != nil {
+		return
+	}
+
+	unlock, err := lockNames(m.Lock, endpointName, profileName, newName)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	err = m.checkNewName(endpointName, newName)
+	if err != nil {
+		return
+	}
+
+	raw, err := m.ReadRaw(profileName, endpointName)
+	if err != nil {
+		return
+	}
+
+	raw, err = setProfileName(raw, newName)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.write(endpointName, newName, raw)
+	return
+}
+
+// Renames a profile along with its history. Profiles extending it and default profile settings are updated to the new name.
+func (m *Memory) Rename(endpointName string, profileName string, newName string) (err error) {
+	err = checkNames(endpointName, profileName, newName)
+	if err != nil {
+		return
+	}
+
+	unlock, err := lockNames(m.Lock, endpointName, profileName, newName)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	err = m.checkNewName(endpointName, newName)
+	if err != nil {
+		return
+	}
+
+	raw, err := m.ReadRaw(profileName, endpointName)
+	if err != nil {
+		return
+	}
+
+	raw, err = setProfileName(raw, newName)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	profiles := m.profiles[endpointName]
+	delete(profiles, profileName)
+	profiles[newName] = raw
+	m.versions[endpointName][newName] = m.versions[endpointName][profileName]
+	delete(m.versions[endpointName], profileName)
+
+	for name, child := range profiles {
+		if profile.Parent(child) != profileName {
+			continue
+		}
+
+		profiles[name], err = setKey(child, "Extends", newName)
+		if err != nil {
+			m.mu.Unlock()
+			return
+		}
+	}
+	m.mu.Unlock()
+
+	err = config.RenameDefaultProfile(endpointName, profileName, newName)
+	return
+}
+
+func (m *Memory) checkNewName(endpointName string, newName string) error {
+	if _, err := m.ReadRaw(newName, endpointName); err == nil {
+		return errors.New("profile " + newName + " of endpoint " + endpointName + " already exists")
+	}
+
+	return nil
+}
+
+func (m *Memory) Delete(endpointName string, profileName string) (err error) {
+	err = checkNames(endpointName, profileName)
+	if err != nil {
+		return
+	}
+
+	unlock, err := m.Lock(endpointName, profileName)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	if name, found := extendedBy(m, endpointName, profileName); found {
+		err = errors.New("profile " + profileName + " is extended by profile " + name + ", which must be deleted or changed first")
+		return
+	}
+
+	m.mu.Lock()
+	delete(m.profiles[endpointName], profileName)
+	delete(m.versions[endpointName], profileName)
+	m.mu.Unlock()
+
+	if config.GetDefaultProfile(endpointName) == profileName {
+		config.SetDefaultProfile(endpointName, "", true)
+	}
+
+	return
+}
+
+func (m *Memory) GetAll(endpointName string) (names []string, err error) {
+	err = checkNames(endpointName)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name := range m.profiles[endpointName] {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return
+}
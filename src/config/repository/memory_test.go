@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/ephex2/go-gpt-cli/chat"
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+)
+
+func TestMemorySettingsAreCopied(t *testing.T) {
+	settings := map[string]string{"ApiKey": "sk-test"}
+	m := NewMemory(settings)
+	settings["ApiKey"] = "sk-changed"
+
+	c, err := m.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Settings["Organization"] = "org-test"
+	c, err = m.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Settings) != 1 || c.Settings["ApiKey"] != "sk-test" {
+		t.Errorf("expected the settings to change through Set only, got: %v", c.Settings)
+	}
+
+	c.Settings = map[string]string{"Organization": "org-test"}
+	err = m.Set(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ = m.Get()
+	if len(c.Settings) != 1 || c.Settings["Organization"] != "org-test" {
+		t.Errorf("expected the settings to be replaced, got: %v", c.Settings)
+	}
+}
+
+func TestMemoryRename(t *testing.T) {
+	m := testRepositories(t)["memory"]
+	useSettings(t, m)
+	e := chatEndpoint(t)
+
+	err := m.Create(e, "base")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Update(chat.ChatProfile{ProfileName: "base", MessageHistory: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Update(chat.ChatProfile{ProfileName: "child", Options: profile.Options{Extends: "base"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Rename("chat", "base", "shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = m.ReadRaw("base", "chat"); err == nil {
+		t.Error("expected the old name to be removed")
+	}
+
+	versions, err := m.History("chat", "shared")
+	if err != nil || len(versions) != 2 {
+		t.Errorf("expected the history to follow the profile, got %v (%v)", versions, err)
+	}
+
+	raw, err := m.ReadRaw("child", "chat")
+	if err != nil || profile.Parent(raw) != "shared" {
+		t.Errorf("expected the child to extend the new name, got %s (%v)", raw, err)
+	}
+
+	if name := config.GetDefaultProfile("chat"); name != "shared" {
+		t.Errorf("expected the default profile to be renamed, got %s", name)
+	}
+
+	err = m.Delete("chat", "shared")
+	if err == nil {
+		t.Error("expected a profile extended by another one to be kept")
+	}
+}
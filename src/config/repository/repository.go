@@ -2,12 +2,20 @@ package repository
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/format"
+	"github.com/ephex2/go-gpt-cli/config/lockedfile"
 	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/config/secret"
 	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/usage"
 )
 
 
@@ -16,22 +24,19 @@ type fileRepository struct {
 	basePath        string
 	filePath        string
 	profileFileName string
+	loaded          map[string]string // Settings as last read or written
 }
 
 func (cr *fileRepository) FilePath() (string, error) {
 	return filepath.Abs(cr.filePath)
 }
 
-func (cr *fileRepository) Init() (err error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		panic(err.Error())
-	}
-
+func (cr *fileRepository) Init(dirs Dirs) (err error) {
 	// Set default values
-	cr.basePath = homeDir + "/.local/go-gpt-cli/"
-	cr.filePath = cr.basePath + "go-gpt-cli.json"
-	cr.profileFileName = "/config.json"
+	cr.basePath = dirs.Config + "/"
+	cr.filePath = findFile(cr.basePath+"go-gpt-cli", format.Json)
+	cr.profileFileName = "/config"
+	cr.loaded = make(map[string]string)
 
 	// Make config folder
 	_, err = os.Stat(cr.basePath)
@@ -42,10 +47,10 @@ func (cr *fileRepository) Init() (err error) {
 		}
 	}
 
-	// Make empty config.json if it doesn't exist
+	// Make empty go-gpt-cli.json if no settings file exists
 	_, err = os.Stat(cr.filePath)
 	if err != nil {
-		_, err = os.Create(cr.filePath)
+		err = os.WriteFile(cr.filePath, nil, 0600)
 		if err != nil {
 			return
 		}
@@ -66,19 +71,12 @@ func (cr fileRepository) Get() (baseConfig config.Config, err error) {
 		return
 	}
 
-	buf, err := os.ReadFile(path)
+	settingsMap, err := readSettings(path)
 	if err != nil {
 		return
 	}
 
-	settingsMap := make(map[string]string)
-    
-    if len(buf) != 0 {
-        err = json.Unmarshal(buf, &settingsMap)
-	    if err != nil {
-		    return
-	    }
-    }
+	cr.setLoaded(settingsMap)
 
 	baseConfig = config.Config{
 		Repository: cr,
@@ -88,29 +86,162 @@ func (cr fileRepository) Get() (baseConfig config.Config, err error) {
 	return baseConfig, nil
 }
 
+func readSettings(path string) (settingsMap map[string]string, err error) {
+	buf, err := readFile(path)
+	if err != nil {
+		return
+	}
+
+	settingsMap = make(map[string]string)
+	if len(buf) == 0 {
+		return
+	}
+
+	var settings map[string]any
+	err = json.Unmarshal(buf, &settings)
+	if err != nil {
+		return
+	}
+
+	// Yaml and toml settings files may hold numbers and booleans unquoted
+	for k, v := range settings {
+		if s, ok := v.(string); ok {
+			settingsMap[k] = s
+		} else if v != nil {
+			value, _ := json.Marshal(v)
+			settingsMap[k] = string(value)
+		}
+	}
+
+	return
+}
+
+// Keeps a copy of the settings as they were loaded, the changes made since are the ones written by Set.
+func (cr fileRepository) setLoaded(settings map[string]string) {
+	clear(cr.loaded)
+	for k, v := range settings {
+		cr.loaded[k] = v
+	}
+}
+
+// Settings are loaded when the CLI starts, and other invocations may have changed them since. Only the settings
+// changed by this invocation are applied to the settings as they are now, the settings of c are refreshed with the
+// changes of other invocations.
 func (cr fileRepository) Set(c config.Config) (err error) {
 	path, err := cr.FilePath()
 	if err != nil {
 		return
 	}
 
-	settingsJson, err := json.Marshal(c.Settings)
+	unlock, err := lockedfile.Lock(cr.lockPath("settings"))
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	current, err := readSettings(path)
+	if err != nil {
+		return
+	}
+
+	mergeSettings(cr.loaded, c.Settings, current)
+	settingsJson, err := json.Marshal(current)
 	if err != nil {
 		return
 	}
 
 	log.Debug("Writing config file to path: %s\n", path)
-	err = os.WriteFile(path, settingsJson, 0750)
+	// The settings may hold secrets, only the owner can read them
+	err = writeFile(path, settingsJson, 0600)
 	if err != nil {
 		return
 	}
 
+	cr.setLoaded(current)
+	refreshSettings(c.Settings, current)
 	return
 }
 
+// Applies the settings changed since they were loaded to the current settings.
+func mergeSettings(loaded map[string]string, settings map[string]string, current map[string]string) {
+	for k, v := range settings {
+		if l, ok := loaded[k]; !ok || l != v {
+			current[k] = v
+		}
+	}
+
+	for k := range loaded {
+		if _, ok := settings[k]; !ok {
+			delete(current, k)
+		}
+	}
+}
+
+func refreshSettings(settings map[string]string, current map[string]string) {
+	if settings == nil {
+		return
+	}
+
+	clear(settings)
+	for k, v := range current {
+		settings[k] = v
+	}
+}
+
+// Lock files are kept apart from the settings and profiles, so that locking a profile never creates its folder.
+func (cr *fileRepository) lockPath(name string) string {
+	return filepath.Join(cr.basePath, ".locks", name+".lock")
+}
+
+func (cr fileRepository) Lock(endpointName string, profileName string) (unlock func(), err error) {
+	err = checkNames(endpointName, profileName)
+	if err != nil {
+		return
+	}
+
+	return lockedfile.Lock(cr.lockPath(filepath.Join(endpointName, profileName)))
+}
+
+// Settings and profile files are json, yaml or toml files depending on their extension, see package format.
+// Returns the path of the file stored in any format under a path without extension, or the path of a new file in
+// newFormat when there is none.
+func findFile(base string, newFormat string) string {
+	for _, f := range format.Formats {
+		for _, ext := range format.Extensions(f) {
+			if _, err := os.Stat(base + "." + ext); err == nil {
+				return base + "." + ext
+			}
+		}
+	}
+
+	return base + "." + format.Extensions(newFormat)[0]
+}
+
+// Reads a file as json.
+func readFile(path string) (buf []byte, err error) {
+	buf, err = os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	return format.ToJson(format.FromPath(path), buf)
+}
+
+// Writes json to a file in the format of its extension, keeping the comments of the file it replaces. The file is
+// replaced atomically, a crash leaves either the previous or the new file.
+func writeFile(path string, buf []byte, perm os.FileMode) (err error) {
+	previous, _ := os.ReadFile(path)
+	buf, err = format.FromJson(format.FromPath(path), buf, previous)
+	if err != nil {
+		return
+	}
+
+	return lockedfile.WriteFile(path, buf, perm)
+}
+
 // Implementing ProfileRepository interface directly on fileRepository
 func (cr *fileRepository) profileFilePath(endpointName string, profileName string) string {
-	return cr.profileFolderPath(endpointName, profileName) + cr.profileFileName
+	return findFile(cr.profileFolderPath(endpointName, profileName)+cr.profileFileName, config.ProfileFormat())
 }
 
 func (cr *fileRepository) profileFolderPath(endpointName string, profileName string) string {
@@ -118,15 +249,18 @@ func (cr *fileRepository) profileFolderPath(endpointName string, profileName str
 }
 
 func (cr fileRepository) Create(endpoint profile.Endpoint, profileName string) (err error) {
+	err = checkNames(endpoint.Name(), profileName)
+	if err != nil {
+		return
+	}
+
 	p := endpoint.DefaultProfile()
 	p = p.SetName(profileName)
 
 	profileFolder := cr.profileFolderPath(endpoint.Name(), p.Name())
 	err = os.MkdirAll(profileFolder, 0750)
-	if err != nil && !os.IsExist(err) {
-		panic(err.Error())
-	} else {
-		err = nil
+	if err != nil {
+		return
 	}
 
 	err = cr.Update(p)
@@ -143,8 +277,33 @@ func (cr fileRepository) Create(endpoint profile.Endpoint, profileName string) (
 }
 
 func (cr fileRepository) Read(name string, endpointName string) (pBytes []byte, err error) {
+	return readValidated(cr.rawReader(endpointName), name, endpointName)
+}
+
+// Profiles are validated when they are loaded, invalid ones are still returned so that requests can be attempted with them.
+func readValidated(read profile.RawReader, name string, endpointName string) (pBytes []byte, err error) {
+	pBytes, err = profile.Resolve(read, name)
+	if err != nil {
+		return
+	}
+
+	if e, getErr := profile.EndpointRegistry.Get(endpointName); getErr == nil {
+		if _, validateErr := profile.Validate(e, pBytes); validateErr != nil {
+			log.Warning("Profile %s of endpoint %s is invalid, fix it with 'profile edit %s %s':\n%s\n", name, endpointName, endpointName, name, validateErr.Error())
+		}
+	}
+
+	return
+}
+
+func (cr fileRepository) ReadRaw(name string, endpointName string) (pBytes []byte, err error) {
+	err = checkNames(endpointName, name)
+	if err != nil {
+		return
+	}
+
 	log.Debug("Looking for profile in path: %s\n", cr.profileFilePath(endpointName, name))
-	pBytes, err = os.ReadFile(cr.profileFilePath(endpointName, name))
+	pBytes, err = readFile(cr.profileFilePath(endpointName, name))
 	if err != nil {
 		return
 	}
@@ -152,19 +311,359 @@ func (cr fileRepository) Read(name string, endpointName string) (pBytes []byte,
 	return
 }
 
+func (cr fileRepository) rawReader(endpointName string) profile.RawReader {
+	return func(name string) ([]byte, error) {
+		return cr.ReadRaw(name, endpointName)
+	}
+}
+
+// Profiles extending another one are stored as an overlay of their parent.
 func (cr fileRepository) Update(p profile.Profile) (err error) {
+	unlock, err := cr.Lock(p.Endpoint().Name(), p.Name())
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	return cr.UpdateLocked(p)
+}
+
+func (cr fileRepository) UpdateLocked(p profile.Profile) (err error) {
+	err = checkNames(p.Endpoint().Name(), p.Name())
+	if err != nil {
+		return
+	}
+
 	buf, err := json.Marshal(p)
 	if err != nil {
 		return
 	}
 
+	buf, err = profile.Overlay(cr.rawReader(p.Endpoint().Name()), p.Name(), buf)
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(cr.profileFolderPath(p.Endpoint().Name(), p.Name()), 0750)
+	if err != nil {
+		return
+	}
+
 	profilePath := cr.profileFilePath(p.Endpoint().Name(), p.Name())
 	log.Debug("Writing profile at path: " + profilePath + "\n")
-	err = os.WriteFile(cr.profileFilePath(p.Endpoint().Name(), p.Name()), buf, 0750)
+	// Profiles may hold api keys, only the owner can read them
+	err = writeFile(profilePath, buf, 0600)
+	if err != nil {
+		return
+	}
+
+	err = cr.snapshot(p.Endpoint().Name(), p.Name(), buf)
+	return
+}
+
+// Number of versions kept for each profile, chat profiles with message history are updated on every message.
+const maxProfileVersions = 20
+
+func (cr *fileRepository) historyFolderPath(endpointName string, profileName string) string {
+	return cr.profileFolderPath(endpointName, profileName) + "/history"
+}
+
+func (cr *fileRepository) versionFilePath(endpointName string, profileName string, version int) string {
+	return cr.historyFolderPath(endpointName, profileName) + "/" + strconv.Itoa(version) + ".json"
+}
+
+// Stores a profile as its next version, then removes the oldest versions.
+func (cr fileRepository) snapshot(endpointName string, profileName string, buf []byte) (err error) {
+	versions, err := cr.History(endpointName, profileName)
+	if err != nil {
+		return
+	}
+
+	next := 1
+	if len(versions) > 0 {
+		next = versions[len(versions)-1].Version + 1
+	}
+
+	err = os.MkdirAll(cr.historyFolderPath(endpointName, profileName), 0750)
+	if err != nil {
+		return
+	}
+
+	err = lockedfile.WriteFile(cr.versionFilePath(endpointName, profileName, next), buf, 0600)
+	if err != nil {
+		return
+	}
+
+	for i := 0; i < len(versions)+1-maxProfileVersions; i++ {
+		os.Remove(cr.versionFilePath(endpointName, profileName, versions[i].Version))
+	}
+
+	return
+}
+
+// Returns the versions of a profile, oldest first.
+func (cr fileRepository) History(endpointName string, profileName string) (versions []profile.Version, err error) {
+	err = checkNames(endpointName, profileName)
+	if err != nil {
+		return
+	}
+
+	entries, err := os.ReadDir(cr.historyFolderPath(endpointName, profileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		n, convErr := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".json"))
+		info, infoErr := entry.Info()
+		if convErr != nil || infoErr != nil {
+			continue
+		}
+
+		versions = append(versions, profile.Version{Version: n, Time: info.ModTime(), Size: info.Size()})
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	return
+}
+
+func (cr fileRepository) ReadVersion(endpointName string, profileName string, version int) (buf []byte, err error) {
+	err = checkNames(endpointName, profileName)
+	if err != nil {
+		return
+	}
+
+	buf, err = os.ReadFile(cr.versionFilePath(endpointName, profileName, version))
+	if os.IsNotExist(err) {
+		err = errors.New("profile " + profileName + " of endpoint " + endpointName + " has no version " + strconv.Itoa(version))
+	}
+
+	return
+}
+
+// Copies a profile as stored, a copy of a profile extending another one extends the same parent. History is not copied.
+func (cr fileRepository) Copy(endpointName string, profileName string, newName string) (err error) {
+	err = checkNames(endpointName, profileName, newName)
+	if err != nil {
+		return
+	}
+
+	unlock, err := lockNames(cr.Lock, endpointName, profileName, newName)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	err = cr.checkNewName(endpointName, newName)
+	if err != nil {
+		return
+	}
+
+	raw, err := cr.ReadRaw(profileName, endpointName)
+	if err != nil {
+		return
+	}
+
+	raw, err = setProfileName(raw, newName)
+	if err != nil {
+		return
+	}
+
+	err = os.MkdirAll(cr.profileFolderPath(endpointName, newName), 0750)
+	if err != nil {
+		return
+	}
+
+	// The copy is stored in the format of the profile
+	ext := filepath.Ext(cr.profileFilePath(endpointName, profileName))
+	err = writeFile(cr.profileFolderPath(endpointName, newName)+cr.profileFileName+ext, raw, 0600)
+	if err != nil {
+		return
+	}
+
+	err = cr.snapshot(endpointName, newName, raw)
+	return
+}
+
+// Renames a profile along with its history. Profiles extending it and default profile settings are updated to the new name.
+func (cr fileRepository) Rename(endpointName string, profileName string, newName string) (err error) {
+	err = checkNames(endpointName, profileName, newName)
+	if err != nil {
+		return
+	}
+
+	unlock, err := lockNames(cr.Lock, endpointName, profileName, newName)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	err = cr.checkNewName(endpointName, newName)
+	if err != nil {
+		return
+	}
+
+	raw, err := cr.ReadRaw(profileName, endpointName)
+	if err != nil {
+		return
+	}
+
+	raw, err = setProfileName(raw, newName)
+	if err != nil {
+		return
+	}
+
+	err = os.Rename(cr.profileFolderPath(endpointName, profileName), cr.profileFolderPath(endpointName, newName))
+	if err != nil {
+		return
+	}
+
+	err = writeFile(cr.profileFilePath(endpointName, newName), raw, 0600)
+	if err != nil {
+		return
+	}
+
+	names, err := cr.GetAll(endpointName)
+	if err != nil {
+		return
+	}
+
+	for _, name := range names {
+		child, readErr := cr.ReadRaw(name, endpointName)
+		if readErr != nil || profile.Parent(child) != profileName {
+			continue
+		}
+
+		child, err = setKey(child, "Extends", newName)
+		if err != nil {
+			return
+		}
+
+		err = cr.writeLocked(endpointName, name, child)
+		if err != nil {
+			return
+		}
+	}
+
+	err = config.RenameDefaultProfile(endpointName, profileName, newName)
 	return
 }
 
+func (cr fileRepository) writeLocked(endpointName string, profileName string, raw []byte) (err error) {
+	unlock, err := cr.Lock(endpointName, profileName)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	return writeFile(cr.profileFilePath(endpointName, profileName), raw, 0600)
+}
+
+func (cr fileRepository) checkNewName(endpointName string, newName string) error {
+	if _, err := os.Stat(cr.profileFolderPath(endpointName, newName)); err == nil {
+		return errors.New("profile " + newName + " of endpoint " + endpointName + " already exists")
+	}
+
+	return nil
+}
+
+// Endpoint and profile names are used as folder names, they are checked by every method of the repositories so that no
+// name leaves the folder of the profiles, whichever the repository.
+func checkNames(endpointName string, profileNames ...string) error {
+	if profile.CheckName(endpointName) != nil {
+		return errors.New("invalid endpoint name " + endpointName)
+	}
+
+	for _, name := range profileNames {
+		err := profile.CheckName(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Locks profiles of an endpoint in the order of their names, so that invocations locking the same profiles never wait
+// for each other. Every name is locked once.
+func lockNames(lock func(string, string) (func(), error), endpointName string, names ...string) (unlock func(), err error) {
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+
+	var unlocks []func()
+	unlock = func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+
+	for i, name := range names {
+		if i > 0 && name == names[i-1] {
+			continue
+		}
+
+		var u func()
+		u, err = lock(endpointName, name)
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+
+		unlocks = append(unlocks, u)
+	}
+
+	return
+}
+
+func setProfileName(raw []byte, name string) ([]byte, error) {
+	return setKey(raw, "ProfileName", name)
+}
+
+func setKey(raw []byte, key string, value string) ([]byte, error) {
+	var m map[string]any
+	err := json.Unmarshal(raw, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	m[key] = value
+	return json.Marshal(m)
+}
+
+// Returns a profile extending a profile, which can not be deleted while it is extended.
+func extendedBy(repo profile.Repository, endpointName string, profileName string) (child string, found bool) {
+	names, _ := repo.GetAll(endpointName)
+	for _, name := range names {
+		raw, readErr := repo.ReadRaw(name, endpointName)
+		if readErr == nil && profile.Parent(raw) == profileName {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
 func (cr fileRepository) Delete(endpointName string, profileName string) (err error) {
+	err = checkNames(endpointName, profileName)
+	if err != nil {
+		return
+	}
+
+	unlock, err := cr.Lock(endpointName, profileName)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	if name, found := extendedBy(cr, endpointName, profileName); found {
+		err = errors.New("profile " + profileName + " is extended by profile " + name + ", which must be deleted or changed first")
+		return
+	}
+
 	profilePath := cr.profileFolderPath(endpointName, profileName)
 	log.Debug("Deleting profile at path: " + profilePath + "\n")
 	err = os.RemoveAll(profilePath)
@@ -180,6 +679,11 @@ func (cr fileRepository) Delete(endpointName string, profileName string) (err er
 }
 
 func (cr fileRepository) GetAll(endpointName string) (names []string, err error) {
+	err = checkNames(endpointName)
+	if err != nil {
+		return
+	}
+
 	dirs, err := os.ReadDir(cr.basePath + "/" + endpointName)
 	if err != nil || len(dirs) == 0 {
 		return
@@ -194,11 +698,21 @@ func (cr fileRepository) GetAll(endpointName string) (names []string, err error)
 
 // Why Init() ? The initialization of main seemed to only call init in packages imported by main.
 // This function is meant to be called by the init function in the root cobra package
-func Init() {
+func Init() (err error) {
+	dirs, err := ResolveDirs()
+	if err != nil {
+		return
+	}
+
+	err = prepareDirs(dirs)
+	if err != nil {
+		return
+	}
+
 	dummyConfig := fileRepository{}
-	err := dummyConfig.Init()
+	err = dummyConfig.Init(dirs)
 	if err != nil {
-		panic(err.Error())
+		return
 	}
 
 	cfg, err := dummyConfig.Get()
@@ -208,12 +722,38 @@ func Init() {
 	}
 
 	Profile := fileRepository{}
-	err = Profile.Init()
+	err = Profile.Init(dirs)
 	if err != nil {
-		panic(err.Error())
+		return
 	}
 
 	config.RuntimeConfig = cfg
+	config.ConfigDir = dirs.Config
+	config.DataDir = dirs.Data
+	config.CacheDir = dirs.Cache
+	secret.StorePath = filepath.Join(dirs.Data, "secrets.enc")
 	config.RuntimeConfig.Repository = dummyConfig
 	profile.RuntimeRepository = Profile
+
+	runtimeDirs = dirs
+	runtimeFile = dummyConfig
+	runtimeStorage, err = selectedStorage(cfg.Settings)
+	if err != nil || runtimeStorage != StorageSqlite {
+		return
+	}
+
+	runtimeSqlite, err = openSqlite(dirs)
+	if err != nil {
+		return
+	}
+
+	cfg, err = runtimeSqlite.Get()
+	if err != nil {
+		return
+	}
+
+	config.RuntimeConfig = cfg
+	profile.RuntimeRepository = runtimeSqlite
+	usage.RuntimeLedger = runtimeSqlite.ledger
+	return nil
 }
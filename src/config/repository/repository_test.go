@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/chat"
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+)
+
+// Repositories whose settings and profiles are ready for use by a test, by storage.
+type testRepository interface {
+	profile.Repository
+	config.ConfigRepository
+}
+
+// Returns a file, a sqlite and a memory repository, the first two stored in a temporary directory.
+func testRepositories(t *testing.T) map[string]testRepository {
+	t.Helper()
+
+	profile.EndpointRegistry = profile.Registry{}
+	chat.Register(&profile.EndpointRegistry)
+
+	dirs := Dirs{Config: t.TempDir(), Data: t.TempDir(), Cache: t.TempDir()}
+	fr := &fileRepository{}
+	err := fr.Init(dirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sr, err := openSqlite(dirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sr.Close() })
+
+	return map[string]testRepository{
+		StorageFile:   fr,
+		StorageSqlite: sr,
+		"memory":      NewMemory(nil),
+	}
+}
+
+// Makes the settings of a repository the ones used by the config package, default profiles are stored in them.
+func useSettings(t *testing.T, repo testRepository) {
+	t.Helper()
+
+	err := config.RuntimeConfig.Init(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func chatEndpoint(t *testing.T) profile.Endpoint {
+	t.Helper()
+
+	e, err := profile.EndpointRegistry.Get("chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return e
+}
+
+func TestNamesOutsideFolderAreRefused(t *testing.T) {
+	for storage, repo := range testRepositories(t) {
+		useSettings(t, repo)
+		e := chatEndpoint(t)
+
+		err := repo.Create(e, "kept")
+		if err != nil {
+			t.Fatalf("%s: %s", storage, err)
+		}
+
+		for _, name := range []string{"..", ".", "", "../chat", `..\chat`, "a/b"} {
+			checks := map[string]error{
+				"Create": repo.Create(e, name),
+				"Update": repo.Update(chat.ChatProfile{ProfileName: name}),
+				"Delete": repo.Delete("chat", name),
+				"Copy":   repo.Copy("chat", name, "copy"),
+				"Rename": repo.Rename("chat", "kept", name),
+			}
+
+			_, checks["ReadRaw"] = repo.ReadRaw(name, "chat")
+			_, checks["Read"] = repo.Read(name, "chat")
+			_, checks["History"] = repo.History("chat", name)
+			_, checks["ReadVersion"] = repo.ReadVersion("chat", name, 1)
+			_, checks["Lock"] = repo.Lock("chat", name)
+			_, checks["GetAll"] = repo.GetAll(name)
+
+			for method, err := range checks {
+				// An empty name is the default profile for Update
+				if name == "" && method == "Update" {
+					continue
+				}
+
+				if err == nil {
+					t.Errorf("%s: expected %s to refuse the name %q", storage, method, name)
+				}
+			}
+		}
+
+		_, err = repo.ReadRaw("kept", "chat")
+		if err != nil {
+			t.Errorf("%s: expected the profile to be left in place, got: %s", storage, err)
+		}
+	}
+}
+
+func TestSnapshotLeavesNoTemporaryFile(t *testing.T) {
+	repo := testRepositories(t)[StorageFile]
+	useSettings(t, repo)
+
+	err := repo.Create(chatEndpoint(t), "versioned")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fr := repo.(*fileRepository)
+	entries, err := os.ReadDir(fr.historyFolderPath("chat", "versioned"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || filepath.Ext(entries[0].Name()) != ".json" {
+		t.Errorf("expected a single version file, got: %v", entries)
+	}
+}
+
+func TestLockedUpdatesAreNotLost(t *testing.T) {
+	for storage, repo := range testRepositories(t) {
+		useSettings(t, repo)
+		e := chatEndpoint(t)
+
+		err := repo.Create(e, "shared")
+		if err != nil {
+			t.Fatalf("%s: %s", storage, err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				unlock, err := repo.Lock("chat", "shared")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				defer unlock()
+
+				var p chat.ChatProfile
+				raw, err := repo.ReadRaw("shared", "chat")
+				if err == nil {
+					err = json.Unmarshal(raw, &p)
+				}
+
+				if err == nil {
+					p.CreateCompletionBody.Messages = append(p.CreateCompletionBody.Messages, chat.Message{Role: "user", Content: "Hello"})
+					err = repo.UpdateLocked(p)
+				}
+
+				if err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		var p chat.ChatProfile
+		raw, err := repo.ReadRaw("shared", "chat")
+		if err == nil {
+			err = json.Unmarshal(raw, &p)
+		}
+
+		if err != nil {
+			t.Fatalf("%s: %s", storage, err)
+		}
+
+		// The default profile starts with its system message
+		if len(p.CreateCompletionBody.Messages) != 11 {
+			t.Errorf("%s: expected every message to be kept, got %d of 11", storage, len(p.CreateCompletionBody.Messages))
+		}
+	}
+}
+
+func TestRenamesInOppositeDirections(t *testing.T) {
+	for storage, repo := range testRepositories(t) {
+		useSettings(t, repo)
+		e := chatEndpoint(t)
+
+		for _, name := range []string{"a", "b"} {
+			err := repo.Create(e, name)
+			if err != nil {
+				t.Fatalf("%s: %s", storage, err)
+			}
+		}
+
+		// Both renames fail since the names are taken, they must not wait for each other while doing so
+		done := make(chan struct{})
+		go func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(2)
+				go func() { defer wg.Done(); repo.Rename("chat", "a", "b") }()
+				go func() { defer wg.Done(); repo.Copy("chat", "b", "a") }()
+			}
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("%s: renames and copies of the same profiles deadlocked", storage)
+		}
+	}
+}
@@ -0,0 +1,602 @@
+package repository
+
+// The sqlite repository keeps settings, profiles with their versions, and usage records in a single database of the
+// data directory. Chat sessions are profiles with MessageHistory, they are stored along with the other profiles.
+//
+// Profiles are stored as json whatever the ProfileFormat setting, which only applies to profile files.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/lockedfile"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/usage"
+	_ "modernc.org/sqlite"
+)
+
+const databaseFileName string = "go-gpt-cli.db"
+
+// Version of the schema, recorded as the user_version of the database.
+const schemaVersion int = 1
+
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS settings (key TEXT PRIMARY KEY, value TEXT NOT NULL)`,
+	`CREATE TABLE IF NOT EXISTS profiles (endpoint TEXT NOT NULL, name TEXT NOT NULL, data BLOB NOT NULL, PRIMARY KEY (endpoint, name))`,
+	`CREATE TABLE IF NOT EXISTS profile_versions (endpoint TEXT NOT NULL, name TEXT NOT NULL, version INTEGER NOT NULL, saved_at INTEGER NOT NULL, data BLOB NOT NULL, PRIMARY KEY (endpoint, name, version))`,
+	`CREATE TABLE IF NOT EXISTS usage (id INTEGER PRIMARY KEY AUTOINCREMENT, time INTEGER NOT NULL, record TEXT NOT NULL)`,
+	`CREATE INDEX IF NOT EXISTS usage_time ON usage (time)`,
+}
+
+// sqliteRepository implements both the ProfileRepository and ConfigRepository interfaces
+type sqliteRepository struct {
+	db       *sql.DB
+	path     string
+	lockPath string
+	loaded   map[string]string // Settings as last read or written
+	ledger   sqliteLedger
+}
+
+// sqliteLedger implements the usage Ledger interface, whose Read method is not the one of profiles
+type sqliteLedger struct {
+	db   *sql.DB
+	path string
+}
+
+func openSqlite(dirs Dirs) (sr *sqliteRepository, err error) {
+	path := filepath.Join(dirs.Data, databaseFileName)
+	// Other invocations may be writing, transactions wait for them instead of failing
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate")
+	if err != nil {
+		return
+	}
+
+	var version int
+	err = db.QueryRow(`PRAGMA user_version`).Scan(&version)
+	if err != nil {
+		db.Close()
+		return
+	}
+
+	if version > schemaVersion {
+		db.Close()
+		return nil, errors.New("the database " + path + " was written by a newer version of go-gpt-cli, schema version " + strconv.Itoa(version))
+	}
+
+	for _, statement := range append(schema, `PRAGMA user_version = `+strconv.Itoa(schemaVersion)) {
+		_, err = db.Exec(statement)
+		if err != nil {
+			db.Close()
+			return
+		}
+	}
+
+	// The database may hold api keys, only the owner can read it
+	os.Chmod(path, 0600)
+
+	sr = &sqliteRepository{
+		db:       db,
+		path:     path,
+		lockPath: filepath.Join(dirs.Data, ".locks"),
+		loaded:   make(map[string]string),
+		ledger:   sqliteLedger{db: db, path: path},
+	}
+
+	return
+}
+
+func (sr *sqliteRepository) Close() error {
+	return sr.db.Close()
+}
+
+// ConfigRepository implementation
+func (sr *sqliteRepository) Get() (baseConfig config.Config, err error) {
+	settingsMap, err := sr.readSettings(sr.db)
+	if err != nil {
+		return
+	}
+
+	sr.setLoaded(settingsMap)
+
+	baseConfig = config.Config{
+		Repository: sr,
+		Settings:   settingsMap,
+	}
+
+	return
+}
+
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func (sr *sqliteRepository) readSettings(q querier) (settingsMap map[string]string, err error) {
+	rows, err := q.Query(`SELECT key, value FROM settings`)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	settingsMap = make(map[string]string)
+	for rows.Next() {
+		var k, v string
+		err = rows.Scan(&k, &v)
+		if err != nil {
+			return
+		}
+
+		settingsMap[k] = v
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sr *sqliteRepository) setLoaded(settings map[string]string) {
+	clear(sr.loaded)
+	for k, v := range settings {
+		sr.loaded[k] = v
+	}
+}
+
+// As with settings files, only the settings changed by this invocation are applied to the settings as they are now.
+func (sr *sqliteRepository) Set(c config.Config) (err error) {
+	current, err := sr.transaction(func(tx *sql.Tx) (current map[string]string, err error) {
+		current, err = sr.readSettings(tx)
+		if err != nil {
+			return
+		}
+
+		mergeSettings(sr.loaded, c.Settings, current)
+		err = replaceSettings(tx, current)
+		return
+	})
+
+	if err != nil {
+		return
+	}
+
+	sr.setLoaded(current)
+	refreshSettings(c.Settings, current)
+	return
+}
+
+func replaceSettings(tx *sql.Tx, settings map[string]string) (err error) {
+	_, err = tx.Exec(`DELETE FROM settings`)
+	if err != nil {
+		return
+	}
+
+	for k, v := range settings {
+		_, err = tx.Exec(`INSERT INTO settings (key, value) VALUES (?, ?)`, k, v)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// Runs f in a transaction, committed when f returns no error.
+func (sr *sqliteRepository) transaction(f func(tx *sql.Tx) (map[string]string, error)) (result map[string]string, err error) {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return
+	}
+
+	result, err = f(tx)
+	if err != nil {
+		tx.Rollback()
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+func (sr *sqliteRepository) exec(f func(tx *sql.Tx) error) (err error) {
+	_, err = sr.transaction(func(tx *sql.Tx) (map[string]string, error) {
+		return nil, f(tx)
+	})
+
+	return
+}
+
+// Transactions keep the database consistent, profile locks keep read-modify-write cycles of other invocations from
+// overwriting each other's changes, as with profile files.
+func (sr *sqliteRepository) Lock(endpointName string, profileName string) (unlock func(), err error) {
+	err = checkNames(endpointName, profileName)
+	if err != nil {
+		return
+	}
+
+	return lockedfile.Lock(filepath.Join(sr.lockPath, endpointName, profileName+".lock"))
+}
+
+// ProfileRepository implementation
+func (sr *sqliteRepository) Create(endpoint profile.Endpoint, profileName string) (err error) {
+	err = checkNames(endpoint.Name(), profileName)
+	if err != nil {
+		return
+	}
+
+	p := endpoint.DefaultProfile()
+	p = p.SetName(profileName)
+
+	err = sr.Update(p)
+	if err != nil {
+		return
+	}
+
+	err = config.SetDefaultProfile(endpoint.Name(), profileName, false)
+	return
+}
+
+func (sr *sqliteRepository) Read(name string, endpointName string) (pBytes []byte, err error) {
+	return readValidated(sr.rawReader(endpointName), name, endpointName)
+}
+
+func (sr *sqliteRepository) ReadRaw(name string, endpointName string) (pBytes []byte, err error) {
+	err = checkNames(endpointName, name)
+	if err != nil {
+		return
+	}
+
+	log.Debug("Looking for profile %s of endpoint %s in database: %s\n", name, endpointName, sr.path)
+	err = sr.db.QueryRow(`SELECT data FROM profiles WHERE endpoint = ? AND name = ?`, endpointName, name).Scan(&pBytes)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = errors.New("profile " + name + " of endpoint " + endpointName + " does not exist")
+	}
+
+	return
+}
+
+func (sr *sqliteRepository) rawReader(endpointName string) profile.RawReader {
+	return func(name string) ([]byte, error) {
+		return sr.ReadRaw(name, endpointName)
+	}
+}
+
+// Profiles extending another one are stored as an overlay of their parent.
+func (sr *sqliteRepository) Update(p profile.Profile) (err error) {
+	unlock, err := sr.Lock(p.Endpoint().Name(), p.Name())
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	return sr.UpdateLocked(p)
+}
+
+func (sr *sqliteRepository) UpdateLocked(p profile.Profile) (err error) {
+	err = checkNames(p.Endpoint().Name(), p.Name())
+	if err != nil {
+		return
+	}
+
+	buf, err := json.Marshal(p)
+	if err != nil {
+		return
+	}
+
+	buf, err = profile.Overlay(sr.rawReader(p.Endpoint().Name()), p.Name(), buf)
+	if err != nil {
+		return
+	}
+
+	return sr.exec(func(tx *sql.Tx) error {
+		return writeProfile(tx, p.Endpoint().Name(), p.Name(), buf)
+	})
+}
+
+// Stores a profile, and stores it as its next version.
+func writeProfile(tx *sql.Tx, endpointName string, profileName string, buf []byte) (err error) {
+	_, err = tx.Exec(`INSERT INTO profiles (endpoint, name, data) VALUES (?, ?, ?) ON CONFLICT (endpoint, name) DO UPDATE SET data = excluded.data`,
+		endpointName, profileName, buf)
+	if err != nil {
+		return
+	}
+
+	return snapshotTx(tx, endpointName, profileName, buf)
+}
+
+// Stores a profile as its next version, then removes the oldest versions.
+func snapshotTx(tx *sql.Tx, endpointName string, profileName string, buf []byte) (err error) {
+	var next int
+	err = tx.QueryRow(`SELECT COALESCE(MAX(version), 0) + 1 FROM profile_versions WHERE endpoint = ? AND name = ?`, endpointName, profileName).Scan(&next)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`INSERT INTO profile_versions (endpoint, name, version, saved_at, data) VALUES (?, ?, ?, ?, ?)`,
+		endpointName, profileName, next, time.Now().UnixNano(), buf)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(`DELETE FROM profile_versions WHERE endpoint = ? AND name = ? AND version <= ?`, endpointName, profileName, next-maxProfileVersions)
+	return
+}
+
+// Returns the versions of a profile, oldest first.
+func (sr *sqliteRepository) History(endpointName string, profileName string) (versions []profile.Version, err error) {
+	err = checkNames(endpointName, profileName)
+	if err != nil {
+		return
+	}
+
+	rows, err := sr.db.Query(`SELECT version, saved_at, LENGTH(data) FROM profile_versions WHERE endpoint = ? AND name = ? ORDER BY version`, endpointName, profileName)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v profile.Version
+		var savedAt int64
+		err = rows.Scan(&v.Version, &savedAt, &v.Size)
+		if err != nil {
+			return
+		}
+
+		v.Time = time.Unix(0, savedAt)
+		versions = append(versions, v)
+	}
+
+	err = rows.Err()
+	return
+}
+
+func (sr *sqliteRepository) ReadVersion(endpointName string, profileName string, version int) (buf []byte, err error) {
+	err = checkNames(endpointName, profileName)
+	if err != nil {
+		return
+	}
+
+	err = sr.db.QueryRow(`SELECT data FROM profile_versions WHERE endpoint = ? AND name = ? AND version = ?`, endpointName, profileName, version).Scan(&buf)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = errors.New("profile " + profileName + " of endpoint " + endpointName + " has no version " + strconv.Itoa(version))
+	}
+
+	return
+}
+
+// Copies a profile as stored, a copy of a profile extending another one extends the same parent. History is not copied.
+func (sr *sqliteRepository) Copy(endpointName string, profileName string, newName string) (err error) {
+	err = checkNames(endpointName, profileName, newName)
+	if err != nil {
+		return
+	}
+
+	unlock, err := lockNames(sr.Lock, endpointName, profileName, newName)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	err = sr.checkNewName(endpointName, newName)
+	if err != nil {
+		return
+	}
+
+	raw, err := sr.ReadRaw(profileName, endpointName)
+	if err != nil {
+		return
+	}
+
+	raw, err = setProfileName(raw, newName)
+	if err != nil {
+		return
+	}
+
+	return sr.exec(func(tx *sql.Tx) error {
+		return writeProfile(tx, endpointName, newName, raw)
+	})
+}
+
+// Renames a profile along with its history. Profiles extending it and default profile settings are updated to the new name.
+func (sr *sqliteRepository) Rename(endpointName string, profileName string, newName string) (err error) {
+	err = checkNames(endpointName, profileName, newName)
+	if err != nil {
+		return
+	}
+
+	unlock, err := lockNames(sr.Lock, endpointName, profileName, newName)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	err = sr.checkNewName(endpointName, newName)
+	if err != nil {
+		return
+	}
+
+	raw, err := sr.ReadRaw(profileName, endpointName)
+	if err != nil {
+		return
+	}
+
+	raw, err = setProfileName(raw, newName)
+	if err != nil {
+		return
+	}
+
+	names, err := sr.GetAll(endpointName)
+	if err != nil {
+		return
+	}
+
+	children := make(map[string][]byte)
+	for _, name := range names {
+		child, readErr := sr.ReadRaw(name, endpointName)
+		if readErr != nil || profile.Parent(child) != profileName {
+			continue
+		}
+
+		children[name], err = setKey(child, "Extends", newName)
+		if err != nil {
+			return
+		}
+	}
+
+	err = sr.exec(func(tx *sql.Tx) (err error) {
+		_, err = tx.Exec(`UPDATE profiles SET name = ?, data = ? WHERE endpoint = ? AND name = ?`, newName, raw, endpointName, profileName)
+		if err != nil {
+			return
+		}
+
+		_, err = tx.Exec(`UPDATE profile_versions SET name = ? WHERE endpoint = ? AND name = ?`, newName, endpointName, profileName)
+		if err != nil {
+			return
+		}
+
+		for name, child := range children {
+			_, err = tx.Exec(`UPDATE profiles SET data = ? WHERE endpoint = ? AND name = ?`, child, endpointName, name)
+			if err != nil {
+				return
+			}
+		}
+
+		return
+	})
+
+	if err != nil {
+		return
+	}
+
+	err = config.RenameDefaultProfile(endpointName, profileName, newName)
+	return
+}
+
+func (sr *sqliteRepository) checkNewName(endpointName string, newName string) error {
+	if _, err := sr.ReadRaw(newName, endpointName); err == nil {
+		return errors.New("profile " + newName + " of endpoint " + endpointName + " already exists")
+	}
+
+	return nil
+}
+
+func (sr *sqliteRepository) Delete(endpointName string, profileName string) (err error) {
+	err = checkNames(endpointName, profileName)
+	if err != nil {
+		return
+	}
+
+	unlock, err := sr.Lock(endpointName, profileName)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	if name, found := extendedBy(sr, endpointName, profileName); found {
+		err = errors.New("profile " + profileName + " is extended by profile " + name + ", which must be deleted or changed first")
+		return
+	}
+
+	log.Debug("Deleting profile %s of endpoint %s from database: %s\n", profileName, endpointName, sr.path)
+	err = sr.exec(func(tx *sql.Tx) (err error) {
+		_, err = tx.Exec(`DELETE FROM profiles WHERE endpoint = ? AND name = ?`, endpointName, profileName)
+		if err != nil {
+			return
+		}
+
+		_, err = tx.Exec(`DELETE FROM profile_versions WHERE endpoint = ? AND name = ?`, endpointName, profileName)
+		return
+	})
+
+	if err != nil {
+		return
+	}
+
+	if config.GetDefaultProfile(endpointName) == profileName {
+		config.SetDefaultProfile(endpointName, "", true)
+	}
+
+	return
+}
+
+func (sr *sqliteRepository) GetAll(endpointName string) (names []string, err error) {
+	err = checkNames(endpointName)
+	if err != nil {
+		return
+	}
+
+	rows, err := sr.db.Query(`SELECT name FROM profiles WHERE endpoint = ? ORDER BY name`, endpointName)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			return
+		}
+
+		names = append(names, name)
+	}
+
+	err = rows.Err()
+	return
+}
+
+// Ledger implementation
+func (sl sqliteLedger) Append(r usage.Record) (err error) {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+
+	_, err = sl.db.Exec(`INSERT INTO usage (time, record) VALUES (?, ?)`, r.Time.UnixNano(), string(buf))
+	return
+}
+
+func (sl sqliteLedger) Read(since time.Time, until time.Time) (records []usage.Record, err error) {
+	query := `SELECT record FROM usage WHERE 1 = 1`
+	var args []any
+	if !since.IsZero() {
+		query += ` AND time >= ?`
+		args = append(args, since.UnixNano())
+	}
+
+	if !until.IsZero() {
+		query += ` AND time < ?`
+		args = append(args, until.UnixNano())
+	}
+
+	rows, err := sl.db.Query(query+` ORDER BY time, id`, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var buf string
+		err = rows.Scan(&buf)
+		if err != nil {
+			return
+		}
+
+		var r usage.Record
+		err = json.Unmarshal([]byte(buf), &r)
+		if err != nil {
+			err = errors.New("invalid usage record in database " + sl.path + ": " + err.Error())
+			return
+		}
+
+		records = append(records, r)
+	}
+
+	err = rows.Err()
+	return
+}
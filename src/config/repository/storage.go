@@ -0,0 +1,490 @@
+package repository
+
+// Settings, profiles and usage records are stored either in files, the default, or in a sqlite database. The storage
+// is selected by the Storage setting of the settings file, which is the only setting kept in the file when the sqlite
+// storage is selected, or by GO_GPT_CLI_STORAGE. Migrate moves the data from one storage to the other.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/lockedfile"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/usage"
+)
+
+const (
+	StorageFile   string = "file"
+	StorageSqlite string = "sqlite"
+)
+
+var Storages = []string{StorageFile, StorageSqlite}
+
+// Environment variable overriding the Storage setting.
+const StorageEnv string = "GO_GPT_CLI_STORAGE"
+
+const storageKeyName string = "Storage"
+
+// Backups of the data moved by Migrate are named after this prefix, the storage they were moved from and the time.
+const backupFilePrefix string = "storage-backup-"
+
+// State of the repository once initialized, the settings file always exists since it selects the storage.
+var (
+	runtimeDirs    Dirs
+	runtimeFile    fileRepository
+	runtimeSqlite  *sqliteRepository // Only opened when the sqlite storage is selected
+	runtimeStorage string
+)
+
+// Returns the storage selected by GO_GPT_CLI_STORAGE or by the settings of the settings file.
+func selectedStorage(settings map[string]string) (storage string, err error) {
+	storage = os.Getenv(StorageEnv)
+	if storage == "" {
+		storage = settings[storageKeyName]
+	}
+
+	if storage == "" {
+		storage = StorageFile
+	}
+
+	if !slices.Contains(Storages, storage) {
+		err = errors.New("unknown storage " + storage + ", expected one of: " + strings.Join(Storages, ", "))
+	}
+
+	return
+}
+
+// Returns the storage in use, and where its data is.
+func Storage() (storage string, location string) {
+	if runtimeStorage == StorageSqlite {
+		return runtimeStorage, filepath.Join(runtimeDirs.Data, databaseFileName)
+	}
+
+	return runtimeStorage, runtimeDirs.Config
+}
+
+// Data of a storage, as moved by Migrate.
+type storedProfile struct {
+	Endpoint string
+	Name     string
+	Raw      []byte
+	Versions []storedVersion
+}
+
+type storedVersion struct {
+	Version int
+	Time    time.Time
+	Raw     []byte
+}
+
+type storedData struct {
+	Settings map[string]string
+	Profiles []storedProfile
+	Records  []usage.Record
+}
+
+// Reads every setting, profile and usage record of a storage.
+func exportData(cr config.ConfigRepository, repo profile.Repository, ledger usage.Ledger) (data storedData, err error) {
+	cfg, err := cr.Get()
+	if err != nil {
+		return
+	}
+
+	data.Settings = cfg.Settings
+	delete(data.Settings, storageKeyName)
+
+	for _, e := range profile.EndpointRegistry.Endpoints {
+		names, getErr := repo.GetAll(e.Name())
+		if getErr != nil {
+			continue
+		}
+
+		for _, name := range names {
+			p := storedProfile{Endpoint: e.Name(), Name: name}
+			p.Raw, err = repo.ReadRaw(name, e.Name())
+			if err != nil {
+				return
+			}
+
+			versions, historyErr := repo.History(e.Name(), name)
+			if historyErr != nil {
+				return data, historyErr
+			}
+
+			for _, v := range versions {
+				raw, readErr := repo.ReadVersion(e.Name(), name, v.Version)
+				if readErr != nil {
+					return data, readErr
+				}
+
+				p.Versions = append(p.Versions, storedVersion{Version: v.Version, Time: v.Time, Raw: raw})
+			}
+
+			data.Profiles = append(data.Profiles, p)
+		}
+	}
+
+	data.Records, err = ledger.Read(time.Time{}, time.Time{})
+	return
+}
+
+func (data storedData) empty() bool {
+	return len(data.Profiles) == 0 && len(data.Records) == 0
+}
+
+// Moves settings, profiles with their history and usage records to another storage, then selects it. The data of the
+// storage in use is written to a backup file of the data directory, whose path is returned, and is only removed once
+// it has been read back from the other storage. A storage holding profiles or usage records is only replaced when
+// force is set.
+func Migrate(to string, force bool) (backup string, err error) {
+	if !slices.Contains(Storages, to) {
+		err = errors.New("unknown storage " + to + ", expected one of: " + strings.Join(Storages, ", "))
+		return
+	}
+
+	if to == runtimeStorage {
+		err = errors.New("the " + to + " storage is already in use")
+		return
+	}
+
+	db := runtimeSqlite
+	if db == nil {
+		db, err = openSqlite(runtimeDirs)
+		if err != nil {
+			return
+		}
+		defer db.Close()
+	}
+
+	fromFile := runtimeStorage == StorageFile
+	var data, existing storedData
+	if fromFile {
+		data, err = exportData(runtimeFile, runtimeFile, usage.FileLedger{})
+		if err == nil {
+			existing, err = exportData(db, db, db.ledger)
+		}
+	} else {
+		data, err = exportData(db, db, db.ledger)
+		if err == nil {
+			existing, err = exportData(runtimeFile, runtimeFile, usage.FileLedger{})
+		}
+	}
+
+	if err != nil {
+		return
+	}
+
+	if !existing.empty() && !force {
+		err = errors.New("the " + to + " storage already holds profiles or usage records, use --force to replace them")
+		return
+	}
+
+	backup, err = backupData(runtimeStorage, data)
+	if err != nil {
+		return
+	}
+
+	var copied storedData
+	if fromFile {
+		err = db.importData(data)
+		if err == nil {
+			copied, err = exportData(db, db, db.ledger)
+		}
+
+		if err == nil {
+			err = verifyData(to, data, copied)
+		}
+
+		if err != nil {
+			return
+		}
+
+		err = runtimeFile.clearData()
+		if err != nil {
+			return
+		}
+
+		err = runtimeFile.writeSettings(map[string]string{storageKeyName: StorageSqlite})
+	} else {
+		err = runtimeFile.clearData()
+		if err == nil {
+			err = runtimeFile.importData(data)
+		}
+
+		if err == nil {
+			copied, err = exportData(runtimeFile, runtimeFile, usage.FileLedger{})
+		}
+
+		if err == nil {
+			err = verifyData(to, data, copied)
+		}
+
+		if err != nil {
+			return
+		}
+
+		err = db.clearData()
+	}
+
+	if err != nil {
+		return
+	}
+
+	if env := os.Getenv(StorageEnv); env != "" && env != to {
+		log.Warning("%s is set to %s, unset it to use the %s storage\n", StorageEnv, env, to)
+	}
+
+	return
+}
+
+// Writes the data of a storage to a json file of the data directory, from which it can be recovered should the
+// migration go wrong.
+func backupData(from string, data storedData) (path string, err error) {
+	buf, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return
+	}
+
+	path = filepath.Join(runtimeDirs.Data, backupFilePrefix+from+"-"+time.Now().Format("20060102-150405")+".json")
+	err = lockedfile.WriteFile(path, buf, 0600)
+	return
+}
+
+// Checks that the data read back from a storage is the data copied to it. Profiles are compared as json, since the
+// file storage keeps them in the ProfileFormat.
+func verifyData(to string, data storedData, copied storedData) (err error) {
+	fail := func(what string) error {
+		return errors.New("the " + to + " storage does not hold the " + what + " copied to it, the data was left in place")
+	}
+
+	if !maps.Equal(data.Settings, copied.Settings) {
+		return fail("settings")
+	}
+
+	profiles, err := profileDigests(data.Profiles)
+	if err != nil {
+		return
+	}
+
+	copiedProfiles, err := profileDigests(copied.Profiles)
+	if err != nil {
+		return
+	}
+
+	if !maps.Equal(profiles, copiedProfiles) {
+		return fail("profiles")
+	}
+
+	records, err := recordDigests(data.Records)
+	if err != nil {
+		return
+	}
+
+	copiedRecords, err := recordDigests(copied.Records)
+	if err != nil {
+		return
+	}
+
+	if !slices.Equal(records, copiedRecords) {
+		return fail("usage records")
+	}
+
+	return
+}
+
+// Returns the compact json of every profile and version, by endpoint, name and version.
+func profileDigests(profiles []storedProfile) (digests map[string]string, err error) {
+	digests = make(map[string]string)
+	for _, p := range profiles {
+		key := p.Endpoint + "/" + p.Name
+		digests[key], err = compactJson(p.Raw)
+		if err != nil {
+			return
+		}
+
+		for _, v := range p.Versions {
+			digests[key+"@"+strconv.Itoa(v.Version)], err = compactJson(v.Raw)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// Returns the json of every record, sorted since storages do not order records made at the same time alike.
+func recordDigests(records []usage.Record) (digests []string, err error) {
+	for _, r := range records {
+		buf, marshalErr := json.Marshal(r)
+		if marshalErr != nil {
+			return nil, marshalErr
+		}
+
+		digests = append(digests, string(buf))
+	}
+
+	sort.Strings(digests)
+	return
+}
+
+func compactJson(raw []byte) (s string, err error) {
+	var v any
+	err = json.Unmarshal(raw, &v)
+	if err != nil {
+		return
+	}
+
+	buf, err := json.Marshal(v)
+	s = string(buf)
+	return
+}
+
+// Replaces the data of the sqlite storage.
+func (sr *sqliteRepository) importData(data storedData) error {
+	return sr.exec(func(tx *sql.Tx) (err error) {
+		for _, statement := range []string{`DELETE FROM profiles`, `DELETE FROM profile_versions`, `DELETE FROM usage`} {
+			_, err = tx.Exec(statement)
+			if err != nil {
+				return
+			}
+		}
+
+		err = replaceSettings(tx, data.Settings)
+		if err != nil {
+			return
+		}
+
+		for _, p := range data.Profiles {
+			_, err = tx.Exec(`INSERT INTO profiles (endpoint, name, data) VALUES (?, ?, ?)`, p.Endpoint, p.Name, p.Raw)
+			if err != nil {
+				return
+			}
+
+			for _, v := range p.Versions {
+				_, err = tx.Exec(`INSERT INTO profile_versions (endpoint, name, version, saved_at, data) VALUES (?, ?, ?, ?, ?)`,
+					p.Endpoint, p.Name, v.Version, v.Time.UnixNano(), v.Raw)
+				if err != nil {
+					return
+				}
+			}
+		}
+
+		for _, r := range data.Records {
+			buf, marshalErr := json.Marshal(r)
+			if marshalErr != nil {
+				return marshalErr
+			}
+
+			_, err = tx.Exec(`INSERT INTO usage (time, record) VALUES (?, ?)`, r.Time.UnixNano(), string(buf))
+			if err != nil {
+				return
+			}
+		}
+
+		return
+	})
+}
+
+// Removes the database once its data has been moved.
+func (sr *sqliteRepository) clearData() (err error) {
+	err = sr.Close()
+	if err != nil {
+		return
+	}
+
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		err = os.Remove(sr.path + suffix)
+		if err != nil && !os.IsNotExist(err) {
+			return
+		}
+	}
+
+	return nil
+}
+
+// Replaces the data of the file storage. Profiles are written in the ProfileFormat, versions keep their time.
+func (cr fileRepository) importData(data storedData) (err error) {
+	err = cr.writeSettings(data.Settings)
+	if err != nil {
+		return
+	}
+
+	for _, p := range data.Profiles {
+		err = os.MkdirAll(cr.historyFolderPath(p.Endpoint, p.Name), 0750)
+		if err != nil {
+			return
+		}
+
+		err = writeFile(cr.profileFilePath(p.Endpoint, p.Name), p.Raw, 0600)
+		if err != nil {
+			return
+		}
+
+		for _, v := range p.Versions {
+			path := cr.versionFilePath(p.Endpoint, p.Name, v.Version)
+			err = os.WriteFile(path, v.Raw, 0600)
+			if err != nil {
+				return
+			}
+
+			err = os.Chtimes(path, v.Time, v.Time)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	for _, r := range data.Records {
+		err = usage.FileLedger{}.Append(r)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (cr fileRepository) writeSettings(settings map[string]string) (err error) {
+	unlock, err := lockedfile.Lock(cr.lockPath("settings"))
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	buf, err := json.Marshal(settings)
+	if err != nil {
+		return
+	}
+
+	return writeFile(cr.filePath, buf, 0600)
+}
+
+// Removes the profiles and the usage ledger once they have been moved, the settings file is kept since it selects the
+// storage.
+func (cr fileRepository) clearData() (err error) {
+	for _, e := range profile.EndpointRegistry.Endpoints {
+		err = os.RemoveAll(filepath.Join(cr.basePath, e.Name()))
+		if err != nil {
+			return
+		}
+	}
+
+	err = os.Remove(usage.LedgerPath())
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/chat"
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/usage"
+)
+
+// Makes a file repository of temporary directories the one Migrate moves data from.
+func useFileStorage(t *testing.T) *fileRepository {
+	t.Helper()
+
+	configDir, dataDir := config.ConfigDir, config.DataDir
+	t.Cleanup(func() {
+		config.ConfigDir, config.DataDir = configDir, dataDir
+		runtimeDirs, runtimeFile, runtimeSqlite, runtimeStorage = Dirs{}, fileRepository{}, nil, ""
+	})
+
+	fr := testRepositories(t)[StorageFile].(*fileRepository)
+	useSettings(t, fr)
+
+	runtimeDirs = Dirs{Config: filepath.Dir(fr.filePath), Data: t.TempDir()}
+	runtimeFile = *fr
+	runtimeStorage = StorageFile
+	config.ConfigDir, config.DataDir = runtimeDirs.Config, runtimeDirs.Data
+	return fr
+}
+
+func TestMigrateRoundTrip(t *testing.T) {
+	fr := useFileStorage(t)
+	e := chatEndpoint(t)
+
+	err := fr.Create(e, "coding")
+	if err == nil {
+		err = fr.Update(chat.ChatProfile{ProfileName: "coding", MessageHistory: true})
+	}
+
+	if err == nil {
+		err = usage.FileLedger{}.Append(usage.Record{Time: time.Now(), Model: "gpt-4o", PromptTokens: 12})
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := exportData(fr, fr, usage.FileLedger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := Migrate(StorageSqlite, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(backup); err != nil || !strings.HasPrefix(filepath.Base(backup), backupFilePrefix+StorageFile) {
+		t.Errorf("expected a backup of the file storage, got %s (%v)", backup, err)
+	}
+
+	if _, err = fr.ReadRaw("coding", "chat"); err == nil {
+		t.Error("expected the profiles to be removed from the file storage")
+	}
+
+	runtimeSqlite, err = openSqlite(runtimeDirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtimeStorage = StorageSqlite
+
+	migrated, err := exportData(runtimeSqlite, runtimeSqlite, runtimeSqlite.ledger)
+	if err == nil {
+		err = verifyData(StorageSqlite, data, migrated)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Migrate(StorageFile, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(filepath.Join(runtimeDirs.Data, databaseFileName)); !os.IsNotExist(err) {
+		t.Error("expected the database to be removed")
+	}
+
+	back, err := exportData(fr, fr, usage.FileLedger{})
+	if err == nil {
+		err = verifyData(StorageFile, data, back)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(back.Profiles) != 1 || len(back.Profiles[0].Versions) != 2 || len(back.Records) != 1 {
+		t.Errorf("expected the profile with its history and the usage record, got: %+v", back)
+	}
+}
+
+func TestMigrateKeepsExistingData(t *testing.T) {
+	fr := useFileStorage(t)
+
+	err := fr.Create(chatEndpoint(t), "coding")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := openSqlite(runtimeDirs)
+	if err == nil {
+		err = db.ledger.Append(usage.Record{Time: time.Now(), Model: "gpt-4o"})
+		db.Close()
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Migrate(StorageSqlite, false)
+	if err == nil {
+		t.Error("expected a storage holding usage records to be kept without --force")
+	}
+
+	if _, err = fr.ReadRaw("coding", "chat"); err != nil {
+		t.Errorf("expected the profiles to be left in place, got: %s", err)
+	}
+}
+
+func TestVerifyData(t *testing.T) {
+	data := storedData{
+		Settings: map[string]string{"ApiKey": "sk-test"},
+		Profiles: []storedProfile{{Endpoint: "chat", Name: "coding", Raw: []byte(`{"ProfileName": "coding"}`)}},
+		Records:  []usage.Record{{Model: "gpt-4o"}, {Model: "gpt-4o-mini"}},
+	}
+
+	same := storedData{
+		Settings: map[string]string{"ApiKey": "sk-test"},
+		Profiles: []storedProfile{{Endpoint: "chat", Name: "coding", Raw: []byte("{\n  \"ProfileName\": \"coding\"\n}")}},
+		Records:  []usage.Record{{Model: "gpt-4o-mini"}, {Model: "gpt-4o"}},
+	}
+
+	err := verifyData(StorageSqlite, data, same)
+	if err != nil {
+		t.Errorf("expected the data to be the same, got: %s", err)
+	}
+
+	lost := same
+	lost.Records = lost.Records[:1]
+	err = verifyData(StorageSqlite, data, lost)
+	if err == nil {
+		t.Error("expected a lost record to be reported")
+	}
+
+	changed := same
+	changed.Profiles = []storedProfile{{Endpoint: "chat", Name: "coding", Raw: []byte(`{"ProfileName": "writing"}`)}}
+	err = verifyData(StorageSqlite, data, changed)
+	if err == nil {
+		t.Error("expected a changed profile to be reported")
+	}
+}
@@ -0,0 +1,32 @@
+//go:build linux
+
+package secret
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Reads a line from stdin with echo disabled when stdin is a terminal.
+func readPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	termios, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		// Not a terminal, the passphrase is piped in
+		return readLine(prompt)
+	}
+
+	noEcho := *termios
+	noEcho.Lflag &^= unix.ECHO
+	noEcho.Lflag |= unix.ICANON | unix.ISIG
+	err = unix.IoctlSetTermios(fd, unix.TCSETS, &noEcho)
+	if err != nil {
+		return "", err
+	}
+	defer unix.IoctlSetTermios(fd, unix.TCSETS, termios)
+
+	line, err := readLine(prompt)
+	os.Stderr.WriteString("\n")
+	return line, err
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package secret
+
+import "github.com/ephex2/go-gpt-cli/log"
+
+// Echo can only be disabled on linux, set the passphrase environment variable to avoid typing it elsewhere.
+func readPassword(prompt string) (string, error) {
+	log.Warning("The passphrase will be echoed, set %s to avoid typing it\n", PassphraseEnv)
+	return readLine(prompt)
+}
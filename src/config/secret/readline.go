@@ -0,0 +1,27 @@
+package secret
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// Shared by every prompt, a reader per prompt would lose the lines it buffered when stdin is piped.
+var stdin = bufio.NewReader(os.Stdin)
+
+// Prompts on stderr so that stdout only holds the output of commands, then reads a line from stdin.
+func readLine(prompt string) (string, error) {
+	os.Stderr.WriteString(prompt)
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Prompts for a secret, without echoing it when possible.
+func ReadSecret(prompt string) (string, error) {
+	return readPassword(prompt)
+}
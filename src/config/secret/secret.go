@@ -0,0 +1,146 @@
+package secret
+
+// Secrets such as the api key are stored in settings either as plaintext, or as a reference to where the secret lives:
+//
+//	env:OPENAI_API_KEY          the value of an environment variable
+//	file:/path/to/key           the content of a file
+//	cmd:pass show openai        the output of a helper command, like git's credential helpers
+//	encrypted:ApiKey            a secret of the encrypted store, whose key is derived from a passphrase
+//
+// References are resolved each time a secret is needed, so plaintext secrets never have to be written to the settings.
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const (
+	PrefixEnv       = "env:"
+	PrefixFile      = "file:"
+	PrefixCmd       = "cmd:"
+	PrefixEncrypted = "encrypted:"
+)
+
+const (
+	SourcePlain     = "plain"
+	SourceEnv       = "env"
+	SourceFile      = "file"
+	SourceCmd       = "cmd"
+	SourceEncrypted = "encrypted"
+)
+
+var Sources = []string{SourcePlain, SourceEnv, SourceFile, SourceCmd, SourceEncrypted}
+
+// A backend resolves the secrets referenced with its prefix.
+type backend func(ref string) (string, error)
+
+var backends = map[string]backend{
+	PrefixEnv:       resolveEnv,
+	PrefixFile:      resolveFile,
+	PrefixCmd:       resolveCmd,
+	PrefixEncrypted: resolveEncrypted,
+}
+
+// Returns the secret held by a setting's value. Values which are not references are plaintext secrets and returned as-is.
+func Resolve(value string) (string, error) {
+	for prefix, resolve := range backends {
+		if strings.HasPrefix(value, prefix) {
+			return resolve(strings.TrimPrefix(value, prefix))
+		}
+	}
+
+	return value, nil
+}
+
+func IsReference(value string) bool {
+	for prefix := range backends {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the value to display for a secret setting. References are not secret and shown as-is.
+func Mask(value string) string {
+	if IsReference(value) {
+		return value
+	}
+
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+
+	return value[:3] + strings.Repeat("*", 8) + value[len(value)-4:]
+}
+
+// Returns the value to store in settings for a secret from a source. For the encrypted source, the secret is stored
+// in the encrypted store under name, and a reference to it is returned.
+func Reference(source string, name string, value string) (ref string, err error) {
+	switch source {
+	case SourcePlain:
+		ref = value
+	case SourceEnv:
+		ref = PrefixEnv + value
+	case SourceFile:
+		ref = PrefixFile + value
+	case SourceCmd:
+		ref = PrefixCmd + value
+	case SourceEncrypted:
+		err = Store(name, value)
+		ref = PrefixEncrypted + name
+	default:
+		err = errors.New("secret source not supported: " + source + ". Supported sources are: " + strings.Join(Sources, ", "))
+	}
+
+	return
+}
+
+func resolveEnv(name string) (value string, err error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		err = errors.New("environment variable " + name + " referenced by a secret is not set")
+	}
+
+	return
+}
+
+func resolveFile(path string) (value string, err error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	value = strings.TrimSpace(string(buf))
+	return
+}
+
+// Helper commands write the secret to stdout. Stdin and stderr are left to the user, so helpers can prompt for input.
+func resolveCmd(command string) (value string, err error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		err = errors.New("secret helper command failed: " + command + ": " + err.Error())
+		return
+	}
+
+	value = strings.TrimSpace(string(out))
+	if value == "" {
+		err = errors.New("secret helper command returned an empty secret: " + command)
+	}
+
+	return
+}
@@ -0,0 +1,116 @@
+package secret
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("TEST_OPENAI_KEY", "sk-env")
+
+	path := filepath.Join(t.TempDir(), "key")
+	err := os.WriteFile(path, []byte("sk-file\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"sk-plain", "sk-plain"},
+		{PrefixEnv + "TEST_OPENAI_KEY", "sk-env"},
+		{PrefixFile + path, "sk-file"},
+		{PrefixCmd + "echo sk-cmd", "sk-cmd"},
+	}
+
+	for _, test := range tests {
+		value, err := Resolve(test.value)
+		if err != nil || value != test.expected {
+			t.Errorf("%s: expected %s, got %s (%v)", test.value, test.expected, value, err)
+		}
+	}
+
+	failing := []string{
+		PrefixEnv + "TEST_MISSING_KEY",
+		PrefixFile + filepath.Join(t.TempDir(), "missing"),
+		PrefixCmd + "exit 1",
+	}
+
+	// An empty output is refused, echo prints a blank line on windows
+	if runtime.GOOS != "windows" {
+		failing = append(failing, PrefixCmd+"true")
+	}
+
+	for _, value := range failing {
+		_, err := Resolve(value)
+		if err == nil {
+			t.Errorf("%s: expected an error", value)
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"sk-short", "********"},
+		{"sk-proj-0123456789abcdef", "sk-********cdef"},
+		{"env:OPENAI_API_KEY", "env:OPENAI_API_KEY"},
+	}
+
+	for _, test := range tests {
+		if masked := Mask(test.value); masked != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.value, test.expected, masked)
+		}
+	}
+}
+
+func TestEncryptedStore(t *testing.T) {
+	StorePath = filepath.Join(t.TempDir(), "secrets.json")
+	t.Cleanup(func() { StorePath, passphrase = "", "" })
+
+	passphrase = ""
+	t.Setenv(PassphraseEnv, "correct horse")
+
+	for name, value := range map[string]string{"work": "sk-work", "personal": "sk-personal"} {
+		ref, err := Reference(SourceEncrypted, name, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ref != PrefixEncrypted+name {
+			t.Errorf("expected a reference to %s, got %s", name, ref)
+		}
+	}
+
+	buf, err := os.ReadFile(StorePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(buf), "sk-work") {
+		t.Error("expected the secrets to be encrypted")
+	}
+
+	value, err := Resolve(PrefixEncrypted + "work")
+	if err != nil || value != "sk-work" {
+		t.Errorf("expected sk-work, got %s (%v)", value, err)
+	}
+
+	_, err = Resolve(PrefixEncrypted + "missing")
+	if err == nil {
+		t.Error("expected an error for a secret missing from the store")
+	}
+
+	passphrase = ""
+	t.Setenv(PassphraseEnv, "wrong")
+	_, err = Resolve(PrefixEncrypted + "work")
+	if err == nil || passphrase != "" {
+		t.Errorf("expected a wrong passphrase to be refused and forgotten, got: %v", err)
+	}
+}
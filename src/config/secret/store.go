@@ -0,0 +1,225 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ephex2/go-gpt-cli/config/lockedfile"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Environment variable holding the passphrase of the encrypted store, the passphrase is prompted for when it is not set.
+const PassphraseEnv = "GO_GPT_CLI_PASSPHRASE"
+
+// scrypt parameters recommended for interactive logins
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	keyLength    = 32
+	saltLength   = 16
+	storeVersion = 1
+)
+
+// Path of the encrypted store, set by the repository when it is initialized.
+var StorePath string
+
+// Passphrase entered during this execution, so that it is only prompted for once.
+var passphrase string
+
+// The store holds a json object of secrets by name, encrypted with AES-GCM using a key derived from the passphrase with scrypt.
+// Parameters are stored with the data so they can be raised later without breaking existing stores.
+type encryptedStore struct {
+	Version int
+	N       int
+	R       int
+	P       int
+	Salt    []byte
+	Nonce   []byte
+	Data    []byte
+}
+
+func resolveEncrypted(name string) (value string, err error) {
+	secrets, err := readStore()
+	if err != nil {
+		return
+	}
+
+	value, ok := secrets[name]
+	if !ok {
+		err = errors.New("no secret named " + name + " in the encrypted store " + StorePath)
+	}
+
+	return
+}
+
+// Stores a secret in the encrypted store, creating the store when it does not exist.
+func Store(name string, value string) (err error) {
+	unlock, err := lockedfile.Lock(StorePath + ".lock")
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	secrets, err := readStore()
+	if errors.Is(err, os.ErrNotExist) {
+		secrets = make(map[string]string)
+		err = nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	secrets[name] = value
+	err = writeStore(secrets)
+	return
+}
+
+func readStore() (secrets map[string]string, err error) {
+	if StorePath == "" {
+		err = errors.New("the encrypted secret store is not initialized")
+		return
+	}
+
+	buf, err := os.ReadFile(StorePath)
+	if err != nil {
+		return
+	}
+
+	var store encryptedStore
+	err = json.Unmarshal(buf, &store)
+	if err != nil {
+		err = errors.New("unable to parse the encrypted store " + StorePath + ": " + err.Error())
+		return
+	}
+
+	if store.Version != storeVersion {
+		err = fmt.Errorf("unsupported version %d of the encrypted store %s", store.Version, StorePath)
+		return
+	}
+
+	pass, err := getPassphrase(false)
+	if err != nil {
+		return
+	}
+
+	gcm, err := newGCM(pass, store.Salt, store.N, store.R, store.P)
+	if err != nil {
+		return
+	}
+
+	plaintext, err := gcm.Open(nil, store.Nonce, store.Data, nil)
+	if err != nil {
+		// Forget the passphrase so that a new one is prompted for next time
+		passphrase = ""
+		err = errors.New("unable to decrypt the encrypted store " + StorePath + ", the passphrase is likely wrong")
+		return
+	}
+
+	err = json.Unmarshal(plaintext, &secrets)
+	return
+}
+
+// Encrypts the secrets with a new salt and nonce, then writes the store.
+func writeStore(secrets map[string]string) (err error) {
+	_, statErr := os.Stat(StorePath)
+	pass, err := getPassphrase(os.IsNotExist(statErr))
+	if err != nil {
+		return
+	}
+
+	store := encryptedStore{
+		Version: storeVersion,
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+		Salt:    make([]byte, saltLength),
+	}
+
+	_, err = rand.Read(store.Salt)
+	if err != nil {
+		return
+	}
+
+	gcm, err := newGCM(pass, store.Salt, store.N, store.R, store.P)
+	if err != nil {
+		return
+	}
+
+	store.Nonce = make([]byte, gcm.NonceSize())
+	_, err = rand.Read(store.Nonce)
+	if err != nil {
+		return
+	}
+
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return
+	}
+
+	store.Data = gcm.Seal(nil, store.Nonce, plaintext, nil)
+
+	buf, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return
+	}
+
+	err = lockedfile.WriteFile(StorePath, buf, 0600)
+	return
+}
+
+func newGCM(pass string, salt []byte, n int, r int, p int) (gcm cipher.AEAD, err error) {
+	key, err := scrypt.Key([]byte(pass), salt, n, r, p, keyLength)
+	if err != nil {
+		return
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return
+	}
+
+	gcm, err = cipher.NewGCM(block)
+	return
+}
+
+// Returns the passphrase from the environment, or prompts for it. New stores ask for the passphrase twice.
+func getPassphrase(confirm bool) (string, error) {
+	if passphrase != "" {
+		return passphrase, nil
+	}
+
+	if value := os.Getenv(PassphraseEnv); value != "" {
+		passphrase = value
+		return passphrase, nil
+	}
+
+	value, err := readPassword("Passphrase of the encrypted secret store: ")
+	if err != nil {
+		return "", err
+	}
+
+	if value == "" {
+		return "", errors.New("the passphrase of the encrypted secret store can not be empty")
+	}
+
+	if confirm {
+		again, err := readPassword("Confirm the passphrase: ")
+		if err != nil {
+			return "", err
+		}
+
+		if again != value {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+
+	passphrase = value
+	return passphrase, nil
+}
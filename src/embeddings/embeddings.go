@@ -1,12 +1,13 @@
 package embeddings
 
 import (
-	"encoding/json"
+	"context"
 
 	"github.com/ephex2/go-gpt-cli/api"
+	"github.com/ephex2/go-gpt-cli/client"
 )
 
-const BaseEmbeddingsRoute string = "/v1/embeddings"
+const BaseEmbeddingsRoute string = client.EmbeddingsRoute
 
 // Should the whole response be returned or just the embeddings themselves?
 func CreateEmbeddings(input []string) (ceResp CreateEmbeddingResponse, err error) {
@@ -17,20 +18,6 @@ func CreateEmbeddings(input []string) (ceResp CreateEmbeddingResponse, err error
 
 	embeddingsP.CreateEmbeddingBody.Input = input
 
-	bodyBuf, err := json.Marshal(embeddingsP.CreateEmbeddingBody)
-	if err != nil {
-		return
-	}
-
-	buf, err := api.GenericRequest(nil, bodyBuf, BaseEmbeddingsRoute, "POST", embeddingsP.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-	err = json.Unmarshal(buf, &ceResp)
-	if err != nil {
-		return
-	}
-
+	ceResp, err = api.NewClient(embeddingsP).CreateEmbedding(context.Background(), embeddingsP.CreateEmbeddingBody)
 	return
 }
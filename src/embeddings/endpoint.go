@@ -3,6 +3,7 @@ package embeddings
 import (
 	"encoding/json"
 
+	"github.com/ephex2/go-gpt-cli/config"
 	"github.com/ephex2/go-gpt-cli/config/profile"
 )
 
@@ -28,7 +29,9 @@ func (e embeddingsEndpoint) DefaultProfile() profile.Profile {
 func (e embeddingsEndpoint) ProfileFromJsonBuf(buf []byte) (p profile.Profile, err error) {
 	var cProf EmbeddingsProfile
 	err = json.Unmarshal(buf, &cProf)
-	// if err != nil { return }
+	if err != nil {
+		return
+	}
 
 	p = cProf
 	return
@@ -37,5 +40,34 @@ func (e embeddingsEndpoint) ProfileFromJsonBuf(buf []byte) (p profile.Profile, e
 // Why Init() ? The initialization of main seemed to only call init in packages imported by main.
 // This function is meant to be called by the init function in the audio cobra package
 func Init() {
-	profile.EndpointRegistry.Add(eEndpoint)
+	Register(&profile.EndpointRegistry)
+}
+
+// Adds the endpoint to a registry other than profile.EndpointRegistry, such as the registry of an app.Runtime.
+func Register(r *profile.Registry) {
+	r.Add(eEndpoint)
+}
+
+var allowedEncodingFormats = []string{"float", "base64"}
+
+func (e embeddingsEndpoint) ValidateProfile(p profile.Profile) (errs profile.ValidationErrors) {
+	ep, ok := p.(EmbeddingsProfile)
+	if !ok {
+		return
+	}
+
+	body := ep.CreateEmbeddingBody
+	// Other vendors have their own models
+	if config.TargetsOpenAI(ep) {
+		errs.CheckAllowed("CreateEmbeddingBody.model", body.Model, profile.AllowedValues(AllowedEncodingModels))
+	}
+
+	if body.EncodingFormat != nil {
+		errs.CheckAllowed("CreateEmbeddingBody.encoding_format", *body.EncodingFormat, allowedEncodingFormats)
+	}
+
+	if body.Dimensions != nil && *body.Dimensions < 1 {
+		errs = append(errs, profile.ValidationError{Path: "CreateEmbeddingBody.dimensions", Message: "must be greater than 0"})
+	}
+	return
 }
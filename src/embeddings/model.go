@@ -1,5 +1,7 @@
 package embeddings
 
+import "github.com/ephex2/go-gpt-cli/client"
+
 var AllowedEncodingModels = struct{
     TextEmbedding3Small string
     TextEmbedding3Large string
@@ -10,35 +12,13 @@ var AllowedEncodingModels = struct{
     TextEmbeddingAda002: "text-embedding-ada-002",
 }
 
-/* Note: the input can technically be a string, array of strings, integer, or array of integers. Simplified for the current case.
-
-See the 'reducing embedding dimensions' section here for more information on Dimensions: https://platform.openai.com/docs/guides/embeddings/use-cases 
-*/
-type CreateEmbeddingBody struct {
-    Input []string `json:"input"`
-    Model string `json:"model"`
-    EncodingFormat *string `json:"encoding_format,omitempty"`
-    Dimensions *int `json:"dimensions,omitempty"`
-    User string `json:"user,omitempty"`
-}
-
-type CreateEmbeddingResponse struct {
-    Object string `json:"object"`
-    Data []Embedding `json:"data"`
-    Model string `json:"model"`
-    Usage Usage `json:"usage"`
-}
-
-type Embedding struct {
-    Object string `json:"object"`
-    Embedding []float64 `json:"embedding"`
-    Index int `json:"index"`
-}
-
-type Usage struct {
-    PromptTokens int `json:"prompt_tokens"`
-    TotalTokens int `json:"total_tokens"`
-}
+// Note: the input can technically be a string, array of strings, integer, or array of integers, see client.EmbeddingRequest.
+type (
+    CreateEmbeddingBody = client.EmbeddingRequest
+    CreateEmbeddingResponse = client.EmbeddingResponse
+    Embedding = client.Embedding
+    Usage = client.EmbeddingUsage
+)
 
 func GetDefaultBody() CreateEmbeddingBody {
     format := new(string)
@@ -11,6 +11,7 @@ type EmbeddingsProfile struct {
 	ProfileName         string
 	CreateEmbeddingBody CreateEmbeddingBody
     Url         string
+    profile.Options
 }
 
 func (p EmbeddingsProfile) Name() string {
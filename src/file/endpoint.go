@@ -28,7 +28,9 @@ func (e fileEndpoint) DefaultProfile() profile.Profile {
 func (e fileEndpoint) ProfileFromJsonBuf(buf []byte) (p profile.Profile, err error) {
 	var cProf FileProfile
 	err = json.Unmarshal(buf, &cProf)
-	// if err != nil { return }
+	if err != nil {
+		return
+	}
 
 	p = cProf
 	return
@@ -37,5 +39,10 @@ func (e fileEndpoint) ProfileFromJsonBuf(buf []byte) (p profile.Profile, err err
 // Why Init() ? The initialization of main seemed to only call init in packages imported by main.
 // This function is meant to be called by the init function in the audio cobra package
 func Init() {
-	profile.EndpointRegistry.Add(fEndpoint)
+	Register(&profile.EndpointRegistry)
+}
+
+// Adds the endpoint to a registry other than profile.EndpointRegistry, such as the registry of an app.Runtime.
+func Register(r *profile.Registry) {
+	r.Add(fEndpoint)
 }
@@ -1,15 +1,17 @@
 package file
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"os"
 	"path/filepath"
 
 	"github.com/ephex2/go-gpt-cli/api"
+	"github.com/ephex2/go-gpt-cli/client"
 )
 
-const BaseFileRoute string = "/v1/files"
+const BaseFileRoute string = client.FilesRoute
 
 // Should the whole response be returned or just the embeddings themselves?
 func CreateFile(purpose string, filePath string) (resp File, err error) {
@@ -48,7 +50,7 @@ func CreateFile(purpose string, filePath string) (resp File, err error) {
 		},
 	}
 
-	buf, err := api.MultiPartFormRequest(fileDetails, p.CreateFileBody, BaseFileRoute, "POST", p.OverrideUrl())
+	buf, err := api.MultiPartFormRequest(fileDetails, p.CreateFileBody, BaseFileRoute, "POST", p)
 	if err != nil {
 		return
 	}
@@ -67,18 +69,7 @@ func DeleteFile(fileId string) (status DeleteStatus, err error) {
         return
     }
 
-	route := BaseFileRoute + "/" + fileId
-	buf, err := api.GenericRequest(nil, nil, route, "DELETE", p.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-	err = json.Unmarshal(buf, &status)
-	if err != nil {
-		return
-	}
-
-	return
+	return api.NewClient(p).DeleteFile(context.Background(), fileId)
 }
 
 func GetFile(fileId string) (buf []byte, err error) {
@@ -87,13 +78,7 @@ func GetFile(fileId string) (buf []byte, err error) {
         return
     }
 
-	route := BaseFileRoute + "/" + fileId + "/content"
-	buf, err = api.GenericRequest(nil, nil, route, "GET", p.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-	return
+	return api.NewClient(p).RetrieveFileContent(context.Background(), fileId)
 }
 
 func StatFile(fileId string) (file File, err error) {
@@ -102,18 +87,7 @@ func StatFile(fileId string) (file File, err error) {
         return
     }
 
-	route := BaseFileRoute + "/" + fileId
-	buf, err := api.GenericRequest(nil, nil, route, "GET", p.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-	err = json.Unmarshal(buf, &file)
-	if err != nil {
-		return
-	}
-
-	return
+	return api.NewClient(p).RetrieveFile(context.Background(), fileId)
 }
 
 func ListFiles() (files FileList, err error) {
@@ -122,15 +96,5 @@ func ListFiles() (files FileList, err error) {
         return
     }
 
-	buf, err := api.GenericRequest(nil, nil, BaseFileRoute, "GET", p.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-	err = json.Unmarshal(buf, &files)
-	if err != nil {
-		return
-	}
-
-	return
+	return api.NewClient(p).ListFiles(context.Background())
 }
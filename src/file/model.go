@@ -1,5 +1,7 @@
 package file
 
+import "github.com/ephex2/go-gpt-cli/client"
+
 var AllowedFilePurposes = struct {
 	Assistants       string
 	AssistantsOutput string
@@ -20,25 +22,11 @@ var CreateFileBody = map[string]string{
 	"purpose": "DecidedAtRuntime",
 }
 
-type FileList struct {
-	Object string `json:"object"`
-	Data   []File `json:"data"`
-}
-
-type File struct {
-	ID        string `json:"id"`
-	Bytes     int    `json:"bytes"`
-	CreatedAt int    `json:"created_at"`
-	FileName  string `json:"filename"`
-	Object    string `json:"object"`
-	Purpose   string `json:"purpose"`
-}
-
-type DeleteStatus struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Deleted bool   `json:"deleted"`
-}
+type (
+	FileList     = client.FileList
+	File         = client.File
+	DeleteStatus = client.DeleteStatus
+)
 
 func GetDefaultBody() map[string]string {
 	return CreateFileBody
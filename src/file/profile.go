@@ -11,6 +11,7 @@ type FileProfile struct {
 	ProfileName    string
 	CreateFileBody map[string]string
     Url            string
+    profile.Options
 }
 
 func (p FileProfile) Name() string {
@@ -37,5 +37,10 @@ func (e finetuningEndpoint) ProfileFromJsonBuf(buf []byte) (p profile.Profile, e
 // Why Init() ? The initialization of main seemed to only call init in packages imported by main.
 // This function is meant to be called by the init function in the audio cobra package
 func Init() {
-	profile.EndpointRegistry.Add(fEndpoint)
+	Register(&profile.EndpointRegistry)
+}
+
+// Adds the endpoint to a registry other than profile.EndpointRegistry, such as the registry of an app.Runtime.
+func Register(r *profile.Registry) {
+	r.Add(fEndpoint)
 }
@@ -1,11 +1,11 @@
 package finetuning
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
-	"strconv"
 
 	"github.com/ephex2/go-gpt-cli/api"
 )
@@ -21,6 +21,15 @@ type listJobEventsPaginator struct {
 }
 
 // Based on current Open AI API spec, must use query parameters "after" and "limit" to control pagination.
+// The API paginates with cursors: the next page starts after the id of the last object received.
+func nextPage(req *http.Request, lastId string) *http.Request {
+	nextReq := req.Clone(req.Context())
+	q := nextReq.URL.Query()
+	q.Set("after", lastId)
+	nextReq.URL.RawQuery = q.Encode()
+	return nextReq
+}
+
 func (paginator *listJobsPaginator) Continue(req *http.Request, res *http.Response) (more bool, nextReq *http.Request, err error) {
 	buf, err := io.ReadAll(res.Body)
 	if err != nil {
@@ -35,24 +44,9 @@ func (paginator *listJobsPaginator) Continue(req *http.Request, res *http.Respon
 
 	paginator.listJobsReturn = append(paginator.listJobsReturn, jobList)
 
-	if jobList.HasMore {
-		var after int
-		var limit int
-
-		after, err = strconv.Atoi(req.Header.Get("after"))
-		if err != nil {
-			return
-		}
-
-		limit, err = strconv.Atoi(req.Header.Get("limit"))
-		if err != nil {
-			return
-		}
-
-		newAfter := strconv.Itoa(after + limit)
-		req.Header.Set("after", newAfter)
-
-		http.DefaultClient.Do(req)
+	if jobList.HasMore && len(jobList.Data) > 0 {
+		nextReq = nextPage(req, jobList.Data[len(jobList.Data)-1].ID)
+		more = true
 	}
 
 	return
@@ -72,24 +66,9 @@ func (paginator *listJobEventsPaginator) Continue(req *http.Request, res *http.R
 
 	paginator.listJobEventsReturn = append(paginator.listJobEventsReturn, eventList)
 
-	if eventList.HasMore {
-		var after int
-		var limit int
-
-		after, err = strconv.Atoi(req.Header.Get("after"))
-		if err != nil {
-			return
-		}
-
-		limit, err = strconv.Atoi(req.Header.Get("limit"))
-		if err != nil {
-			return
-		}
-
-		newAfter := strconv.Itoa(after + limit)
-		req.Header.Set("after", newAfter)
-
-		http.DefaultClient.Do(req)
+	if eventList.HasMore && len(eventList.Data) > 0 {
+		nextReq = nextPage(req, eventList.Data[len(eventList.Data)-1].ID)
+		more = true
 	}
 
 	return
@@ -101,18 +80,7 @@ func CancelJob(id string) (resp Job, err error) {
         return
     }
 
-	route := BaseFineTuningRoute + "/jobs/" + id + "/cancel"
-	buf, err := api.GenericRequest(nil, nil, route, "POST", p.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-	err = json.Unmarshal(buf, &resp)
-	if err != nil {
-		return
-	}
-
-	return
+	return api.NewClient(p).CancelFineTuningJob(context.Background(), id)
 }
 
 func GetJob(id string) (resp Job, err error) {
@@ -121,18 +89,7 @@ func GetJob(id string) (resp Job, err error) {
         return
     }
 
-	route := BaseFineTuningRoute + "/jobs/" + id
-	buf, err := api.GenericRequest(nil, nil, route, "GET", p.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-	err = json.Unmarshal(buf, &resp)
-	if err != nil {
-		return
-	}
-
-	return
+	return api.NewClient(p).RetrieveFineTuningJob(context.Background(), id)
 }
 
 // List all fine-tune jobs on the API.
@@ -145,7 +102,7 @@ func ListJobs() (resp []Job, err error) {
 	paginator := listJobsPaginator{}
 	route := BaseFineTuningRoute + "/jobs"
 
-	err = api.GenericPaginatedRequest(&paginator, defaultPaginationQueryParameters, nil, route, "GET", p.OverrideUrl())
+	err = api.GenericPaginatedRequest(&paginator, defaultPaginationQueryParameters, nil, route, "GET", p)
 	if err != nil {
 		return
 	}
@@ -166,7 +123,7 @@ func ListEvents(id string) (resp []JobEvent, err error) {
 	paginator := listJobEventsPaginator{}
 	route := BaseFineTuningRoute + "/jobs/" + id + "/events"
 
-	err = api.GenericPaginatedRequest(&paginator, defaultPaginationQueryParameters, nil, route, "GET", p.OverrideUrl())
+	err = api.GenericPaginatedRequest(&paginator, defaultPaginationQueryParameters, nil, route, "GET", p)
 	if err != nil {
 		return
 	}
@@ -192,21 +149,5 @@ func CreateJob(id string) (resp Job, err error) {
 		p.CreateFineTuneBody.TrainingFile = id
 	}
 
-	reqBuf, err := json.Marshal(p.CreateFineTuneBody)
-	if err != nil {
-		return
-	}
-
-	route := BaseFineTuningRoute + "/jobs"
-	buf, err := api.GenericRequest(nil, reqBuf, route, "POST", p.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-	err = json.Unmarshal(buf, &resp)
-	if err != nil {
-		return
-	}
-
-	return
+	return api.NewClient(p).CreateFineTuningJob(context.Background(), p.CreateFineTuneBody)
 }
@@ -11,6 +11,7 @@ type FineTuningProfile struct {
 	ProfileName        string
 	CreateFineTuneBody CreateFineTuneBody
     Url                string
+    profile.Options
 }
 
 func (p FineTuningProfile) Name() string {
@@ -3,6 +3,7 @@ package image
 import (
 	"encoding/json"
 
+	"github.com/ephex2/go-gpt-cli/config"
 	"github.com/ephex2/go-gpt-cli/config/profile"
 )
 
@@ -29,7 +30,9 @@ func (ie imageEndpoint) DefaultProfile() profile.Profile {
 func (ie imageEndpoint) ProfileFromJsonBuf(buf []byte) (p profile.Profile, err error) {
 	var iProf ImageProfile
 	err = json.Unmarshal(buf, &iProf)
-	// if err != nil { return }
+	if err != nil {
+		return
+	}
 
 	p = iProf
 	return
@@ -37,5 +40,45 @@ func (ie imageEndpoint) ProfileFromJsonBuf(buf []byte) (p profile.Profile, err e
 
 // This function is meant to be called by the init function in the audio cobra package
 func Init() {
-	profile.EndpointRegistry.Add(iEndpoint)
+	Register(&profile.EndpointRegistry)
+}
+
+// Adds the endpoint to a registry other than profile.EndpointRegistry, such as the registry of an app.Runtime.
+func Register(r *profile.Registry) {
+	r.Add(iEndpoint)
+}
+
+func (ie imageEndpoint) ValidateProfile(p profile.Profile) (errs profile.ValidationErrors) {
+	ip, ok := p.(ImageProfile)
+	if !ok {
+		return
+	}
+
+	checkPtr := func(path string, value *string, allowed []string) {
+		if value != nil {
+			errs.CheckAllowed(path, *value, allowed)
+		}
+	}
+
+	// Other vendors have their own models
+	openAI := config.TargetsOpenAI(ip)
+	body := ip.CreateImageBody
+	if openAI {
+		checkPtr("CreateImageBody.model", body.Model, []string{"dall-e-2"})
+	}
+	checkPtr("CreateImageBody.size", body.Size, AllowedDalle2Sizes)
+	checkPtr("CreateImageBody.response_format", body.ResponseFormat, AllowedImageResponseFormats)
+
+	dalle3 := ip.CreateDalle3ImageBody
+	if openAI {
+		checkPtr("CreateDalle3ImageBody.model", dalle3.Model, []string{"dall-e-3"})
+	}
+	checkPtr("CreateDalle3ImageBody.size", dalle3.Size, AllowedDalle3Sizes)
+	checkPtr("CreateDalle3ImageBody.response_format", dalle3.ResponseFormat, AllowedImageResponseFormats)
+	checkPtr("CreateDalle3ImageBody.quality", dalle3.Quality, AllowedDalle3Qualities)
+	checkPtr("CreateDalle3ImageBody.style", dalle3.Style, AllowedDalle3Styles)
+	if dalle3.N != nil && *dalle3.N != 1 {
+		errs = append(errs, profile.ValidationError{Path: "CreateDalle3ImageBody.n", Message: "dall-e-3 only supports n=1"})
+	}
+	return
 }
@@ -2,6 +2,7 @@ package image
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -40,19 +41,7 @@ func CreateImage(folderPath string, prompt []string) (ImagePaths []string, revis
 	}
 
 	imageProfile.CreateImageBody.Prompt = msg
-	reqBody, err := json.Marshal(imageProfile.CreateImageBody)
-	if err != nil {
-		return
-	}
-
-	route := BaseImageRoute + createImageRoute
-	buf, err := api.GenericRequest(nil, reqBody, route, "POST", imageProfile.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-	var imageResponse CreateImageResponse
-	err = json.Unmarshal(buf, &imageResponse)
+	imageResponse, err := api.NewClient(imageProfile).CreateImage(context.Background(), imageProfile.CreateImageBody)
 	if err != nil {
 		return
 	}
@@ -85,19 +74,7 @@ func CreateDalle3Image(folderPath string, prompt []string) (ImagePaths []string,
 	}
 
 	imageProfile.CreateDalle3ImageBody.Prompt = msg
-	reqBody, err := json.Marshal(imageProfile.CreateDalle3ImageBody)
-	if err != nil {
-		return
-	}
-
-	route := BaseImageRoute + createImageRoute
-	buf, err := api.GenericRequest(nil, reqBody, route, "POST", imageProfile.OverrideUrl())
-	if err != nil {
-		return
-	}
-
-	var imageResponse CreateImageResponse
-	err = json.Unmarshal(buf, &imageResponse)
+	imageResponse, err := api.NewClient(imageProfile).CreateDalle3Image(context.Background(), imageProfile.CreateDalle3ImageBody)
 	if err != nil {
 		return
 	}
@@ -160,7 +137,7 @@ func CreateEdit(filePath string, mask *os.File, folderPath string, prompt []stri
 	}
 
 	route := BaseImageRoute + editImageRoute
-	buf, err := api.MultiPartFormRequest(details, fieldMap, route, "POST", imageProfile.OverrideUrl())
+	buf, err := api.MultiPartFormRequest(details, fieldMap, route, "POST", imageProfile)
 	if err != nil {
 		return
 	}
@@ -217,7 +194,7 @@ func CreateVariation(filePath string, folderPath string) (imagePaths []string, e
 		},
 	}
 
-	buf, err := api.MultiPartFormRequest(details, fieldMap, route, "POST", imageProfile.OverrideUrl())
+	buf, err := api.MultiPartFormRequest(details, fieldMap, route, "POST", imageProfile)
 	if err != nil {
 		return
 	}
@@ -340,7 +317,7 @@ func downloadImage(url string, filePath string) (err error) {
 		return
 	}
 
-	response, err := http.DefaultClient.Do(r)
+	response, err := api.Do(r)
 	if err != nil {
 		return
 	}
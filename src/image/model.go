@@ -1,5 +1,7 @@
 package image
 
+import "github.com/ephex2/go-gpt-cli/client"
+
 // Note that the Create images endpoint seems to differ in its parameters for dall-e-2 and dall-e-3.
 var CreateImageEditBody = map[string]string{
 	"model": "dall-e-2",
@@ -15,38 +17,19 @@ var CreateVariationBody = map[string]string{
 	"user":  "go-gpt-cli",
 }
 
-type CreateImageBody struct {
-	Prompt         string  `json:"prompt"`
-	Model          *string `json:"model,omitempty"`
-	N              *int    `json:"n,omitempty"`
-	ResponseFormat *string `json:"response_format,omitempty"`
-	Size           *string `json:"size,omitempty"`
-	User           string  `json:"user,omitempty"`
-    Mode           *int    `json:"mode,omitempty"`
-    Step           *int    `json:"step,omitempty"`
-}
-
-type CreateDalle3ImageBody struct {
-	Prompt         string  `json:"prompt"`
-	Model          *string `json:"model,omitempty"`
-	N              *int    `json:"n,omitempty"`
-	Quality        *string `json:"quality,omitempty"`
-	ResponseFormat *string `json:"response_format,omitempty"`
-	Size           *string `json:"size,omitempty"`
-	Style          *string `json:"style,omitempty"`
-	User           string  `json:"user,omitempty"`
-}
-
-type CreateImageResponse struct {
-	Created int             `json:"created"`
-	Data    []ImageResponse `json:"data"`
-}
-
-type ImageResponse struct {
-	Url           string `json:"url,omitempty"`
-	B64Json       string `json:"b64_json,omitempty"`
-	RevisedPrompt string `json:"revised_prompt,omitempty"`
-}
+var AllowedDalle2Sizes = []string{"256x256", "512x512", "1024x1024"}
+var AllowedDalle3Sizes = []string{"1024x1024", "1792x1024", "1024x1792"}
+var AllowedImageResponseFormats = []string{"url", "b64_json"}
+var AllowedDalle3Qualities = []string{"standard", "hd"}
+var AllowedDalle3Styles = []string{"vivid", "natural"}
+
+// Request and response types are the ones of the client package.
+type (
+	CreateImageBody       = client.ImageRequest
+	CreateDalle3ImageBody = client.Dalle3ImageRequest
+	CreateImageResponse   = client.ImagesResponse
+	ImageResponse         = client.Image
+)
 
 func GetDefaultCreateImageBody() CreateImageBody {
 	model := new(string)
@@ -14,6 +14,7 @@ type ImageProfile struct {
 	CreateEditBody        map[string]string
 	CreateVariationBody   map[string]string
     Url                   string
+    profile.Options
 }
 
 func (ip ImageProfile) Name() string {
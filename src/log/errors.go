@@ -8,7 +8,7 @@ import (
 type InvalidLogError string
 
 func (e InvalidLogError) Error() string {
-    return fmt.Sprintf("log: Invalid LogLevel: %s. Please use one of [Debug,Info,Warning,Critical]", e)
+    return fmt.Sprintf("log: Invalid LogLevel: %s. Please use one of [Debug,Info,Warning,Critical]", string(e))
 }
 
 func (e InvalidLogError) Timeout() bool {
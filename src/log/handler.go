@@ -0,0 +1,190 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ephex2/go-gpt-cli/color"
+)
+
+const redacted string = "[REDACTED]"
+
+var (
+	bearerPattern  = regexp.MustCompile(`(?i)(bearer\s+)[^\s"'\],}]+`)
+	keyPattern     = regexp.MustCompile(`(?i)((?:api[-_]?key|x-api-key|authorization)["']?\s*[:=]\s*\[?["']?)[^\s"'\],}]+`)
+	openAIKey      = regexp.MustCompile(`sk-[A-Za-z0-9_-]{8,}`)
+	dataUrlPattern = regexp.MustCompile(`data:([\w/+.-]+);base64,[A-Za-z0-9+/=]+`)
+	base64Pattern  = regexp.MustCompile(`[A-Za-z0-9+/]{200,}={0,2}`)
+)
+
+// Attributes whose value is always redacted, compared in lower case.
+var secretKeys = []string{"authorization", "api-key", "api_key", "apikey", "x-api-key", "password", "passphrase", "token"}
+
+// Headers whose name contains one of these words are redacted, compared in lower case, as are the headers registered
+// with AddSecretHeader.
+var secretHeaderWords = []string{"authorization", "key", "token", "secret", "cookie", "password", "passphrase"}
+
+var secretHeaders sync.Map
+
+// Registers a header holding a secret under a name which does not look like one, such as the header of the header auth
+// scheme, ex: Ocp-Apim-Subscription-Id.
+func AddSecretHeader(name string) {
+	secretHeaders.Store(strings.ToLower(name), true)
+}
+
+// Returns true when the value of a header should not be logged, see secretHeaderWords and AddSecretHeader.
+func IsSecretHeader(name string) bool {
+	lower := strings.ToLower(name)
+	if _, ok := secretHeaders.Load(lower); ok {
+		return true
+	}
+
+	for _, word := range secretHeaderWords {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Removes secrets and payloads from a log message: bearer tokens, api keys, authorization headers, and base64
+// payloads such as images and audio, which are replaced by their size.
+func Redact(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "${1}"+redacted)
+	s = keyPattern.ReplaceAllString(s, "${1}"+redacted)
+	s = openAIKey.ReplaceAllString(s, "sk-"+redacted)
+	s = dataUrlPattern.ReplaceAllStringFunc(s, func(m string) string {
+		mime, payload, _ := strings.Cut(strings.TrimPrefix(m, "data:"), ";base64,")
+		return "data:" + mime + ";base64,[" + strconv.Itoa(len(payload)) + " base64 characters]"
+	})
+	s = base64Pattern.ReplaceAllStringFunc(s, func(m string) string {
+		return "[" + strconv.Itoa(len(m)) + " base64 characters]"
+	})
+
+	return s
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range secretKeys {
+		if key == k {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns headers as log attributes, with the values of authentication headers redacted.
+func Headers(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		value := strings.Join(v, ", ")
+		if IsSecretHeader(k) {
+			value = redacted
+		}
+
+		out[k] = Redact(value)
+	}
+
+	return out
+}
+
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if isSecretKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+
+	return a
+}
+
+var levelPrefixes = map[slog.Level]string{
+	slog.LevelDebug: "DEBUG: ",
+	slog.LevelInfo:  "INFO: ",
+	slog.LevelWarn:  "WARNING: ",
+	slog.LevelError: "ERROR: ",
+}
+
+var levelColors = map[slog.Level][]byte{
+	slog.LevelDebug: color.Blue,
+	slog.LevelInfo:  color.Green,
+	slog.LevelWarn:  color.Yellow,
+	slog.LevelError: color.BrightRed,
+}
+
+// Writes records as lines of text: level, time, message, then attributes as key=value. The message is colored when
+// written to stderr.
+type textHandler struct {
+	w       io.Writer
+	colored bool
+	attrs   []slog.Attr
+	mu      *sync.Mutex
+}
+
+func (h *textHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (h *textHandler) Handle(ctx context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+	buf.WriteString(levelPrefixes[r.Level])
+	buf.WriteString(r.Time.Format("2006/01/02 15:04:05 "))
+
+	if h.colored {
+		buf.WriteString(color.ColorSprintf(levelColors[r.Level], "%s", r.Message))
+	} else {
+		buf.WriteString(r.Message)
+	}
+
+	writeAttr := func(a slog.Attr) bool {
+		a = redactAttr(nil, a)
+		value := a.Value.String()
+		if strings.ContainsAny(value, " \"=\n") {
+			value = strconv.Quote(value)
+		}
+
+		fmt.Fprintf(&buf, " %s=%s", a.Key, value)
+		return true
+	}
+
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
+	buf.WriteByte('\n')
+
+	if h.mu != nil {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+	}
+
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &textHandler{w: h.w, colored: h.colored, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...), mu: h.mu}
+}
+
+// Groups are not used by the CLI, their attributes are written without a prefix.
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	return h
+}
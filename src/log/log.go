@@ -1,13 +1,21 @@
 package log
 
+// Logs are leveled records, written as text or as json lines to stderr or to a rotating log file. Messages and
+// attributes are redacted before being written, see Redact, so that api keys and payloads never reach a log.
+//
+// Debug, Info, Warning and Critical format their message like fmt.Printf, Log records a message along with attributes
+// given as key value pairs, as with log/slog.
+
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
-
-	"github.com/ephex2/go-gpt-cli/color"
+	"strings"
+	"sync"
 )
 
 const (
@@ -17,101 +25,159 @@ const (
 	LevelDebug
 )
 
-var logLevel = LevelInfo
+const (
+	FormatText string = "text"
+	FormatJson string = "json"
+)
+
+// Environment variables configuring logs when the matching flag is not set.
+const (
+	LevelEnv  string = "GO_GPT_CLI_LOG_LEVEL"
+	FormatEnv string = "GO_GPT_CLI_LOG_FORMAT"
+	FileEnv   string = "GO_GPT_CLI_LOG_FILE"
+)
+
+var levelNames = map[string]int{
+	"critical": LevelCritical,
+	"error":    LevelCritical,
+	"warning":  LevelWarning,
+	"warn":     LevelWarning,
+	"info":     LevelInfo,
+	"debug":    LevelDebug,
+}
 
-var logWriter io.Writer
+var slogLevels = map[int]slog.Level{
+	LevelCritical: slog.LevelError,
+	LevelWarning:  slog.LevelWarn,
+	LevelInfo:     slog.LevelInfo,
+	LevelDebug:    slog.LevelDebug,
+}
 
-var debugLogger *log.Logger
-var infoLogger *log.Logger
-var warningLogger *log.Logger
-var criticalLogger *log.Logger
+var (
+	mu        sync.Mutex
+	logLevel  = LevelInfo
+	logFormat = FormatText
+	logWriter io.Writer = os.Stderr // Diagnostics go to stderr, stdout holds the results of commands, see package output
+	logFile   *rotatingFile
+	logger    *slog.Logger
+)
 
-// var Logger *log.Logger
 func SetLogLevel(level int) (e error) {
-	switch level {
-	case LevelCritical:
-		logLevel = LevelCritical
-	case LevelWarning:
-		logLevel = LevelWarning
-	case LevelInfo:
-		logLevel = LevelInfo
-	case LevelDebug:
-		logLevel = LevelDebug
-	default:
-		e = InvalidLogError(strconv.Itoa(level))
+	if _, ok := slogLevels[level]; !ok {
+		return InvalidLogError(strconv.Itoa(level))
 	}
 
+	mu.Lock()
+	logLevel = level
+	mu.Unlock()
 	return
 }
 
-func Debug(s string, a ...any) {
-	var input string
+// Returns the level named debug, info, warning or critical.
+func ParseLevel(name string) (level int, err error) {
+	level, ok := levelNames[strings.ToLower(name)]
+	if !ok {
+		err = InvalidLogError(name)
+	}
 
-	if logLevel >= LevelDebug {
-		if logWriter == os.Stdout {
-			input = color.ColorSprintf(color.Blue, s, a...)
-		} else {
-			input = fmt.Sprintf(s, a...)
-		}
+	return
+}
 
-		debugLogger.Print(input)
+// Selects the text or json format of records.
+func SetFormat(format string) error {
+	if format != FormatText && format != FormatJson {
+		return errors.New("unknown log format " + format + ", expected one of: " + FormatText + ", " + FormatJson)
 	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	logFormat = format
+	logger = newLogger()
+	return nil
 }
 
-func Info(s string, a ...any) {
-	var input string
+// Writes records to a log file rotated once it reaches maxFileSize, or to stderr when path is empty.
+func SetFile(path string) (err error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if logFile != nil {
+		logFile.Close()
+		logFile = nil
+	}
 
-	if logLevel >= LevelInfo {
-		if logWriter == os.Stdout {
-			input = color.ColorSprintf(color.Green, s, a...)
-		} else {
-			input = fmt.Sprintf(s, a...)
+	logWriter = os.Stderr
+	if path != "" {
+		logFile, err = openRotatingFile(path)
+		if err != nil {
+			return
 		}
 
-		infoLogger.Print(input)
+		logWriter = logFile
 	}
+
+	logger = newLogger()
+	return
 }
 
-func Warning(s string, a ...any) {
-	var input string
+// Closes the log file, if any.
+func Close() error {
+	return SetFile("")
+}
 
-	if logLevel >= LevelWarning {
-		if logWriter == os.Stdout {
-			input = color.ColorSprintf(color.Yellow, s, a...)
-		} else {
-			input = fmt.Sprintf(s, a...)
-		}
+func newLogger() *slog.Logger {
+	if logFormat == FormatJson {
+		return slog.New(slog.NewJSONHandler(logWriter, &slog.HandlerOptions{
+			Level:       slog.LevelDebug, // Levels are checked before records are built
+			ReplaceAttr: redactAttr,
+		}))
+	}
+
+	return slog.New(&textHandler{w: logWriter, colored: logWriter == io.Writer(os.Stderr), mu: &sync.Mutex{}})
+}
 
-		warningLogger.Print(input)
+func enabled(level int) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return logLevel >= level
+}
+
+// Records a message with attributes given as key value pairs, ex: Log(LevelDebug, "response", "status", 200).
+func Log(level int, msg string, args ...any) {
+	if !enabled(level) {
+		return
 	}
+
+	mu.Lock()
+	l := logger
+	mu.Unlock()
+
+	l.Log(context.Background(), slogLevels[level], Redact(strings.TrimSuffix(msg, "\n")), args...)
 }
 
-func Critical(s string, a ...any) {
-	var input string
+func Debug(s string, a ...any) {
+	if enabled(LevelDebug) {
+		Log(LevelDebug, fmt.Sprintf(s, a...))
+	}
+}
 
-	if logLevel >= LevelCritical {
-		if logWriter == os.Stdout {
-			input = color.ColorSprintf(color.BrightRed, s, a...)
-		} else {
-			input = fmt.Sprintf(s, a...)
-		}
+func Info(s string, a ...any) {
+	if enabled(LevelInfo) {
+		Log(LevelInfo, fmt.Sprintf(s, a...))
 	}
+}
 
-	criticalLogger.Print(input)
+func Warning(s string, a ...any) {
+	if enabled(LevelWarning) {
+		Log(LevelWarning, fmt.Sprintf(s, a...))
+	}
+}
+
+func Critical(s string, a ...any) {
+	Log(LevelCritical, fmt.Sprintf(s, a...))
 }
 
 func init() {
-	logWriter = os.Stdout
-
-	debugLogger = log.New(logWriter, "DEBUG: ", log.Ldate|log.Ltime)
-	infoLogger = log.New(logWriter, "INFO: ", log.Ldate|log.Ltime)
-	warningLogger = log.New(logWriter, "WARING: ", log.Ldate|log.Ltime)
-	criticalLogger = log.New(logWriter, "ERROR: ", log.Ldate|log.Ltime)
-	/*
-	   var err error
-	   logWriter, err = os.Create("placeholder")
-	   if err != nil {
-	       panic(err.Error())
-	   }
-	*/
+	logger = newLogger()
 }
@@ -0,0 +1,92 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// Log files are rotated once they reach maxFileSize: file.log is renamed to file.log.1, file.log.1 to file.log.2 and
+// so on, keeping at most maxFileBackups previous files.
+const (
+	maxFileSize    int64 = 10 * 1024 * 1024
+	maxFileBackups int   = 3
+)
+
+type rotatingFile struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	size int64
+}
+
+func openRotatingFile(path string) (rf *rotatingFile, err error) {
+	err = os.MkdirAll(filepath.Dir(path), 0750)
+	if err != nil {
+		return
+	}
+
+	rf = &rotatingFile{path: path}
+	err = rf.open()
+	return
+}
+
+func (rf *rotatingFile) open() (err error) {
+	rf.f, err = os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+
+	info, err := rf.f.Stat()
+	if err != nil {
+		return
+	}
+
+	rf.size = info.Size()
+	return
+}
+
+func (rf *rotatingFile) Write(p []byte) (n int, err error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.size > 0 && rf.size+int64(len(p)) > maxFileSize {
+		err = rf.rotate()
+		if err != nil {
+			return
+		}
+	}
+
+	n, err = rf.f.Write(p)
+	rf.size += int64(n)
+	return
+}
+
+func (rf *rotatingFile) rotate() (err error) {
+	err = rf.f.Close()
+	if err != nil {
+		return
+	}
+
+	for i := maxFileBackups - 1; i >= 1; i-- {
+		err = os.Rename(rf.path+"."+strconv.Itoa(i), rf.path+"."+strconv.Itoa(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return
+		}
+	}
+
+	err = os.Rename(rf.path, rf.path+".1")
+	if err != nil {
+		return
+	}
+
+	return rf.open()
+}
+
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	return rf.f.Close()
+}
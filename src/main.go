@@ -1,6 +1,8 @@
 package main
 
 import (
+"os"
+
 "github.com/ephex2/go-gpt-cli/cmd"
 "github.com/ephex2/go-gpt-cli/log"
 )
@@ -9,5 +11,6 @@ func main() {
     err := cmd.Execute()
     if err != nil {
         log.Critical(err.Error() + "\n")
+        os.Exit(1)
     }
 }
@@ -0,0 +1,663 @@
+package mock
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/batches"
+	"github.com/ephex2/go-gpt-cli/chat"
+	"github.com/ephex2/go-gpt-cli/embeddings"
+	"github.com/ephex2/go-gpt-cli/file"
+	"github.com/ephex2/go-gpt-cli/finetuning"
+	cliimage "github.com/ephex2/go-gpt-cli/image"
+	"github.com/ephex2/go-gpt-cli/model"
+)
+
+const cannedContent = "This is a response from the go-gpt-cli mock server."
+
+// Images generated with the url response format are served by the mock server itself under this route.
+const imageDownloadRoute = "/mock/images/"
+
+const defaultEmbeddingDimensions = 8
+
+var mockModels = []string{
+	"gpt-3.5-turbo",
+	"gpt-4-vision-preview",
+	"text-embedding-3-small",
+	"text-embedding-3-large",
+	"text-embedding-ada-002",
+	"whisper-1",
+	"tts-1",
+	"tts-1-hd",
+	"dall-e-2",
+	"dall-e-3",
+}
+
+// Reads the body of a request and replaces it so that it can be parsed again by handlers, for example as a multipart form.
+func readBody(r *http.Request) (body []byte, err error) {
+	if r.Body == nil {
+		return
+	}
+
+	body, err = io.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+	r.Body.Close()
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return
+}
+
+func decodeJsonBody(body []byte) (m map[string]any) {
+	m = make(map[string]any)
+	json.Unmarshal(body, &m)
+	return
+}
+
+func stringField(m map[string]any, key string, fallback string) string {
+	if s, ok := m[key].(string); ok && s != "" {
+		return s
+	}
+
+	return fallback
+}
+
+func intField(m map[string]any, key string, fallback int) int {
+	if f, ok := m[key].(float64); ok {
+		return int(f)
+	}
+
+	return fallback
+}
+
+func (s *Server) content(echo string) string {
+	if s.options.Mode == ModeEcho && echo != "" {
+		return echo
+	}
+
+	return cannedContent
+}
+
+// Returns the text content of the last user message, supporting both plain and vision messages.
+func lastUserMessage(body map[string]any) string {
+	messages, _ := body["messages"].([]any)
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg, _ := messages[i].(map[string]any)
+		if msg["role"] != "user" {
+			continue
+		}
+
+		switch content := msg["content"].(type) {
+		case string:
+			return content
+		case []any:
+			var texts []string
+			for _, part := range content {
+				p, _ := part.(map[string]any)
+				if text, ok := p["text"].(string); ok {
+					texts = append(texts, text)
+				}
+			}
+
+			return strings.Join(texts, " ")
+		}
+	}
+
+	return ""
+}
+
+func (s *Server) chatCompletions(w http.ResponseWriter, r *http.Request, body []byte) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r)
+		return
+	}
+
+	req := decodeJsonBody(body)
+	modelName := stringField(req, "model", mockModels[0])
+	content := s.content(lastUserMessage(req))
+
+	s.mu.Lock()
+	id := s.newId("chatcmpl")
+	s.mu.Unlock()
+
+	if stream, _ := req["stream"].(bool); stream {
+		streamCompletion(w, id, modelName, content)
+		return
+	}
+
+	n := intField(req, "n", 1)
+	resp := chat.CompletionResponse{
+		Id:      id,
+		Object:  "chat.completion",
+		Created: int(time.Now().Unix()),
+		Model:   modelName,
+		Usage: chat.Usage{
+			PromptTokens:     len(strings.Fields(lastUserMessage(req))),
+			CompletionTokens: len(strings.Fields(content)),
+		},
+	}
+	resp.Usage.TotalTokens = resp.Usage.PromptTokens + resp.Usage.CompletionTokens
+
+	for i := 0; i < n; i++ {
+		resp.Choices = append(resp.Choices, chat.Choice{
+			Index:   i,
+			Message: chat.Message{Role: "assistant", Content: content},
+		})
+	}
+
+	writeJson(w, http.StatusOK, resp)
+}
+
+// Streams the content word by word as server-sent events, the same way the chat completions endpoint does when 'stream' is true.
+func streamCompletion(w http.ResponseWriter, id string, modelName string, content string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	chunk := func(delta map[string]any, finishReason any) {
+		buf, _ := json.Marshal(map[string]any{
+			"id":      id,
+			"object":  "chat.completion.chunk",
+			"created": time.Now().Unix(),
+			"model":   modelName,
+			"choices": []any{
+				map[string]any{"index": 0, "delta": delta, "finish_reason": finishReason},
+			},
+		})
+
+		fmt.Fprintf(w, "data: %s\n\n", buf)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	chunk(map[string]any{"role": "assistant", "content": ""}, nil)
+	for i, word := range strings.Fields(content) {
+		if i > 0 {
+			word = " " + word
+		}
+
+		chunk(map[string]any{"content": word}, nil)
+	}
+	chunk(map[string]any{}, "stop")
+
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+func (s *Server) embeddings(w http.ResponseWriter, r *http.Request, body []byte) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r)
+		return
+	}
+
+	req := decodeJsonBody(body)
+	dimensions := intField(req, "dimensions", defaultEmbeddingDimensions)
+
+	var inputs []string
+	switch input := req["input"].(type) {
+	case string:
+		inputs = []string{input}
+	case []any:
+		for _, v := range input {
+			inputs = append(inputs, fmt.Sprint(v))
+		}
+	}
+
+	resp := embeddings.CreateEmbeddingResponse{
+		Object: "list",
+		Model:  stringField(req, "model", embeddings.AllowedEncodingModels.TextEmbedding3Small),
+	}
+
+	for i, input := range inputs {
+		resp.Data = append(resp.Data, embeddings.Embedding{
+			Object:    "embedding",
+			Embedding: embeddingVector(input, dimensions),
+			Index:     i,
+		})
+		resp.Usage.PromptTokens += len(strings.Fields(input))
+	}
+	resp.Usage.TotalTokens = resp.Usage.PromptTokens
+
+	writeJson(w, http.StatusOK, resp)
+}
+
+// Embeddings are derived from a hash of the input so that identical inputs always produce identical vectors.
+func embeddingVector(input string, dimensions int) []float64 {
+	vector := make([]float64, dimensions)
+	sum := sha256.Sum256([]byte(input))
+
+	for i := range vector {
+		b := sum[i%len(sum)] ^ byte(i/len(sum))
+		vector[i] = float64(b)/127.5 - 1
+	}
+
+	return vector
+}
+
+func (s *Server) speech(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "audio/wav")
+	w.WriteHeader(http.StatusOK)
+	w.Write(silentWav())
+}
+
+// Returns a tenth of a second of silence as a 16 bit mono wav file.
+func silentWav() []byte {
+	const sampleRate = 8000
+	data := make([]byte, sampleRate/10*2)
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("RIFF")
+	binary.Write(buf, binary.LittleEndian, uint32(36+len(data)))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(buf, binary.LittleEndian, uint32(16))
+	binary.Write(buf, binary.LittleEndian, uint16(1)) // PCM
+	binary.Write(buf, binary.LittleEndian, uint16(1)) // mono
+	binary.Write(buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(buf, binary.LittleEndian, uint32(sampleRate*2))
+	binary.Write(buf, binary.LittleEndian, uint16(2))
+	binary.Write(buf, binary.LittleEndian, uint16(16))
+	buf.WriteString("data")
+	binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+
+	return buf.Bytes()
+}
+
+func (s *Server) transcription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r)
+		return
+	}
+
+	err := r.ParseMultipartForm(32 << 20)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "unable to parse multipart form: "+err.Error())
+		return
+	}
+
+	echo := r.FormValue("prompt")
+	if echo == "" {
+		if _, header, err := r.FormFile("file"); err == nil {
+			echo = header.Filename
+		}
+	}
+
+	text := s.content(echo)
+	task := "transcribe"
+	if strings.HasSuffix(r.URL.Path, "translations") {
+		task = "translate"
+	}
+
+	switch r.FormValue("response_format") {
+	case "text":
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(text + "\n"))
+	case "srt":
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("1\n00:00:00,000 --> 00:00:01,000\n" + text + "\n"))
+	case "vtt":
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("WEBVTT\n\n00:00:00.000 --> 00:00:01.000\n" + text + "\n"))
+	case "verbose_json":
+		language := r.FormValue("language")
+		if language == "" {
+			language = "en"
+		}
+
+		writeJson(w, http.StatusOK, map[string]any{
+			"task":     task,
+			"language": language,
+			"duration": 1.0,
+			"text":     text,
+			"segments": []any{
+				map[string]any{"id": 0, "seek": 0, "start": 0.0, "end": 1.0, "text": text, "tokens": []int{}, "temperature": 0.0},
+			},
+		})
+	default:
+		writeJson(w, http.StatusOK, map[string]string{"text": text})
+	}
+}
+
+func (s *Server) imageGenerations(w http.ResponseWriter, r *http.Request, body []byte) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r)
+		return
+	}
+
+	req := decodeJsonBody(body)
+	s.writeImages(w, r, intField(req, "n", 1), stringField(req, "response_format", "url"), stringField(req, "prompt", ""))
+}
+
+func (s *Server) imageEdits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r)
+		return
+	}
+
+	err := r.ParseMultipartForm(32 << 20)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "unable to parse multipart form: "+err.Error())
+		return
+	}
+
+	n, err := strconv.Atoi(r.FormValue("n"))
+	if err != nil {
+		n = 1
+	}
+
+	format := r.FormValue("response_format")
+	if format == "" {
+		format = "url"
+	}
+
+	s.writeImages(w, r, n, format, r.FormValue("prompt"))
+}
+
+func (s *Server) writeImages(w http.ResponseWriter, r *http.Request, n int, format string, prompt string) {
+	resp := cliimage.CreateImageResponse{Created: int(time.Now().Unix())}
+
+	for i := 0; i < n; i++ {
+		data := cliimage.ImageResponse{}
+		if prompt != "" && s.options.Mode == ModeEcho {
+			data.RevisedPrompt = prompt
+		}
+
+		switch format {
+		case "b64_json":
+			data.B64Json = base64.StdEncoding.EncodeToString(placeholderPng())
+		default:
+			s.mu.Lock()
+			id := s.newId("img")
+			s.mu.Unlock()
+
+			data.Url = "http://" + r.Host + imageDownloadRoute + id + ".png"
+		}
+
+		resp.Data = append(resp.Data, data)
+	}
+
+	writeJson(w, http.StatusOK, resp)
+}
+
+func (s *Server) imageDownload(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "image/png")
+	w.WriteHeader(http.StatusOK)
+	w.Write(placeholderPng())
+}
+
+func placeholderPng() []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for x := 0; x < 2; x++ {
+		for y := 0; y < 2; y++ {
+			img.Set(x, y, color.RGBA{R: 16, G: 163, B: 127, A: 255})
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	png.Encode(buf, img)
+	return buf.Bytes()
+}
+
+func (s *Server) filesRoute(w http.ResponseWriter, r *http.Request, parts []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		list := file.FileList{Object: "list", Data: []file.File{}}
+		for _, f := range s.files {
+			list.Data = append(list.Data, f)
+		}
+		sort.Slice(list.Data, func(i, j int) bool { return list.Data[i].ID < list.Data[j].ID })
+
+		writeJson(w, http.StatusOK, list)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		err := r.ParseMultipartForm(32 << 20)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "unable to parse multipart form: "+err.Error())
+			return
+		}
+
+		upload, header, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "a file must be provided in the 'file' field")
+			return
+		}
+		defer upload.Close()
+
+		content, _ := io.ReadAll(upload)
+		f := file.File{
+			ID:        s.newId("file"),
+			Bytes:     len(content),
+			CreatedAt: int(time.Now().Unix()),
+			FileName:  header.Filename,
+			Object:    "file",
+			Purpose:   r.FormValue("purpose"),
+		}
+
+		s.files[f.ID] = f
+		s.contents[f.ID] = content
+		writeJson(w, http.StatusOK, f)
+	case len(parts) >= 1:
+		f, ok := s.files[parts[0]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "No such File object: "+parts[0])
+			return
+		}
+
+		switch {
+		case len(parts) == 1 && r.Method == http.MethodGet:
+			writeJson(w, http.StatusOK, f)
+		case len(parts) == 1 && r.Method == http.MethodDelete:
+			delete(s.files, f.ID)
+			delete(s.contents, f.ID)
+			writeJson(w, http.StatusOK, file.DeleteStatus{ID: f.ID, Object: "file", Deleted: true})
+		case len(parts) == 2 && parts[1] == "content" && r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusOK)
+			w.Write(s.contents[f.ID])
+		default:
+			methodNotAllowed(w, r)
+		}
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) batchesRoute(w http.ResponseWriter, r *http.Request, body []byte, parts []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		list := batches.BatchList{Object: "list", Data: []batches.Batch{}}
+		for _, b := range s.batches {
+			list.Data = append(list.Data, b)
+		}
+		sort.Slice(list.Data, func(i, j int) bool { return list.Data[i].ID < list.Data[j].ID })
+
+		if len(list.Data) > 0 {
+			list.FirstId = list.Data[0].ID
+			list.LastId = list.Data[len(list.Data)-1].ID
+		}
+
+		writeJson(w, http.StatusOK, list)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var req batches.CreateBatchBody
+		err := json.Unmarshal(body, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid batch request body: "+err.Error())
+			return
+		}
+
+		b := batches.Batch{
+			ID:               s.newId("batch"),
+			Object:           "batch",
+			Endpoint:         req.Endpoint,
+			InputFileID:      req.FileId,
+			CompletionWindow: req.CompletionWindow,
+			Status:           "validating",
+			CreatedAt:        time.Now().Unix(),
+			Metadata:         req.Metadata,
+		}
+
+		s.batches[b.ID] = b
+		writeJson(w, http.StatusOK, b)
+	case len(parts) >= 1:
+		b, ok := s.batches[parts[0]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "No batch found with id '"+parts[0]+"'.")
+			return
+		}
+
+		switch {
+		case len(parts) == 1 && r.Method == http.MethodGet:
+			writeJson(w, http.StatusOK, b)
+		case len(parts) == 2 && parts[1] == "cancel" && r.Method == http.MethodPost:
+			b.Status = "cancelled"
+			b.CancelledAt = time.Now().Unix()
+			s.batches[b.ID] = b
+			writeJson(w, http.StatusOK, b)
+		default:
+			methodNotAllowed(w, r)
+		}
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) fineTuningJobs(w http.ResponseWriter, r *http.Request, body []byte, parts []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		list := finetuning.JobList{Object: "list", Data: []finetuning.Job{}}
+		for _, job := range s.jobs {
+			list.Data = append(list.Data, job)
+		}
+		sort.Slice(list.Data, func(i, j int) bool { return list.Data[i].ID < list.Data[j].ID })
+
+		writeJson(w, http.StatusOK, list)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var req finetuning.CreateFineTuneBody
+		err := json.Unmarshal(body, &req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid fine-tuning request body: "+err.Error())
+			return
+		}
+
+		job := finetuning.Job{
+			ID:             s.newId("ftjob"),
+			CreatedAt:      int(time.Now().Unix()),
+			Model:          req.Model,
+			Object:         "fine_tuning.job",
+			OrganizationID: "org-mock",
+			ResultFiles:    []string{},
+			Status:         "queued",
+			TrainingFile:   req.TrainingFile,
+			ValidationFile: req.ValidationFile,
+		}
+
+		s.jobs[job.ID] = job
+		writeJson(w, http.StatusOK, job)
+	case len(parts) >= 1:
+		job, ok := s.jobs[parts[0]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Could not find fine tune job: "+parts[0])
+			return
+		}
+
+		switch {
+		case len(parts) == 1 && r.Method == http.MethodGet:
+			writeJson(w, http.StatusOK, job)
+		case len(parts) == 2 && parts[1] == "cancel" && r.Method == http.MethodPost:
+			job.Status = "cancelled"
+			s.jobs[job.ID] = job
+			writeJson(w, http.StatusOK, job)
+		case len(parts) == 2 && parts[1] == "events" && r.Method == http.MethodGet:
+			writeJson(w, http.StatusOK, finetuning.JobEventList{
+				Object: "list",
+				Data: []finetuning.JobEvent{
+					{
+						ID:        "ftevent-" + job.ID,
+						CreatedAt: job.CreatedAt,
+						Level:     "info",
+						Message:   "Created fine-tuning job: " + job.ID,
+						Object:    "fine_tuning.job.event",
+					},
+				},
+			})
+		default:
+			methodNotAllowed(w, r)
+		}
+	default:
+		methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) models(w http.ResponseWriter, r *http.Request, parts []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	newModel := func(id string) model.Model {
+		return model.Model{Id: id, Object: "model", Created: 1700000000, OwnedBy: "go-gpt-cli-mock"}
+	}
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		list := model.ListModelResponse{Object: "list", Data: []model.Model{}}
+		for _, id := range mockModels {
+			if !s.deleted[id] {
+				list.Data = append(list.Data, newModel(id))
+			}
+		}
+
+		writeJson(w, http.StatusOK, list)
+	case len(parts) == 1:
+		id := parts[0]
+		known := false
+		for _, m := range mockModels {
+			known = known || m == id
+		}
+
+		if !known || s.deleted[id] {
+			writeError(w, http.StatusNotFound, "The model '"+id+"' does not exist")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJson(w, http.StatusOK, newModel(id))
+		case http.MethodDelete:
+			s.deleted[id] = true
+			writeJson(w, http.StatusOK, model.DeleteModelResponse{Id: id, Object: "model", Deleted: true})
+		default:
+			methodNotAllowed(w, r)
+		}
+	default:
+		methodNotAllowed(w, r)
+	}
+}
@@ -0,0 +1,117 @@
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Scripts replace the built-in responses of the mock server for specific routes.
+// Responses are matched in order, the first response matching a request's method and route which has not been exhausted is served.
+type Script struct {
+	Responses []ScriptedResponse `json:"responses"`
+}
+
+type ScriptedResponse struct {
+	Method  string            `json:"method,omitempty"`  // Matches any method when empty
+	Route   string            `json:"route"`             // Exact path, or a path prefix when ending with '*'
+	Status  int               `json:"status,omitempty"`  // Defaults to 200
+	Headers map[string]string `json:"headers,omitempty"` // Content-Type defaults to application/json
+	Body    json.RawMessage   `json:"body,omitempty"`    // Written as-is when it is a json object or array, json strings are written unquoted
+	Latency string            `json:"latency,omitempty"` // Extra latency for this response, ex: "250ms"
+	Times   int               `json:"times,omitempty"`   // Number of times the response can be served, 0 for no limit
+}
+
+func LoadScript(path string) (script Script, err error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(buf, &script)
+	if err != nil {
+		err = errors.New("unable to parse mock script " + path + ": " + err.Error())
+		return
+	}
+
+	for _, response := range script.Responses {
+		if response.Route == "" {
+			err = errors.New("every response in mock script " + path + " must have a route")
+			return
+		}
+
+		if response.Latency != "" {
+			_, err = time.ParseDuration(response.Latency)
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+func (sr ScriptedResponse) matches(r *http.Request) bool {
+	if sr.Method != "" && !strings.EqualFold(sr.Method, r.Method) {
+		return false
+	}
+
+	if strings.HasSuffix(sr.Route, "*") {
+		return strings.HasPrefix(r.URL.Path, strings.TrimSuffix(sr.Route, "*"))
+	}
+
+	return sr.Route == r.URL.Path
+}
+
+// Writes the scripted response matching the request if there is one. Returns false when no scripted response applies.
+func (s *Server) serveScripted(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	match := -1
+	for i, response := range s.options.Script.Responses {
+		if !response.matches(r) {
+			continue
+		}
+
+		if response.Times > 0 && s.served[i] >= response.Times {
+			continue
+		}
+
+		match = i
+		s.served[i]++
+		break
+	}
+	s.mu.Unlock()
+
+	if match == -1 {
+		return false
+	}
+
+	response := s.options.Script.Responses[match]
+	if response.Latency != "" {
+		d, _ := time.ParseDuration(response.Latency)
+		time.Sleep(d)
+	}
+
+	status := response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	for k, v := range response.Headers {
+		w.Header().Set(k, v)
+	}
+
+	body := []byte(response.Body)
+	var text string
+	if json.Unmarshal(body, &text) == nil {
+		body = []byte(text)
+	}
+
+	w.WriteHeader(status)
+	w.Write(body)
+	return true
+}
@@ -0,0 +1,226 @@
+package mock
+
+// The mock package implements a server which answers the routes used by this CLI the same way an OpenAI compatible API would.
+// It can be started with the 'mock serve' command in order to point 'config seturl' at it, or used from Go code with httptest:
+//
+//	server := httptest.NewServer(mock.NewServer(mock.Options{}))
+//	defer server.Close()
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/batches"
+	"github.com/ephex2/go-gpt-cli/file"
+	"github.com/ephex2/go-gpt-cli/finetuning"
+	"github.com/ephex2/go-gpt-cli/log"
+)
+
+const (
+	ModeCanned = "canned" // Built-in responses are fixed placeholder values
+	ModeEcho   = "echo"   // Built-in responses echo the content of the request back whenever possible
+)
+
+type Options struct {
+	Mode          string        // ModeCanned or ModeEcho, defaults to ModeCanned
+	Latency       time.Duration // Added before answering each request
+	LatencyJitter time.Duration // Random extra latency between 0 and LatencyJitter
+	ErrorRate     float64       // Between 0 and 1, fraction of requests answered with an error
+	ErrorStatus   int           // Status code of injected errors, defaults to 500
+	ApiKey        string        // When set, requests must use this key as a Bearer token
+	Script        Script        // Scripted responses, which take priority over built-in responses
+	Seed          int64         // Seed used for latency jitter and error injection, 0 uses the current time
+}
+
+// A request received by the server, kept so that tests can make assertions on what was sent.
+type Request struct {
+	Method string
+	Route  string
+	Header http.Header
+	Body   []byte
+}
+
+type Server struct {
+	options Options
+
+	mu       sync.Mutex
+	rand     *rand.Rand
+	requests []Request
+	served   map[int]int // number of times each scripted response was served
+
+	// In-memory state for the endpoints which create objects
+	files     map[string]file.File
+	contents  map[string][]byte
+	batches   map[string]batches.Batch
+	jobs      map[string]finetuning.Job
+	deleted   map[string]bool // models deleted through the api
+	idCounter int
+}
+
+func NewServer(options Options) *Server {
+	if options.Mode == "" {
+		options.Mode = ModeCanned
+	}
+
+	if options.ErrorStatus == 0 {
+		options.ErrorStatus = http.StatusInternalServerError
+	}
+
+	seed := options.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	return &Server{
+		options:  options,
+		rand:     rand.New(rand.NewSource(seed)),
+		served:   make(map[int]int),
+		files:    make(map[string]file.File),
+		contents: make(map[string][]byte),
+		batches:  make(map[string]batches.Batch),
+		jobs:     make(map[string]finetuning.Job),
+		deleted:  make(map[string]bool),
+	}
+}
+
+// Starts a mock server listening on a local port. Callers are responsible for closing it.
+func NewTestServer(options Options) *httptest.Server {
+	return httptest.NewServer(NewServer(options))
+}
+
+// Returns a copy of every request received by the server so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Request, len(s.requests))
+	copy(out, s.requests)
+	return out
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := readBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "unable to read request body: "+err.Error())
+		return
+	}
+
+	log.Debug("Mock server received request: %s %s\n", r.Method, r.URL.Path)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Route: r.URL.Path, Header: r.Header.Clone(), Body: body})
+	requestId := "req-mock" + strconv.Itoa(len(s.requests))
+	delay := s.options.Latency
+	if s.options.LatencyJitter > 0 {
+		delay += time.Duration(s.rand.Int63n(int64(s.options.LatencyJitter)))
+	}
+	injectError := s.options.ErrorRate > 0 && s.rand.Float64() < s.options.ErrorRate
+	s.mu.Unlock()
+
+	// As the API, every response carries the id of the request
+	w.Header().Set("x-request-id", requestId)
+
+	time.Sleep(delay)
+
+	if s.options.ApiKey != "" && r.Header.Get("Authorization") != "Bearer "+s.options.ApiKey {
+		writeError(w, http.StatusUnauthorized, "Incorrect API key provided.")
+		return
+	}
+
+	if injectError {
+		writeError(w, s.options.ErrorStatus, "error injected by the mock server")
+		return
+	}
+
+	if s.serveScripted(w, r) {
+		return
+	}
+
+	s.route(w, r, body)
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, body []byte) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+
+	switch {
+	case path == "/v1/chat/completions":
+		s.chatCompletions(w, r, body)
+	case path == "/v1/embeddings":
+		s.embeddings(w, r, body)
+	case path == "/v1/audio/speech":
+		s.speech(w, r)
+	case path == "/v1/audio/transcriptions" || path == "/v1/audio/translations":
+		s.transcription(w, r)
+	case path == "/v1/images/generations":
+		s.imageGenerations(w, r, body)
+	case path == "/v1/images/edits" || path == "/v1/images/variations":
+		s.imageEdits(w, r)
+	case strings.HasPrefix(path, imageDownloadRoute):
+		s.imageDownload(w, r)
+	case strings.HasPrefix(path, "/v1/files"):
+		s.filesRoute(w, r, routeParts(path, "/v1/files"))
+	case strings.HasPrefix(path, "/v1/batches"):
+		s.batchesRoute(w, r, body, routeParts(path, "/v1/batches"))
+	case strings.HasPrefix(path, "/v1/fine_tuning/jobs"):
+		s.fineTuningJobs(w, r, body, routeParts(path, "/v1/fine_tuning/jobs"))
+	case strings.HasPrefix(path, "/v1/models"):
+		s.models(w, r, routeParts(path, "/v1/models"))
+	default:
+		writeError(w, http.StatusNotFound, "route not implemented by the mock server: "+r.Method+" "+r.URL.Path)
+	}
+}
+
+// Returns the non-empty path segments following prefix.
+func routeParts(path string, prefix string) []string {
+	var parts []string
+	for _, part := range strings.Split(strings.TrimPrefix(path, prefix), "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return parts
+}
+
+func (s *Server) newId(prefix string) string {
+	s.idCounter++
+	return prefix + "-mock" + strconv.Itoa(s.idCounter)
+}
+
+func writeJson(w http.ResponseWriter, status int, v any) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(buf)
+}
+
+// Errors follow the format used by the OpenAI API.
+func writeError(w http.ResponseWriter, status int, message string) {
+	buf, _ := json.Marshal(map[string]any{
+		"error": map[string]any{
+			"message": message,
+			"type":    "mock_error",
+			"param":   nil,
+			"code":    status,
+		},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(buf)
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed by the mock server: "+r.Method+" "+r.URL.Path)
+}
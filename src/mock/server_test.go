@@ -0,0 +1,499 @@
+package mock
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, options Options) (server *httptest.Server) {
+	server = NewTestServer(options)
+	t.Cleanup(server.Close)
+	return
+}
+
+// Sends a request with a json body, or no body when v is nil, and decodes the json response into out when it is not nil.
+func doJson(t *testing.T, method string, url string, v any, out any) (res *http.Response) {
+	t.Helper()
+
+	var body io.Reader
+	if v != nil {
+		buf, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if out != nil {
+		err = json.NewDecoder(res.Body).Decode(out)
+		if err != nil {
+			t.Fatalf("%s %s: unable to decode response: %s", method, url, err)
+		}
+	}
+
+	return
+}
+
+// Posts a multipart form made of fields, and of a file named file.bin in the "file" field when content is not nil.
+func postForm(t *testing.T, url string, fields map[string]string, content []byte) (res *http.Response) {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	w := multipart.NewWriter(buf)
+	for k, v := range fields {
+		w.WriteField(k, v)
+	}
+
+	if content != nil {
+		part, err := w.CreateFormFile("file", "file.bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		part.Write(content)
+	}
+
+	w.Close()
+
+	res, err := http.Post(url, w.FormDataContentType(), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func chatBody(content string, stream bool) map[string]any {
+	return map[string]any{
+		"model":    "gpt-3.5-turbo",
+		"stream":   stream,
+		"messages": []any{map[string]any{"role": "user", "content": content}},
+	}
+}
+
+func TestChatCompletion(t *testing.T) {
+	server := newTestServer(t, Options{Mode: ModeEcho})
+
+	var resp struct {
+		Choices []struct {
+			Message struct{ Content string }
+		}
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+		}
+	}
+
+	res := doJson(t, http.MethodPost, server.URL+"/v1/chat/completions", chatBody("Hello there", false), &resp)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", res.StatusCode)
+	}
+
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "Hello there" {
+		t.Errorf("expected the prompt to be echoed, got: %+v", resp.Choices)
+	}
+
+	if resp.Usage.PromptTokens != 2 {
+		t.Errorf("expected 2 prompt tokens, got %d", resp.Usage.PromptTokens)
+	}
+
+	if res.Header.Get("x-request-id") == "" {
+		t.Error("expected the response to carry a request id")
+	}
+}
+
+func TestChatCompletionStream(t *testing.T) {
+	server := newTestServer(t, Options{Mode: ModeEcho})
+
+	buf, _ := json.Marshal(chatBody("one two three", true))
+	res, err := http.Post(server.URL+"/v1/chat/completions", "application/json", bytes.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got: %s", res.Header.Get("Content-Type"))
+	}
+
+	var content strings.Builder
+	var finishReason string
+	done := false
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+
+		var chunk struct {
+			Object  string
+			Choices []struct {
+				Delta        struct{ Content string }
+				FinishReason string `json:"finish_reason"`
+			}
+		}
+
+		err = json.Unmarshal([]byte(data), &chunk)
+		if err != nil {
+			t.Fatalf("invalid chunk %q: %s", data, err)
+		}
+
+		if chunk.Object != "chat.completion.chunk" {
+			t.Errorf("expected chunks, got: %s", chunk.Object)
+		}
+
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		if chunk.Choices[0].FinishReason != "" {
+			finishReason = chunk.Choices[0].FinishReason
+		}
+	}
+
+	if !done {
+		t.Error("expected the stream to end with [DONE]")
+	}
+
+	if content.String() != "one two three" || finishReason != "stop" {
+		t.Errorf("expected the deltas to make up the prompt and to stop, got %q finishing with %q", content.String(), finishReason)
+	}
+}
+
+func TestTranscription(t *testing.T) {
+	server := newTestServer(t, Options{Mode: ModeEcho})
+
+	res := postForm(t, server.URL+"/v1/audio/transcriptions", map[string]string{"model": "whisper-1", "prompt": "Some speech", "response_format": "text"}, []byte("audio"))
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK || string(body) != "Some speech\n" {
+		t.Errorf("expected the prompt as text, got %d: %q", res.StatusCode, body)
+	}
+
+	var verbose struct {
+		Task     string
+		Language string
+		Text     string
+	}
+
+	res = postForm(t, server.URL+"/v1/audio/translations", map[string]string{"response_format": "verbose_json"}, []byte("audio"))
+	defer res.Body.Close()
+
+	err := json.NewDecoder(res.Body).Decode(&verbose)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a prompt, the name of the file is echoed
+	if verbose.Task != "translate" || verbose.Language != "en" || verbose.Text != "file.bin" {
+		t.Errorf("unexpected verbose translation: %+v", verbose)
+	}
+}
+
+func TestImages(t *testing.T) {
+	server := newTestServer(t, Options{})
+
+	var generations struct {
+		Data []struct{ Url string }
+	}
+
+	doJson(t, http.MethodPost, server.URL+"/v1/images/generations", map[string]any{"prompt": "a cat", "n": 2}, &generations)
+	if len(generations.Data) != 2 {
+		t.Fatalf("expected 2 images, got %d", len(generations.Data))
+	}
+
+	res, err := http.Get(generations.Data[0].Url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	png, _ := io.ReadAll(res.Body)
+	if res.Header.Get("Content-Type") != "image/png" || !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Errorf("expected the url to serve a png, got: %s", res.Header.Get("Content-Type"))
+	}
+
+	var edits struct {
+		Data []struct {
+			B64Json string `json:"b64_json"`
+		}
+	}
+
+	res = postForm(t, server.URL+"/v1/images/edits", map[string]string{"prompt": "a dog", "response_format": "b64_json"}, png)
+	defer res.Body.Close()
+
+	err = json.NewDecoder(res.Body).Decode(&edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(edits.Data) != 1 {
+		t.Fatalf("expected a single image, got %d", len(edits.Data))
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(edits.Data[0].B64Json)
+	if err != nil || !bytes.HasPrefix(decoded, []byte("\x89PNG")) {
+		t.Errorf("expected a base64 png, got error: %v", err)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	server := newTestServer(t, Options{})
+
+	res := postForm(t, server.URL+"/v1/files", map[string]string{"purpose": "batch"}, []byte("line\n"))
+	defer res.Body.Close()
+
+	var f struct {
+		Id       string
+		Bytes    int
+		Purpose  string
+		Filename string
+	}
+
+	err := json.NewDecoder(res.Body).Decode(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Id == "" || f.Bytes != 5 || f.Purpose != "batch" || f.Filename != "file.bin" {
+		t.Fatalf("unexpected file: %+v", f)
+	}
+
+	var list struct {
+		Data []struct{ Id string }
+	}
+
+	doJson(t, http.MethodGet, server.URL+"/v1/files", nil, &list)
+	if len(list.Data) != 1 || list.Data[0].Id != f.Id {
+		t.Errorf("expected the file to be listed, got: %+v", list.Data)
+	}
+
+	res, err = http.Get(server.URL + "/v1/files/" + f.Id + "/content")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	content, _ := io.ReadAll(res.Body)
+	if string(content) != "line\n" {
+		t.Errorf("expected the content of the file, got: %q", content)
+	}
+
+	var deleted struct{ Deleted bool }
+	doJson(t, http.MethodDelete, server.URL+"/v1/files/"+f.Id, nil, &deleted)
+	if !deleted.Deleted {
+		t.Error("expected the file to be deleted")
+	}
+
+	res = doJson(t, http.MethodGet, server.URL+"/v1/files/"+f.Id, nil, nil)
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a deleted file not to be found, got status %d", res.StatusCode)
+	}
+}
+
+func TestBatches(t *testing.T) {
+	server := newTestServer(t, Options{})
+
+	var b struct {
+		Id          string
+		Status      string
+		InputFileId string `json:"input_file_id"`
+	}
+
+	doJson(t, http.MethodPost, server.URL+"/v1/batches", map[string]any{"input_file_id": "file-abc", "endpoint": "/v1/chat/completions", "completion_window": "24h"}, &b)
+	if b.Id == "" || b.Status != "validating" || b.InputFileId != "file-abc" {
+		t.Fatalf("unexpected batch: %+v", b)
+	}
+
+	doJson(t, http.MethodPost, server.URL+"/v1/batches/"+b.Id+"/cancel", nil, &b)
+	if b.Status != "cancelled" {
+		t.Errorf("expected the batch to be cancelled, got: %s", b.Status)
+	}
+
+	var list struct {
+		Data    []struct{ Id string }
+		FirstId string `json:"first_id"`
+	}
+
+	doJson(t, http.MethodGet, server.URL+"/v1/batches", nil, &list)
+	if len(list.Data) != 1 || list.FirstId != b.Id {
+		t.Errorf("expected the batch to be listed, got: %+v", list)
+	}
+
+	res := doJson(t, http.MethodGet, server.URL+"/v1/batches/batch-unknown", nil, nil)
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("expected an unknown batch not to be found, got status %d", res.StatusCode)
+	}
+}
+
+func TestFineTuningJobs(t *testing.T) {
+	server := newTestServer(t, Options{})
+
+	var job struct {
+		Id           string
+		Status       string
+		Model        string
+		TrainingFile string `json:"training_file"`
+	}
+
+	doJson(t, http.MethodPost, server.URL+"/v1/fine_tuning/jobs", map[string]any{"model": "gpt-3.5-turbo", "training_file": "file-abc"}, &job)
+	if job.Id == "" || job.Status != "queued" || job.TrainingFile != "file-abc" {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+
+	var events struct {
+		Data []struct{ Message string }
+	}
+
+	doJson(t, http.MethodGet, server.URL+"/v1/fine_tuning/jobs/"+job.Id+"/events", nil, &events)
+	if len(events.Data) != 1 || !strings.Contains(events.Data[0].Message, job.Id) {
+		t.Errorf("expected the creation event of the job, got: %+v", events.Data)
+	}
+
+	doJson(t, http.MethodPost, server.URL+"/v1/fine_tuning/jobs/"+job.Id+"/cancel", nil, &job)
+	if job.Status != "cancelled" {
+		t.Errorf("expected the job to be cancelled, got: %s", job.Status)
+	}
+}
+
+func TestModels(t *testing.T) {
+	server := newTestServer(t, Options{})
+
+	var list struct {
+		Data []struct{ Id string }
+	}
+
+	doJson(t, http.MethodGet, server.URL+"/v1/models", nil, &list)
+	if len(list.Data) != len(mockModels) {
+		t.Fatalf("expected %d models, got %d", len(mockModels), len(list.Data))
+	}
+
+	var m struct{ Id string }
+	doJson(t, http.MethodGet, server.URL+"/v1/models/whisper-1", nil, &m)
+	if m.Id != "whisper-1" {
+		t.Errorf("expected whisper-1, got: %s", m.Id)
+	}
+
+	doJson(t, http.MethodDelete, server.URL+"/v1/models/whisper-1", nil, nil)
+	res := doJson(t, http.MethodGet, server.URL+"/v1/models/whisper-1", nil, nil)
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a deleted model not to be found, got status %d", res.StatusCode)
+	}
+
+	res = doJson(t, http.MethodGet, server.URL+"/v1/models/unknown", nil, nil)
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("expected an unknown model not to be found, got status %d", res.StatusCode)
+	}
+}
+
+func TestApiKey(t *testing.T) {
+	server := newTestServer(t, Options{ApiKey: "sk-mock"})
+
+	res := doJson(t, http.MethodGet, server.URL+"/v1/models", nil, nil)
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a request without the key to be refused, got status %d", res.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer sk-mock")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected a request with the key to be served, got status %d", res.StatusCode)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	latency := 50 * time.Millisecond
+	server := newTestServer(t, Options{Latency: latency, LatencyJitter: latency, Seed: 1})
+
+	start := time.Now()
+	doJson(t, http.MethodGet, server.URL+"/v1/models", nil, nil)
+	if elapsed := time.Since(start); elapsed < latency {
+		t.Errorf("expected the response to take at least %s, took %s", latency, elapsed)
+	}
+}
+
+func TestErrorRate(t *testing.T) {
+	server := newTestServer(t, Options{ErrorRate: 1, ErrorStatus: http.StatusServiceUnavailable})
+
+	var resp struct {
+		Error struct{ Message string }
+	}
+
+	res := doJson(t, http.MethodGet, server.URL+"/v1/models", nil, &resp)
+	if res.StatusCode != http.StatusServiceUnavailable || resp.Error.Message == "" {
+		t.Errorf("expected an injected error, got status %d: %+v", res.StatusCode, resp)
+	}
+
+	server = newTestServer(t, Options{ErrorRate: 0.5, Seed: 1})
+	failed := 0
+	for i := 0; i < 40; i++ {
+		res = doJson(t, http.MethodGet, server.URL+"/v1/models", nil, nil)
+		if res.StatusCode == http.StatusInternalServerError {
+			failed++
+		}
+	}
+
+	if failed == 0 || failed == 40 {
+		t.Errorf("expected some requests to fail and others to succeed, %d out of 40 failed", failed)
+	}
+}
+
+func TestScript(t *testing.T) {
+	server := newTestServer(t, Options{Script: Script{Responses: []ScriptedResponse{
+		{Method: "POST", Route: "/v1/chat/completions", Status: http.StatusTooManyRequests, Body: json.RawMessage(`{"error":{"message":"slow down"}}`), Times: 1},
+		{Route: "/v1/files*", Headers: map[string]string{"Content-Type": "text/plain"}, Body: json.RawMessage(`"scripted"`)},
+	}}})
+
+	// The first chat request is scripted, the next ones are built-in responses
+	res := doJson(t, http.MethodPost, server.URL+"/v1/chat/completions", chatBody("Hi", false), nil)
+	if res.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected the scripted status, got %d", res.StatusCode)
+	}
+
+	res = doJson(t, http.MethodPost, server.URL+"/v1/chat/completions", chatBody("Hi", false), nil)
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected the built-in response once the script is exhausted, got %d", res.StatusCode)
+	}
+
+	res, err := http.Get(server.URL + "/v1/files/file-abc/content")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	if res.Header.Get("Content-Type") != "text/plain" || string(body) != "scripted" {
+		t.Errorf("expected the unquoted scripted body of the route prefix, got %s: %q", res.Header.Get("Content-Type"), body)
+	}
+}
@@ -1,19 +1,13 @@
 package model
 
 import (
-	"encoding/json"
+	"context"
 
 	"github.com/ephex2/go-gpt-cli/api"
 )
 
 func ListModels() (models []Model, err error) {
-	res, err := api.GenericRequest(nil, nil, ModelRoute, "GET", "")
-	if err != nil {
-		return
-	}
-
-	var resp ListModelResponse
-	err = json.Unmarshal(res, &resp)
+	resp, err := api.NewClient(nil).ListModels(context.Background())
 	if err != nil {
 		return
 	}
@@ -23,30 +17,9 @@ func ListModels() (models []Model, err error) {
 }
 
 func RetrieveModel(name string) (model Model, err error) {
-	buf, err := api.GenericRequest(nil, nil, ModelRoute+"/"+name, "GET", "")
-	if err != nil {
-		return
-	}
-
-	err = json.Unmarshal(buf, &model)
-	if err != nil {
-		return
-	}
-
-	return
+	return api.NewClient(nil).RetrieveModel(context.Background(), name)
 }
 
 func DeleteModel(name string) (dres DeleteModelResponse, err error) {
-	route := ModelRoute + "/" + name
-	buf, err := api.GenericRequest(nil, nil, route, "DELETE", "")
-	if err != nil {
-		return
-	}
-
-	err = json.Unmarshal(buf, &dres)
-	if err != nil {
-		return
-	}
-
-	return
+	return api.NewClient(nil).DeleteModel(context.Background(), name)
 }
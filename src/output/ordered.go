@@ -0,0 +1,145 @@
+package output
+
+// Json values are decoded keeping the order of object fields, so that yaml and tables list fields in the order of the
+// json output rather than sorted by name.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"gopkg.in/yaml.v3"
+)
+
+type object struct {
+	keys   []string
+	values []any
+}
+
+func (o *object) get(key string) any {
+	for i, k := range o.keys {
+		if k == key {
+			return o.values[i]
+		}
+	}
+
+	return nil
+}
+
+// Decodes json into *object, []any, json.Number, string, bool or nil values.
+func decodeOrdered(buf []byte) (v any, err error) {
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	return decodeValue(dec)
+}
+
+func decodeValue(dec *json.Decoder) (v any, err error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		o := &object{}
+		for dec.More() {
+			var keyTok json.Token
+			keyTok, err = dec.Token()
+			if err != nil {
+				return
+			}
+
+			key, ok := keyTok.(string)
+			if !ok {
+				return nil, errors.New("invalid json object key")
+			}
+
+			var value any
+			value, err = decodeValue(dec)
+			if err != nil {
+				return
+			}
+
+			o.keys = append(o.keys, key)
+			o.values = append(o.values, value)
+		}
+
+		_, err = dec.Token()
+		return o, err
+	case json.Delim('['):
+		list := []any{}
+		for dec.More() {
+			var value any
+			value, err = decodeValue(dec)
+			if err != nil {
+				return
+			}
+
+			list = append(list, value)
+		}
+
+		_, err = dec.Token()
+		return list, err
+	}
+
+	return tok, nil
+}
+
+// Converts ordered values back to maps and slices, for json encoding.
+func plain(v any) any {
+	switch v := v.(type) {
+	case *object:
+		m := make(map[string]any, len(v.keys))
+		for i, key := range v.keys {
+			m[key] = plain(v.values[i])
+		}
+
+		return m
+	case []any:
+		list := make([]any, len(v))
+		for i, e := range v {
+			list[i] = plain(e)
+		}
+
+		return list
+	}
+
+	return v
+}
+
+func yamlNode(v any) *yaml.Node {
+	switch v := v.(type) {
+	case *object:
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		for i, key := range v.keys {
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, yamlNode(v.values[i]))
+		}
+
+		return n
+	case []any:
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, e := range v {
+			n.Content = append(n.Content, yamlNode(e))
+		}
+
+		return n
+	case json.Number:
+		tag := "!!int"
+		if bytes.ContainsAny([]byte(v), ".eE") {
+			tag = "!!float"
+		}
+
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+	case bool:
+		value := "false"
+		if v {
+			value = "true"
+		}
+
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: value}
+	}
+
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
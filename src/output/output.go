@@ -0,0 +1,273 @@
+package output
+
+// Commands print their results through Print, in the format selected with --output or GO_GPT_CLI_OUTPUT:
+//   - text: the human readable output of the command, the default
+//   - json: the value printed as indented json, suitable for scripting
+//   - yaml: the json value as yaml, keeping the field names and their order
+//   - table: a table of the value, see writeTable
+//   - template=<go template>: the template executed with the json value, ex: template={{range .}}{{.id}}{{"\n"}}{{end}}
+//
+// Results are written to Writer, diagnostics go to stderr through the log package.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatText     string = "text"
+	FormatJson     string = "json"
+	FormatYaml     string = "yaml"
+	FormatTable    string = "table"
+	FormatTemplate string = "template"
+)
+
+var Formats = []string{FormatText, FormatJson, FormatYaml, FormatTable, FormatTemplate + "=<go template>"}
+
+// Environment variable selecting the output format when --output is not set.
+const OutputEnv string = "GO_GPT_CLI_OUTPUT"
+
+// Where results are printed.
+var Writer io.Writer = os.Stdout
+
+var format = FormatText
+var tmpl *template.Template
+
+// Selects the output format from a --output value, text when empty.
+func SetFormat(spec string) (err error) {
+	name, text, hasTemplate := strings.Cut(spec, "=")
+	switch {
+	case spec == "":
+		format, tmpl = FormatText, nil
+	case hasTemplate && name == FormatTemplate:
+		var t *template.Template
+		t, err = template.New("output").Funcs(template.FuncMap{"json": compactJson}).Parse(text)
+		if err != nil {
+			return errors.New("invalid output template: " + err.Error())
+		}
+
+		format, tmpl = FormatTemplate, t
+	case !hasTemplate && slices.Contains([]string{FormatText, FormatJson, FormatYaml, FormatTable}, spec):
+		format, tmpl = spec, nil
+	default:
+		return errors.New("unknown output format " + spec + ", expected one of: " + strings.Join(Formats, ", "))
+	}
+
+	return
+}
+
+// Returns the selected output format.
+func Format() string {
+	return format
+}
+
+// Prints a value in the selected output format. text writes the value in the text format, the value is printed as json
+// when text is nil.
+func Print(v any, text func(w io.Writer) error) (err error) {
+	if format == FormatText && text != nil {
+		return text(Writer)
+	}
+
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	switch format {
+	case FormatYaml:
+		err = writeYaml(Writer, buf)
+	case FormatTable:
+		err = writeTable(Writer, buf)
+	case FormatTemplate:
+		err = writeTemplate(Writer, buf)
+	default:
+		err = writeJson(Writer, buf)
+	}
+
+	return
+}
+
+// Prints a value whose text format is a line.
+func PrintLine(v any, line string) error {
+	return Print(v, func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, line)
+		return err
+	})
+}
+
+// Prints a value whose text format is one line per element.
+func PrintLines(v any, lines []string) error {
+	return Print(v, func(w io.Writer) (err error) {
+		for _, line := range lines {
+			_, err = fmt.Fprintln(w, line)
+			if err != nil {
+				return
+			}
+		}
+
+		return
+	})
+}
+
+func writeJson(w io.Writer, buf []byte) (err error) {
+	var out bytes.Buffer
+	err = json.Indent(&out, buf, "", "    ")
+	if err != nil {
+		return
+	}
+
+	out.WriteByte('\n')
+	_, err = out.WriteTo(w)
+	return
+}
+
+func writeYaml(w io.Writer, buf []byte) (err error) {
+	v, err := decodeOrdered(buf)
+	if err != nil {
+		return
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	err = enc.Encode(yamlNode(v))
+	if err != nil {
+		return
+	}
+
+	return enc.Close()
+}
+
+func writeTemplate(w io.Writer, buf []byte) (err error) {
+	var v any
+	err = json.Unmarshal(buf, &v)
+	if err != nil {
+		return
+	}
+
+	var out bytes.Buffer
+	err = tmpl.Execute(&out, v)
+	if err != nil {
+		return
+	}
+
+	if out.Len() > 0 && !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+		out.WriteByte('\n')
+	}
+
+	_, err = out.WriteTo(w)
+	return
+}
+
+// Lists have a row per element, with a column per field of the elements. Objects holding a list in their data field,
+// such as the lists of the API, are printed as that list. Other objects have a row per field.
+func writeTable(w io.Writer, buf []byte) (err error) {
+	v, err := decodeOrdered(buf)
+	if err != nil {
+		return
+	}
+
+	if o, ok := v.(*object); ok {
+		if data, ok := o.get("data").([]any); ok {
+			v = data
+		}
+	}
+
+	var header []string
+	var rows [][]string
+	switch v := v.(type) {
+	case []any:
+		// Empty lists print nothing rather than a header of unknown columns
+		if len(v) == 0 {
+			return
+		}
+
+		header, rows = listTable(v)
+	case *object:
+		header = []string{"FIELD", "VALUE"}
+		for i, key := range v.keys {
+			rows = append(rows, []string{key, cell(v.values[i])})
+		}
+	default:
+		header, rows = []string{"VALUE"}, [][]string{{cell(v)}}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+func listTable(list []any) (header []string, rows [][]string) {
+	var keys []string
+	for _, e := range list {
+		if o, ok := e.(*object); ok {
+			for _, key := range o.keys {
+				if !slices.Contains(keys, key) {
+					keys = append(keys, key)
+				}
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		header = []string{"VALUE"}
+		for _, e := range list {
+			rows = append(rows, []string{cell(e)})
+		}
+
+		return
+	}
+
+	for _, key := range keys {
+		header = append(header, strings.ToUpper(key))
+	}
+
+	for _, e := range list {
+		o, _ := e.(*object)
+		row := make([]string, len(keys))
+		for i, key := range keys {
+			if o != nil {
+				row[i] = cell(o.get(key))
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return
+}
+
+// Scalars are printed as is, nested values as compact json.
+func cell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	default:
+		buf, _ := json.Marshal(plain(v))
+		return string(buf)
+	}
+}
+
+func compactJson(v any) (string, error) {
+	buf, err := json.Marshal(v)
+	return string(buf), err
+}
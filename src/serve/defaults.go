@@ -0,0 +1,199 @@
+package serve
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+)
+
+// Describes how requests sent to a route relate to profiles.
+type routeSpec struct {
+	prefix       string          // Route prefix, matched against the path of incoming requests
+	endpointName string          // Name of the profile endpoint whose profiles apply to the route
+	bodyField    string          // Field of the profile holding default request values, none are applied when empty
+	multipart    bool            // Whether the route expects multipart forms instead of json bodies
+	exclude      map[string]bool // Fields of the profile's body which are set at runtime and must never be used as defaults
+}
+
+// Routes are matched in order, more specific prefixes must come first.
+var routeSpecs = []routeSpec{
+	{prefix: "/v1/chat/completions", endpointName: "chat", bodyField: "CreateCompletionBody", exclude: map[string]bool{"messages": true}},
+	{prefix: "/v1/embeddings", endpointName: "embeddings", bodyField: "CreateEmbeddingBody", exclude: map[string]bool{"input": true}},
+	{prefix: "/v1/images/generations", endpointName: "image", bodyField: "CreateImageBody", exclude: map[string]bool{"prompt": true}},
+	{prefix: "/v1/images/edits", endpointName: "image", bodyField: "CreateEditBody", multipart: true, exclude: map[string]bool{"prompt": true, "size": true}},
+	{prefix: "/v1/images/variations", endpointName: "image", bodyField: "CreateVariationBody", multipart: true, exclude: map[string]bool{"size": true}},
+	{prefix: "/v1/audio/speech", endpointName: "audio", bodyField: "CreateSpeechBody", exclude: map[string]bool{"input": true}},
+	{prefix: "/v1/audio/transcriptions", endpointName: "audio", bodyField: "CreateTranscriptionBody", multipart: true, exclude: map[string]bool{"prompt": true}},
+	{prefix: "/v1/audio/translations", endpointName: "audio", bodyField: "CreateTranslationBody", multipart: true, exclude: map[string]bool{"prompt": true}},
+	{prefix: "/v1/files", endpointName: "file"},
+	{prefix: "/v1/batches", endpointName: "batch"},
+	{prefix: "/v1/fine_tuning", endpointName: "finetuning"},
+	{prefix: "/v1/"},
+}
+
+func findRouteSpec(path string) (spec routeSpec, ok bool) {
+	for _, spec = range routeSpecs {
+		if strings.HasPrefix(path, spec.prefix) {
+			return spec, true
+		}
+	}
+
+	return
+}
+
+// Returns the default request values held by the profile for the route, excluding the fields set at runtime.
+func (spec routeSpec) defaults(rawProfile []byte) (defaults map[string]any, err error) {
+	if spec.bodyField == "" {
+		return
+	}
+
+	var p map[string]json.RawMessage
+	err = json.Unmarshal(rawProfile, &p)
+	if err != nil {
+		return
+	}
+
+	rawBody, ok := p[spec.bodyField]
+	if !ok {
+		return
+	}
+
+	err = json.Unmarshal(rawBody, &defaults)
+	if err != nil {
+		return
+	}
+
+	for k := range spec.exclude {
+		delete(defaults, k)
+	}
+
+	// Only system messages are defaults, the rest of a chat profile's messages are its history
+	if spec.bodyField == "CreateCompletionBody" {
+		var body struct {
+			Messages []map[string]any `json:"messages"`
+		}
+
+		json.Unmarshal(rawBody, &body)
+		var systemMessages []any
+		for _, msg := range body.Messages {
+			if msg["role"] == "system" {
+				systemMessages = append(systemMessages, msg)
+			}
+		}
+
+		if len(systemMessages) > 0 {
+			defaults["messages"] = systemMessages
+		}
+	}
+
+	return
+}
+
+// Sets the defaults on a json request body for every field the request does not set itself.
+// Default system messages are only added when the request has no system message of its own.
+func applyJsonDefaults(body []byte, defaults map[string]any) (out []byte, err error) {
+	if len(defaults) == 0 || len(body) == 0 {
+		return body, nil
+	}
+
+	var req map[string]any
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		err = errors.New("request body is not a json object: " + err.Error())
+		return
+	}
+
+	for k, v := range defaults {
+		if k == "messages" {
+			continue
+		}
+
+		if _, ok := req[k]; !ok {
+			req[k] = v
+		}
+	}
+
+	if systemMessages, ok := defaults["messages"].([]any); ok {
+		messages, _ := req["messages"].([]any)
+
+		hasSystem := false
+		for _, msg := range messages {
+			m, _ := msg.(map[string]any)
+			hasSystem = hasSystem || m["role"] == "system"
+		}
+
+		if !hasSystem {
+			req["messages"] = append(systemMessages, messages...)
+		}
+	}
+
+	out, err = json.Marshal(req)
+	return
+}
+
+// Same as applyJsonDefaults for multipart forms: fields missing from the form are added from the defaults.
+func applyMultipartDefaults(body []byte, contentType string, defaults map[string]any) (out []byte, outContentType string, err error) {
+	out, outContentType = body, contentType
+	if len(defaults) == 0 {
+		return
+	}
+
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	buf := new(bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+	present := make(map[string]bool)
+
+	for {
+		var part *multipart.Part
+		part, err = reader.NextPart()
+		if err == io.EOF {
+			err = nil
+			break
+		}
+
+		if err != nil {
+			return
+		}
+
+		present[part.FormName()] = true
+
+		var w io.Writer
+		w, err = writer.CreatePart(part.Header)
+		if err != nil {
+			return
+		}
+
+		_, err = io.Copy(w, part)
+		if err != nil {
+			return
+		}
+	}
+
+	for k, v := range defaults {
+		if present[k] {
+			continue
+		}
+
+		err = writer.WriteField(k, fmt.Sprint(v))
+		if err != nil {
+			return
+		}
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return
+	}
+
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
@@ -0,0 +1,436 @@
+package serve
+
+// The serve package exposes an OpenAI compatible API locally which forwards requests to the configured API.
+// The api key from the config is injected into forwarded requests so that local tools never see it, and the defaults
+// of a profile (system prompt, model, temperature...) are applied to every request, along with the profile's url override.
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/api"
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/profile"
+	"github.com/ephex2/go-gpt-cli/log"
+	"github.com/ephex2/go-gpt-cli/usage"
+)
+
+// Header that tools can use to select the profile applied to a request.
+const ProfileHeader = "X-Go-Gpt-Cli-Profile"
+
+// Tools can also select a profile through the base url they use, ex: http://127.0.0.1:8081/profiles/coding/v1
+const profilePathPrefix = "/profiles/"
+
+// Headers which only apply to a single connection and must not be forwarded.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+type Options struct {
+	Profile string // Name of the profile applied to requests which do not select one, default profiles are used when empty
+	Token   string // Bearer token local tools must use, every request is refused when empty, see NewToken
+}
+
+type Server struct {
+	options Options
+}
+
+func NewServer(options Options) *Server {
+	return &Server{options: options}
+}
+
+// Generates a random token, used when none is chosen so that the server is never left open to other local users or to
+// web pages the browser is visiting.
+func NewToken() (token string, err error) {
+	buf := make([]byte, 24)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return
+	}
+
+	token = "gpt-cli-" + hex.EncodeToString(buf)
+	return
+}
+
+// Requests must be addressed to a loopback host: web pages resolving their own domain to 127.0.0.1 (dns rebinding)
+// would otherwise reach the server through the browser.
+func loopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	if !loopbackHost(r.Host) {
+		writeError(w, http.StatusForbidden, "the go-gpt-cli server only accepts requests addressed to localhost, not: "+r.Host)
+		return
+	}
+
+	provided := []byte(r.Header.Get("Authorization"))
+	expected := []byte("Bearer " + s.options.Token)
+	if s.options.Token == "" || subtle.ConstantTimeCompare(provided, expected) != 1 {
+		writeError(w, http.StatusUnauthorized, "invalid token provided to the go-gpt-cli server")
+		return
+	}
+
+	path, profileName := splitProfile(r.URL.Path)
+	if profileName == "" {
+		profileName = r.Header.Get(ProfileHeader)
+	}
+
+	if profileName == "" {
+		profileName = s.options.Profile
+	}
+
+	if profileName != "" {
+		err := profile.CheckName(profileName)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	spec, ok := findRouteSpec(path)
+	if !ok {
+		writeError(w, http.StatusNotFound, "route not supported by the go-gpt-cli server: "+path)
+		return
+	}
+
+	p, defaults, profileName, err := loadProfile(spec, profileName)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "unable to read request body: "+err.Error())
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if spec.multipart {
+		body, contentType, err = applyMultipartDefaults(body, contentType, defaults)
+	} else if r.Method == http.MethodPost {
+		body, err = applyJsonDefaults(body, defaults)
+	}
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rawUrl, err := api.RequestUrl(path, p, requestParams(body, contentType, spec.multipart))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if r.URL.RawQuery != "" && strings.Contains(rawUrl, "?") {
+		rawUrl += "&" + r.URL.RawQuery
+	} else if r.URL.RawQuery != "" {
+		rawUrl += "?" + r.URL.RawQuery
+	}
+
+	req, err := http.NewRequest(r.Method, rawUrl, bytes.NewReader(body))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	copyHeaders(req.Header, r.Header)
+	req.Header.Del("Authorization")
+	req.Header.Del("api-key")
+	req.Header.Del(ProfileHeader)
+	// Let the http client negotiate compression so that responses can be inspected for usage
+	req.Header.Del("Accept-Encoding")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	err = api.Authorize(req, p)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	res, err := api.Do(req)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	defer res.Body.Close()
+
+	copyHeaders(w.Header(), res.Header)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(res.StatusCode)
+
+	var resBody []byte
+	if strings.HasPrefix(res.Header.Get("Content-Type"), "text/event-stream") {
+		resBody, err = stream(w, res.Body)
+	} else {
+		resBody, err = io.ReadAll(res.Body)
+		if err == nil {
+			_, err = w.Write(resBody)
+		}
+	}
+
+	logRequest(r.Method, path, profileName, res.StatusCode, resBody, start, err)
+
+	if res.StatusCode == http.StatusOK && usage.Billable(path) {
+		record := usage.FromResponse(spec.endpointName, profileName, path, requestParams(body, contentType, spec.multipart), resBody)
+		warning, err := usage.Append(record)
+		if err != nil {
+			log.Warning("Unable to record usage of %s in the usage ledger: %s\n", path, err.Error())
+		}
+
+		if warning != "" {
+			log.Warning(warning + "\n")
+		}
+	}
+}
+
+// Returns the path without its profile prefix, along with the profile name found in the prefix.
+func splitProfile(path string) (string, string) {
+	if !strings.HasPrefix(path, profilePathPrefix) {
+		return path, ""
+	}
+
+	rest := strings.TrimPrefix(path, profilePathPrefix)
+	name, route, found := strings.Cut(rest, "/")
+	if !found {
+		return path, ""
+	}
+
+	return "/" + route, name
+}
+
+// Loads the profile applied to a route. The endpoint's default profile is used when no profile name is provided.
+func loadProfile(spec routeSpec, profileName string) (p profile.Profile, defaults map[string]any, name string, err error) {
+	if spec.endpointName == "" {
+		return
+	}
+
+	e, err := profile.EndpointRegistry.Get(spec.endpointName)
+	if err != nil {
+		return
+	}
+
+	name = profileName
+	if name == "" {
+		name, err = config.RuntimeConfig.GetDefaultProfile(e.Name())
+		if err != nil {
+			return
+		}
+	}
+
+	raw, err := profile.RuntimeRepository.Read(name, e.Name())
+	if err != nil {
+		err = errors.New("unable to read profile " + name + " of endpoint " + e.Name() + ": " + err.Error())
+		return
+	}
+
+	p, err = e.ProfileFromJsonBuf(raw)
+	if err != nil {
+		return
+	}
+
+	defaults, err = spec.defaults(raw)
+	return
+}
+
+// Returns the parameters of a request body: the fields of multipart forms, or the top level values of json objects.
+func requestParams(body []byte, contentType string, isMultipart bool) (params map[string]any) {
+	params = make(map[string]any)
+	if !isMultipart {
+		json.Unmarshal(body, &params)
+		return
+	}
+
+	_, mediaParams, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return
+	}
+
+	form, err := multipart.NewReader(bytes.NewReader(body), mediaParams["boundary"]).ReadForm(int64(len(body)))
+	if err != nil {
+		return
+	}
+	defer form.RemoveAll()
+
+	for k, v := range form.Value {
+		if len(v) > 0 {
+			params[k] = v[0]
+		}
+	}
+
+	return
+}
+
+// Forwards server-sent events to the client as they are received. Streamed responses have no body to read usage from,
+// a summary of the events shaped as a json response is returned in its place, see summarizeEvents.
+func stream(w http.ResponseWriter, body io.Reader) (summary []byte, err error) {
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 4096)
+
+	var events bytes.Buffer
+	defer func() { summary = summarizeEvents(events.Bytes()) }()
+
+	for {
+		n, readErr := body.Read(buf)
+		if n > 0 {
+			events.Write(buf[:n])
+			_, err = w.Write(buf[:n])
+			if err != nil {
+				return
+			}
+
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+
+		if readErr == io.EOF {
+			return
+		}
+
+		if readErr != nil {
+			err = readErr
+			return
+		}
+	}
+}
+
+type tokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Returns the model and token usage of streamed chunks as the body of a json response. The usage is the one of the final
+// chunk when the request asked for it (stream_options.include_usage), the chunks holding content are counted as completion
+// tokens otherwise since the API sends a token per chunk, the prompt tokens are then unknown.
+func summarizeEvents(events []byte) []byte {
+	var summary struct {
+		Model string     `json:"model"`
+		Usage tokenUsage `json:"usage"`
+	}
+
+	reported := false
+	counted := 0
+	for _, line := range strings.Split(string(events), "\n") {
+		data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:")
+		data = strings.TrimSpace(data)
+		if !ok || data == "[DONE]" {
+			continue
+		}
+
+		var chunk struct {
+			Model   string      `json:"model"`
+			Usage   *tokenUsage `json:"usage"`
+			Choices []struct {
+				Text  string `json:"text"`
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+		}
+
+		if json.Unmarshal([]byte(data), &chunk) != nil {
+			continue
+		}
+
+		if chunk.Model != "" {
+			summary.Model = chunk.Model
+		}
+
+		if chunk.Usage != nil {
+			summary.Usage = *chunk.Usage
+			reported = true
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" || choice.Text != "" {
+				counted++
+			}
+		}
+	}
+
+	if !reported {
+		summary.Usage = tokenUsage{CompletionTokens: counted, TotalTokens: counted}
+	}
+
+	buf, _ := json.Marshal(summary)
+	return buf
+}
+
+func copyHeaders(dst http.Header, src http.Header) {
+	for k, values := range src {
+		for _, v := range values {
+			dst.Add(k, v)
+		}
+	}
+
+	for _, h := range hopHeaders {
+		dst.Del(h)
+	}
+}
+
+// Logs every forwarded request, along with the model and token usage reported by the API when there is one.
+func logRequest(method string, path string, profileName string, status int, resBody []byte, start time.Time, err error) {
+	var res struct {
+		Model string     `json:"model"`
+		Usage tokenUsage `json:"usage"`
+	}
+
+	json.Unmarshal(resBody, &res)
+
+	log.Info("%s %s profile=%s status=%d model=%s prompt_tokens=%d completion_tokens=%d total_tokens=%d duration=%s\n",
+		method, path, profileName, status, res.Model, res.Usage.PromptTokens, res.Usage.CompletionTokens, res.Usage.TotalTokens, time.Since(start).Round(time.Millisecond))
+
+	if err != nil {
+		log.Warning("Error while forwarding the response of %s %s: %s\n", method, path, err.Error())
+	}
+}
+
+// Errors follow the format used by the OpenAI API so that tools can report them.
+func writeError(w http.ResponseWriter, status int, message string) {
+	buf, _ := json.Marshal(map[string]any{
+		"error": map[string]any{
+			"message": message,
+			"type":    "go_gpt_cli_error",
+		},
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(buf)
+}
@@ -0,0 +1,275 @@
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/app"
+	"github.com/ephex2/go-gpt-cli/chat"
+	"github.com/ephex2/go-gpt-cli/mock"
+)
+
+const testToken = "local-secret"
+
+// Installs an in-memory runtime whose requests are forwarded to a mock server, closed when the test ends.
+func newTestRuntime(t *testing.T, handler http.Handler) *app.Runtime {
+	t.Helper()
+
+	upstream := httptest.NewServer(handler)
+	t.Cleanup(upstream.Close)
+
+	rt, err := app.NewMemory(map[string]string{"BaseUrl": upstream.URL, "ApiKey": "sk-test"}, upstream.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt.Install()
+	return rt
+}
+
+// Stores a chat profile whose body holds the defaults applied by the server.
+func createChatProfile(t *testing.T, rt *app.Runtime, name string, body chat.CreateCompletionBody) {
+	t.Helper()
+
+	e, err := rt.Registry.Get("chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = rt.Repository.Create(e, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = rt.Repository.Update(chat.ChatProfile{ProfileName: name, CreateCompletionBody: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newRequest(path string, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	r.Host = "127.0.0.1:8081"
+	r.Header.Set("Authorization", "Bearer "+testToken)
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+// Returns the json body of the last request received by the mock server.
+func lastBody(t *testing.T, m *mock.Server) (body map[string]any) {
+	t.Helper()
+
+	requests := m.Requests()
+	if len(requests) == 0 {
+		t.Fatal("expected the request to be forwarded")
+	}
+
+	err := json.Unmarshal(requests[len(requests)-1].Body, &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func TestRefusesRequests(t *testing.T) {
+	m := mock.NewServer(mock.Options{})
+	newTestRuntime(t, m)
+	s := NewServer(Options{Token: testToken})
+
+	missing := newRequest("/v1/models", "")
+	missing.Header.Del("Authorization")
+
+	wrong := newRequest("/v1/models", "")
+	wrong.Header.Set("Authorization", "Bearer sk-guess")
+
+	rebound := newRequest("/v1/models", "")
+	rebound.Host = "attacker.example:8081"
+
+	tests := []struct {
+		name   string
+		r      *http.Request
+		status int
+	}{
+		{"missing token", missing, http.StatusUnauthorized},
+		{"wrong token", wrong, http.StatusUnauthorized},
+		{"non-loopback host", rebound, http.StatusForbidden},
+		{"profile name in path", newRequest("/profiles/../v1/chat/completions", `{}`), http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, test.r)
+		if w.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d: %s", test.name, test.status, w.Code, w.Body)
+		}
+	}
+
+	header := newRequest("/v1/chat/completions", `{}`)
+	header.Header.Set(ProfileHeader, `..\chat`)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, header)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("profile name in header: expected status 400, got %d", w.Code)
+	}
+
+	if len(m.Requests()) != 0 {
+		t.Errorf("expected no request to be forwarded, got: %v", m.Requests())
+	}
+
+	// Without a token every request is refused
+	w = httptest.NewRecorder()
+	NewServer(Options{}).ServeHTTP(w, newRequest("/v1/models", ""))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected requests to be refused without a token, got %d", w.Code)
+	}
+}
+
+func TestSelectsProfile(t *testing.T) {
+	m := mock.NewServer(mock.Options{})
+	rt := newTestRuntime(t, m)
+	createChatProfile(t, rt, "coding", chat.CreateCompletionBody{Model: "gpt-4o"})
+	createChatProfile(t, rt, "writing", chat.CreateCompletionBody{Model: "gpt-4o-mini"})
+
+	tests := []struct {
+		name     string
+		options  Options
+		path     string
+		header   string
+		expected string
+	}{
+		{"path", Options{}, "/profiles/coding/v1/chat/completions", "", "gpt-4o"},
+		{"header", Options{}, "/v1/chat/completions", "writing", "gpt-4o-mini"},
+		{"path over header", Options{}, "/profiles/coding/v1/chat/completions", "writing", "gpt-4o"},
+		{"server profile", Options{Profile: "writing"}, "/v1/chat/completions", "", "gpt-4o-mini"},
+		// The first profile created for an endpoint becomes its default profile
+		{"default profile", Options{}, "/v1/chat/completions", "", "gpt-4o"},
+	}
+
+	for _, test := range tests {
+		test.options.Token = testToken
+		r := newRequest(test.path, `{"messages": [{"role": "user", "content": "Hello"}]}`)
+		if test.header != "" {
+			r.Header.Set(ProfileHeader, test.header)
+		}
+
+		w := httptest.NewRecorder()
+		NewServer(test.options).ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", test.name, w.Code, w.Body)
+		}
+
+		if model := lastBody(t, m)["model"]; model != test.expected {
+			t.Errorf("%s: expected the model %s, got %v", test.name, test.expected, model)
+		}
+
+		if route := m.Requests()[len(m.Requests())-1].Route; route != "/v1/chat/completions" {
+			t.Errorf("%s: expected the profile prefix to be removed, got %s", test.name, route)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	NewServer(Options{Token: testToken}).ServeHTTP(w, newRequest("/profiles/missing/v1/chat/completions", `{}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown profile to be refused, got %d", w.Code)
+	}
+}
+
+func TestMergesDefaults(t *testing.T) {
+	m := mock.NewServer(mock.Options{})
+	rt := newTestRuntime(t, m)
+
+	temperature := 0.2
+	createChatProfile(t, rt, "coding", chat.CreateCompletionBody{
+		Model:       "gpt-4o",
+		Temperature: &temperature,
+		Messages: []chat.Message{
+			{Role: "system", Content: "You are a code reviewer."},
+			{Role: "user", Content: "A previous question of the history"},
+		},
+	})
+
+	s := NewServer(Options{Token: testToken, Profile: "coding"})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, newRequest("/v1/chat/completions", `{"temperature": 1, "messages": [{"role": "user", "content": "Hello"}]}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body)
+	}
+
+	body := lastBody(t, m)
+	if body["model"] != "gpt-4o" || body["temperature"] != 1.0 {
+		t.Errorf("expected the model of the profile and the temperature of the request, got: %v", body)
+	}
+
+	messages, _ := body["messages"].([]any)
+	if len(messages) != 2 || messages[0].(map[string]any)["role"] != "system" || messages[1].(map[string]any)["content"] != "Hello" {
+		t.Errorf("expected the system message of the profile before the request's messages, got: %v", messages)
+	}
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, newRequest("/v1/chat/completions", `{"messages": [{"role": "system", "content": "Be brief."}, {"role": "user", "content": "Hello"}]}`))
+	messages, _ = lastBody(t, m)["messages"].([]any)
+	if len(messages) != 2 || messages[0].(map[string]any)["content"] != "Be brief." {
+		t.Errorf("expected the system message of the request to be kept alone, got: %v", messages)
+	}
+}
+
+func TestRecordsStreamedUsage(t *testing.T) {
+	m := mock.NewServer(mock.Options{})
+	rt := newTestRuntime(t, m)
+	s := NewServer(Options{Token: testToken})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, newRequest("/v1/chat/completions", `{"model": "gpt-4o", "stream": true, "messages": [{"role": "user", "content": "Hello"}]}`))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "data: [DONE]") {
+		t.Fatalf("expected the events to be forwarded, got %d: %s", w.Code, w.Body)
+	}
+
+	records, err := rt.Ledger.Read(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 1 || records[0].Model != "gpt-4o" || records[0].CompletionTokens == 0 {
+		t.Errorf("expected the streamed completion to be recorded with its chunks counted, got: %+v", records)
+	}
+}
+
+func TestSummarizeEvents(t *testing.T) {
+	events := strings.Join([]string{
+		`data: {"model": "gpt-4o", "choices": [{"delta": {"role": "assistant", "content": ""}}]}`,
+		`data: {"model": "gpt-4o", "choices": [{"delta": {"content": "Hello"}}]}`,
+		`data: {"model": "gpt-4o", "choices": [{"delta": {"content": " there"}}]}`,
+		`data: {"model": "gpt-4o", "choices": [{"delta": {}, "finish_reason": "stop"}]}`,
+	}, "\n\n")
+
+	tests := []struct {
+		name     string
+		events   string
+		expected tokenUsage
+	}{
+		{"counted", events + "\n\ndata: [DONE]\n\n", tokenUsage{CompletionTokens: 2, TotalTokens: 2}},
+		{"reported", events + "\n\n" + `data: {"model": "gpt-4o", "choices": [], "usage": {"prompt_tokens": 9, "completion_tokens": 3, "total_tokens": 12}}` + "\n\ndata: [DONE]\n\n", tokenUsage{PromptTokens: 9, CompletionTokens: 3, TotalTokens: 12}},
+	}
+
+	for _, test := range tests {
+		var summary struct {
+			Model string     `json:"model"`
+			Usage tokenUsage `json:"usage"`
+		}
+
+		err := json.Unmarshal(summarizeEvents([]byte(test.events)), &summary)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if summary.Model != "gpt-4o" || summary.Usage != test.expected {
+			t.Errorf("%s: expected the usage %+v of gpt-4o, got: %+v", test.name, test.expected, summary)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/config"
+)
+
+const monthlyBudgetKeyName string = "MonthlyBudget"
+
+// Fraction of the monthly budget from which every call warns that the budget is approached.
+const budgetWarningThreshold = 0.8
+
+// Monthly budget in USD, 0 when none is set.
+func MonthlyBudget() float64 {
+	value, _ := config.Lookup(monthlyBudgetKeyName)
+	budget, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+
+	return budget
+}
+
+// Sets the monthly budget in USD of the active context, or the global one when no context is active. A budget of 0
+// removes it.
+func SetMonthlyBudget(budget float64) (err error) {
+	if budget < 0 {
+		err = errors.New("the monthly budget can not be negative")
+		return
+	}
+
+	value := ""
+	if budget > 0 {
+		value = strconv.FormatFloat(budget, 'f', -1, 64)
+	}
+
+	err = config.StoreSetting(monthlyBudgetKeyName, value)
+	return
+}
+
+// Returns the first instant of the month of t, in t's location.
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// Returns the cost of the calls made during the month of t.
+func MonthCost(t time.Time) (cost float64, err error) {
+	records, err := Read(MonthStart(t), MonthStart(t).AddDate(0, 1, 0))
+	if err != nil {
+		return
+	}
+
+	for _, r := range records {
+		cost += r.Cost
+	}
+
+	return
+}
+
+// Returns a warning when the cost of the month of t approaches or exceeds the monthly budget, an empty string otherwise.
+func CheckBudget(t time.Time) (warning string, err error) {
+	budget := MonthlyBudget()
+	if budget == 0 {
+		return
+	}
+
+	cost, err := MonthCost(t)
+	if err != nil {
+		return
+	}
+
+	if cost >= budget {
+		warning = fmt.Sprintf("Monthly budget exceeded: $%.2f spent of $%.2f", cost, budget)
+	} else if cost >= budget*budgetWarningThreshold {
+		warning = fmt.Sprintf("Monthly budget almost reached: $%.2f spent of $%.2f (%.0f%%)", cost, budget, cost/budget*100)
+	}
+
+	return
+}
@@ -0,0 +1,218 @@
+package usage
+
+// The usage package keeps a ledger of the billable calls made to the API, along with their cost computed from a price table.
+// Records are appended to a json lines file in the data directory, or to the storage selected by the repository, which
+// the usage report command aggregates.
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/config"
+)
+
+const ledgerFileName = "usage.jsonl"
+
+// Model used by the API when image requests do not set one.
+const defaultImageModel = "dall-e-2"
+
+// Routes whose calls are billed, others (files, models, batches...) are not recorded.
+var billableRoutes = []string{
+	"/v1/chat/completions",
+	"/v1/embeddings",
+	"/v1/audio/",
+	"/v1/images/",
+}
+
+type Record struct {
+	Time             time.Time `json:"time"`
+	Endpoint         string    `json:"endpoint"`
+	Profile          string    `json:"profile"`
+	Route            string    `json:"route"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	AudioSeconds     float64   `json:"audio_seconds,omitempty"`
+	Characters       int       `json:"characters,omitempty"` // Characters of text converted to speech
+	Images           int       `json:"images,omitempty"`
+	Size             string    `json:"size,omitempty"` // Size of generated images, which their price depends on
+	Cost             float64   `json:"cost"`
+}
+
+func Billable(route string) bool {
+	for _, prefix := range billableRoutes {
+		if strings.HasPrefix(route, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Builds the record of a call from the parameters of its request and the body of its response.
+// Responses which are not json (speech, text transcriptions) only provide what can be read from the request.
+func FromResponse(endpointName string, profileName string, route string, params map[string]any, resBody []byte) (r Record) {
+	var res struct {
+		Model string `json:"model"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+		Duration float64           `json:"duration"`
+		Data     []json.RawMessage `json:"data"`
+	}
+
+	json.Unmarshal(resBody, &res)
+
+	r = Record{
+		Time:             time.Now(),
+		Endpoint:         endpointName,
+		Profile:          profileName,
+		Route:            route,
+		Model:            res.Model,
+		PromptTokens:     res.Usage.PromptTokens,
+		CompletionTokens: res.Usage.CompletionTokens,
+		AudioSeconds:     res.Duration,
+	}
+
+	if r.Model == "" {
+		r.Model, _ = params["model"].(string)
+	}
+
+	switch {
+	case strings.HasPrefix(route, "/v1/audio/speech"):
+		input, _ := params["input"].(string)
+		r.Characters = len([]rune(input))
+	case strings.HasPrefix(route, "/v1/images/"):
+		r.Images = len(res.Data)
+		r.Size, _ = params["size"].(string)
+		if r.Model == "" {
+			r.Model = defaultImageModel
+		}
+	}
+
+	r.Cost = Cost(r)
+	return
+}
+
+// Stores the records of the ledger.
+type Ledger interface {
+	Append(r Record) error
+	// Returns the records made between since and until. Zero times leave the range open.
+	Read(since time.Time, until time.Time) ([]Record, error)
+}
+
+// The ledger records are stored in, set by the repository when another storage than files is selected.
+var RuntimeLedger Ledger = FileLedger{}
+
+// Appends a record to the ledger. A warning is returned when the monthly budget is approached or exceeded.
+func Append(r Record) (warning string, err error) {
+	err = RuntimeLedger.Append(r)
+	if err != nil {
+		return
+	}
+
+	warning, err = CheckBudget(r.Time)
+	return
+}
+
+// Reads the records of the ledger made between since and until. Zero times leave the range open.
+func Read(since time.Time, until time.Time) (records []Record, err error) {
+	return RuntimeLedger.Read(since, until)
+}
+
+// Returns whether a record was made between since and until.
+func inRange(r Record, since time.Time, until time.Time) bool {
+	return (since.IsZero() || !r.Time.Before(since)) && (until.IsZero() || r.Time.Before(until))
+}
+
+func LedgerPath() string {
+	return filepath.Join(config.DataDir, ledgerFileName)
+}
+
+// Keeps the records in a json lines file of the data directory.
+type FileLedger struct{}
+
+func (FileLedger) Append(r Record) (err error) {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+
+	f, err := os.OpenFile(LedgerPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(buf, '\n'))
+	return
+}
+
+func (FileLedger) Read(since time.Time, until time.Time) (records []Record, err error) {
+	f, err := os.Open(LedgerPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var r Record
+		err = json.Unmarshal(line, &r)
+		if err != nil {
+			err = errors.New("invalid record in usage ledger " + LedgerPath() + ": " + err.Error())
+			return
+		}
+
+		if inRange(r, since, until) {
+			records = append(records, r)
+		}
+	}
+
+	err = scanner.Err()
+	return
+}
+
+// Keeps the records in memory, for runtimes which touch no file.
+type MemoryLedger struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (l *MemoryLedger) Append(r Record) (err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.records = append(l.records, r)
+	return
+}
+
+func (l *MemoryLedger) Read(since time.Time, until time.Time) (records []Record, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, r := range l.records {
+		if inRange(r, since, until) {
+			records = append(records, r)
+		}
+	}
+
+	return
+}
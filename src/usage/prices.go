@@ -0,0 +1,126 @@
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ephex2/go-gpt-cli/config"
+)
+
+const pricesFileName = "prices.json"
+
+// Prices are in USD. Token and character prices are per million, so that they can be copied from the pricing page as-is.
+type Price struct {
+	Prompt      float64 `json:"prompt,omitempty"`       // Per million prompt tokens
+	Completion  float64 `json:"completion,omitempty"`   // Per million completion tokens
+	AudioSecond float64 `json:"audio_second,omitempty"` // Per second of transcribed or translated audio
+	Character   float64 `json:"character,omitempty"`    // Per million characters converted to speech
+	Image       float64 `json:"image,omitempty"`        // Per generated image
+}
+
+// Prices used when the price file does not override them. Keys are model names, or model:size for images.
+// Models are matched on their longest prefix, so that dated versions (ex: gpt-4o-2024-08-06) use the price of their family.
+var defaultPrices = map[string]Price{
+	"gpt-3.5-turbo":          {Prompt: 0.5, Completion: 1.5},
+	"gpt-4":                  {Prompt: 30, Completion: 60},
+	"gpt-4-turbo":            {Prompt: 10, Completion: 30},
+	"gpt-4-vision-preview":   {Prompt: 10, Completion: 30},
+	"gpt-4o":                 {Prompt: 2.5, Completion: 10},
+	"gpt-4o-mini":            {Prompt: 0.15, Completion: 0.6},
+	"text-embedding-ada-002": {Prompt: 0.1},
+	"text-embedding-3-small": {Prompt: 0.02},
+	"text-embedding-3-large": {Prompt: 0.13},
+	"whisper-1":              {AudioSecond: 0.0001},
+	"tts-1":                  {Character: 15},
+	"tts-1-hd":               {Character: 30},
+	"dall-e-2":               {Image: 0.02},
+	"dall-e-2:512x512":       {Image: 0.018},
+	"dall-e-2:256x256":       {Image: 0.016},
+	"dall-e-3":               {Image: 0.04},
+	"dall-e-3:1024x1792":     {Image: 0.08},
+	"dall-e-3:1792x1024":     {Image: 0.08},
+}
+
+func PricesPath() string {
+	return filepath.Join(config.ConfigDir, pricesFileName)
+}
+
+// Returns the default prices, overridden by the ones of the price file when it exists.
+func Prices() (prices map[string]Price, err error) {
+	prices = make(map[string]Price)
+	for k, v := range defaultPrices {
+		prices[k] = v
+	}
+
+	buf, err := os.ReadFile(PricesPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return prices, nil
+	}
+
+	if err != nil {
+		return
+	}
+
+	var overrides map[string]Price
+	err = json.Unmarshal(buf, &overrides)
+	if err != nil {
+		err = errors.New("unable to parse price file " + PricesPath() + ": " + err.Error())
+		return
+	}
+
+	for k, v := range overrides {
+		prices[k] = v
+	}
+
+	return
+}
+
+// Finds the price of a model, trying model:size first for images.
+func lookup(prices map[string]Price, model string, size string) (price Price, ok bool) {
+	if size != "" {
+		if price, ok = lookup(prices, model+":"+size, ""); ok {
+			return
+		}
+	}
+
+	if price, ok = prices[model]; ok {
+		return
+	}
+
+	var keys []string
+	for k := range prices {
+		if strings.HasPrefix(model, k) {
+			keys = append(keys, k)
+		}
+	}
+
+	if len(keys) == 0 {
+		return
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	return prices[keys[0]], true
+}
+
+// Computes the cost of a record. Records of models missing from the price table cost 0.
+func Cost(r Record) float64 {
+	prices, err := Prices()
+	if err != nil {
+		prices = defaultPrices
+	}
+
+	price, ok := lookup(prices, r.Model, r.Size)
+	if !ok {
+		return 0
+	}
+
+	return float64(r.PromptTokens)*price.Prompt/1e6 +
+		float64(r.CompletionTokens)*price.Completion/1e6 +
+		r.AudioSeconds*price.AudioSecond +
+		float64(r.Characters)*price.Character/1e6 +
+		float64(r.Images)*price.Image
+}
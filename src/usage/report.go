@@ -0,0 +1,88 @@
+package usage
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+const (
+	GroupByDay      = "day"
+	GroupByMonth    = "month"
+	GroupByProfile  = "profile"
+	GroupByModel    = "model"
+	GroupByEndpoint = "endpoint"
+)
+
+var GroupByValues = []string{GroupByDay, GroupByMonth, GroupByProfile, GroupByModel, GroupByEndpoint}
+
+// Aggregated usage of the records sharing a group key.
+type Row struct {
+	Group            string
+	Requests         int
+	PromptTokens     int
+	CompletionTokens int
+	AudioSeconds     float64
+	Characters       int
+	Images           int
+	Cost             float64
+}
+
+func groupKey(r Record, groupBy string) (key string, err error) {
+	switch groupBy {
+	case GroupByDay:
+		key = r.Time.Local().Format("2006-01-02")
+	case GroupByMonth:
+		key = r.Time.Local().Format("2006-01")
+	case GroupByProfile:
+		key = r.Endpoint + "/" + r.Profile
+	case GroupByModel:
+		key = r.Model
+	case GroupByEndpoint:
+		key = r.Endpoint
+	default:
+		err = errors.New("unable to group usage by " + groupBy + ", valid values are: " + strings.Join(GroupByValues, ", "))
+	}
+
+	return
+}
+
+// Aggregates records by group, rows are sorted by group key. The total of every record is returned separately.
+func Report(records []Record, groupBy string) (rows []Row, total Row, err error) {
+	groups := make(map[string]*Row)
+	total.Group = "total"
+
+	for _, r := range records {
+		var key string
+		key, err = groupKey(r, groupBy)
+		if err != nil {
+			return
+		}
+
+		row, ok := groups[key]
+		if !ok {
+			row = &Row{Group: key}
+			groups[key] = row
+		}
+
+		row.add(r)
+		total.add(r)
+	}
+
+	for _, row := range groups {
+		rows = append(rows, *row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Group < rows[j].Group })
+	return
+}
+
+func (row *Row) add(r Record) {
+	row.Requests++
+	row.PromptTokens += r.PromptTokens
+	row.CompletionTokens += r.CompletionTokens
+	row.AudioSeconds += r.AudioSeconds
+	row.Characters += r.Characters
+	row.Images += r.Images
+	row.Cost += r.Cost
+}
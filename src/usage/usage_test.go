@@ -0,0 +1,197 @@
+package usage_test
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ephex2/go-gpt-cli/config"
+	"github.com/ephex2/go-gpt-cli/config/repository"
+	"github.com/ephex2/go-gpt-cli/usage"
+)
+
+// Keeps settings and records in memory for the duration of a test, with the settings provided.
+func useMemory(t *testing.T, settings map[string]string) {
+	t.Helper()
+
+	cfg, ledger := config.RuntimeConfig, usage.RuntimeLedger
+	t.Cleanup(func() { config.RuntimeConfig, usage.RuntimeLedger = cfg, ledger })
+
+	err := config.RuntimeConfig.Init(repository.NewMemory(settings))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usage.RuntimeLedger = &usage.MemoryLedger{}
+}
+
+func costsAbout(cost float64, expected float64) bool {
+	return math.Abs(cost-expected) < 1e-9
+}
+
+func TestCost(t *testing.T) {
+	dir := config.ConfigDir
+	config.ConfigDir = t.TempDir()
+	t.Cleanup(func() { config.ConfigDir = dir })
+
+	tests := []struct {
+		name     string
+		r        usage.Record
+		expected float64
+	}{
+		{"dated model", usage.Record{Model: "gpt-4o-2024-08-06", PromptTokens: 1e6, CompletionTokens: 1e5}, 3.5},
+		{"longest prefix", usage.Record{Model: "gpt-4o-mini", PromptTokens: 1e6}, 0.15},
+		{"image size", usage.Record{Model: "dall-e-3", Images: 2, Size: "1024x1792"}, 0.16},
+		{"default image size", usage.Record{Model: "dall-e-3", Images: 2, Size: "1024x1024"}, 0.08},
+		{"speech", usage.Record{Model: "tts-1", Characters: 1e5}, 1.5},
+		{"unknown model", usage.Record{Model: "llama3", PromptTokens: 1e6}, 0},
+	}
+
+	for _, test := range tests {
+		if cost := usage.Cost(test.r); !costsAbout(cost, test.expected) {
+			t.Errorf("%s: expected a cost of %g, got %g", test.name, test.expected, cost)
+		}
+	}
+
+	err := os.WriteFile(usage.PricesPath(), []byte(`{"llama3": {"prompt": 1}}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cost := usage.Cost(usage.Record{Model: "llama3", PromptTokens: 1e6}); !costsAbout(cost, 1) {
+		t.Errorf("expected the price file to add the model, got a cost of %g", cost)
+	}
+}
+
+func TestFromResponse(t *testing.T) {
+	r := usage.FromResponse("chat", "coding", "/v1/chat/completions", map[string]any{"model": "gpt-4o"},
+		[]byte(`{"model": "gpt-4o-2024-08-06", "usage": {"prompt_tokens": 12, "completion_tokens": 30}}`))
+	if r.Model != "gpt-4o-2024-08-06" || r.PromptTokens != 12 || r.CompletionTokens != 30 || r.Cost == 0 {
+		t.Errorf("expected the model and usage of the response, got: %+v", r)
+	}
+
+	r = usage.FromResponse("image", "default", "/v1/images/generations", map[string]any{"size": "512x512"},
+		[]byte(`{"data": [{"url": "https://example.com/1.png"}, {"url": "https://example.com/2.png"}]}`))
+	if r.Model != "dall-e-2" || r.Images != 2 || r.Size != "512x512" {
+		t.Errorf("expected two images of the default model, got: %+v", r)
+	}
+
+	r = usage.FromResponse("audio", "default", "/v1/audio/speech", map[string]any{"model": "tts-1", "input": "Héllo"}, []byte("ID3..."))
+	if r.Model != "tts-1" || r.Characters != 5 {
+		t.Errorf("expected the characters of the input to be counted, got: %+v", r)
+	}
+}
+
+func TestFileLedger(t *testing.T) {
+	dir := config.DataDir
+	config.DataDir = t.TempDir()
+	t.Cleanup(func() { config.DataDir = dir })
+
+	var l usage.FileLedger
+	records, err := l.Read(time.Time{}, time.Time{})
+	if err != nil || len(records) != 0 {
+		t.Errorf("expected a missing ledger to hold no records, got %v (%v)", records, err)
+	}
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		err = l.Append(usage.Record{Time: start.AddDate(0, i, 0), Model: "gpt-4o"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err = l.Read(start.AddDate(0, 1, 0), time.Time{})
+	if err != nil || len(records) != 2 {
+		t.Errorf("expected the records since April, got %v (%v)", records, err)
+	}
+
+	records, err = l.Read(start, start.AddDate(0, 1, 0))
+	if err != nil || len(records) != 1 || !records[0].Time.Equal(start) {
+		t.Errorf("expected the records of March, got %v (%v)", records, err)
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	useMemory(t, map[string]string{"MonthlyBudget": "10"})
+	now := time.Now()
+
+	tests := []struct {
+		cost     float64
+		expected string
+	}{
+		{5, ""},
+		{3.5, "almost reached"},
+		{2, "exceeded"},
+	}
+
+	for _, test := range tests {
+		warning, err := usage.Append(usage.Record{Time: now, Cost: test.cost})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.expected == "" && warning != "" || !strings.Contains(warning, test.expected) {
+			t.Errorf("expected a warning containing %q, got %q", test.expected, warning)
+		}
+	}
+
+	// Calls of other months do not count
+	warning, err := usage.CheckBudget(usage.MonthStart(now).AddDate(0, 1, 0))
+	if err != nil || warning != "" {
+		t.Errorf("expected no warning for the next month, got %q (%v)", warning, err)
+	}
+}
+
+func TestSetMonthlyBudget(t *testing.T) {
+	useMemory(t, nil)
+
+	err := usage.SetMonthlyBudget(-1)
+	if err == nil {
+		t.Error("expected a negative budget to be refused")
+	}
+
+	err = usage.SetMonthlyBudget(25.5)
+	if err != nil || usage.MonthlyBudget() != 25.5 {
+		t.Errorf("expected a budget of 25.5, got %g (%v)", usage.MonthlyBudget(), err)
+	}
+
+	err = usage.SetMonthlyBudget(0)
+	if err != nil || usage.MonthlyBudget() != 0 {
+		t.Errorf("expected the budget to be removed, got %g (%v)", usage.MonthlyBudget(), err)
+	}
+
+	// The budget of a context is stored in it, its removal falls back to the global budget
+	err = usage.SetMonthlyBudget(100)
+	if err == nil {
+		err = config.CreateContext(config.Context{Name: "team"})
+	}
+
+	if err == nil {
+		err = config.UseContext("team")
+	}
+
+	if err == nil {
+		err = usage.SetMonthlyBudget(20)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.RuntimeConfig.Settings["MonthlyBudget"] != "100" || usage.MonthlyBudget() != 20 {
+		t.Errorf("expected a budget of 20 in the context over a global budget of 100, got %g: %v", usage.MonthlyBudget(), config.RuntimeConfig.Settings)
+	}
+
+	err = usage.SetMonthlyBudget(0)
+	if err != nil || usage.MonthlyBudget() != 100 {
+		t.Errorf("expected the global budget once the one of the context is removed, got %g (%v)", usage.MonthlyBudget(), err)
+	}
+
+	stored, err := config.RuntimeConfig.Repository.Get()
+	if err != nil || stored.Settings["MonthlyBudget"] != "100" {
+		t.Errorf("expected the budget to be stored, got: %v (%v)", stored.Settings, err)
+	}
+}